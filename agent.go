@@ -107,7 +107,8 @@ type APIRequest[In, Out any] struct {
 	methodName          string
 	effectiveCanisterID principal.Principal
 	requestID           RequestID
-	data                []byte
+	// request is signed when it is sent, with the context of the call.
+	request Request
 }
 
 // CreateAPIRequest creates a new api request to the given canister and method using
@@ -135,7 +136,7 @@ func CreateAPIRequest[In, Out any](
 	if err != nil {
 		return nil, err
 	}
-	requestID, data, err := a.sign(Request{
+	request := Request{
 		Type:          typ,
 		Sender:        a.Sender(),
 		CanisterID:    canisterID,
@@ -143,9 +144,6 @@ func CreateAPIRequest[In, Out any](
 		Arguments:     rawArgs,
 		IngressExpiry: a.expiryDate(),
 		Nonce:         nonce,
-	})
-	if err != nil {
-		return nil, err
 	}
 	return &APIRequest[In, Out]{
 		a:                   a,
@@ -153,8 +151,8 @@ func CreateAPIRequest[In, Out any](
 		typ:                 typ,
 		methodName:          methodName,
 		effectiveCanisterID: effectiveCanisterID,
-		requestID:           *requestID,
-		data:                data,
+		requestID:           NewRequestID(request),
+		request:             request,
 	}, nil
 }
 
@@ -391,6 +389,22 @@ func (a Agent) Sender() principal.Principal {
 	return a.identity.Sender()
 }
 
+// WithIdentity returns a view of the agent that signs its requests with the given
+// identity, e.g. to act on behalf of one of many users. The view shares the client,
+// the root key and the configuration of the agent, so creating it is cheap. A nil
+// identity is the anonymous identity.
+//
+//	for _, user := range users {
+//		_ = a.WithIdentity(user).CallOneway(canisterID, "notify", nil)
+//	}
+func (a Agent) WithIdentity(id identity.Identity) *Agent {
+	if id == nil {
+		id = new(identity.AnonymousIdentity)
+	}
+	a.identity = id
+	return &a
+}
+
 func (a Agent) call(ctx context.Context, ecID principal.Principal, data []byte) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(ctx, a.ingressExpiry)
 	defer cancel()
@@ -456,7 +470,7 @@ func (a Agent) readState(ctx context.Context, ecID principal.Principal, data []b
 }
 
 func (a Agent) readStateCertificate(ctx context.Context, ecID principal.Principal, paths [][]hashtree.Label) (*certification.Certificate, error) {
-	_, data, err := a.sign(Request{
+	_, data, err := a.sign(ctx, Request{
 		Type:          RequestTypeReadState,
 		Sender:        a.Sender(),
 		Paths:         paths,
@@ -483,8 +497,8 @@ func (a Agent) readStateCertificate(ctx context.Context, ecID principal.Principa
 	return &certificate, nil
 }
 
-func (a Agent) readSubnetState(subnetID principal.Principal, data []byte) (map[string][]byte, error) {
-	ctx, cancel := context.WithTimeout(a.ctx, a.ingressExpiry)
+func (a Agent) readSubnetState(ctx context.Context, subnetID principal.Principal, data []byte) (map[string][]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.ingressExpiry)
 	defer cancel()
 	resp, err := a.client.ReadSubnetState(ctx, subnetID, data)
 	if err != nil {
@@ -494,8 +508,8 @@ func (a Agent) readSubnetState(subnetID principal.Principal, data []byte) (map[s
 	return m, cbor.Unmarshal(resp, &m)
 }
 
-func (a Agent) readSubnetStateCertificate(subnetID principal.Principal, paths [][]hashtree.Label) (*certification.Certificate, error) {
-	_, data, err := a.sign(Request{
+func (a Agent) readSubnetStateCertificate(ctx context.Context, subnetID principal.Principal, paths [][]hashtree.Label) (*certification.Certificate, error) {
+	_, data, err := a.sign(ctx, Request{
 		Type:          RequestTypeReadState,
 		Sender:        a.Sender(),
 		Paths:         paths,
@@ -505,7 +519,7 @@ func (a Agent) readSubnetStateCertificate(subnetID principal.Principal, paths []
 		return nil, err
 	}
 	a.logger.Printf("[AGENT] READ SUBNET STATE %s (subnetID)", subnetID)
-	resp, err := a.readSubnetState(subnetID, data)
+	resp, err := a.readSubnetState(ctx, subnetID, data)
 	if err != nil {
 		return nil, err
 	}
@@ -532,9 +546,9 @@ func (a Agent) requestStatus(ctx context.Context, ecID principal.Principal, requ
 	return handleStatus(path, certificate)
 }
 
-func (a Agent) sign(request Request) (*RequestID, []byte, error) {
+func (a Agent) sign(ctx context.Context, request Request) (*RequestID, []byte, error) {
 	requestID := NewRequestID(request)
-	sig, err := requestID.Sign(a.identity)
+	sig, err := requestID.SignWithContext(ctx, a.identity)
 	if err != nil {
 		return nil, nil, err
 	}
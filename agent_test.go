@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -193,6 +194,113 @@ func TestAgent_CallWithContext_cancelledDuringPoll(t *testing.T) {
 	}
 }
 
+func TestAgent_CallOneway(t *testing.T) {
+	// /call returns 202 and nothing else is served: a oneway call must return
+	// without polling the request status.
+	var calls, other int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/call"):
+			calls++
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			other++
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	host, _ := url.Parse(srv.URL)
+
+	a, err := agent.New(agent.Config{
+		ClientConfig: []agent.ClientOption{agent.WithHostURL(host)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CallOneway(LEDGER_PRINCIPAL, "notify", []any{"hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || other != 0 {
+		t.Errorf("expected a single call request, got %d calls and %d other requests", calls, other)
+	}
+}
+
+func TestAgent_CallOnewayWithContext_signer(t *testing.T) {
+	// The request is signed with the context of the call, so a signer that does not
+	// respond can be cancelled.
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	host, _ := url.Parse(srv.URL)
+
+	id, _ := identity.NewRandomEd25519Identity()
+	remote, err := identity.NewRemoteIdentity(context.Background(), blockingSigner{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := agent.New(agent.Config{
+		Identity:     remote,
+		ClientConfig: []agent.ClientOption{agent.WithHostURL(host)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.CallOnewayWithContext(ctx, LEDGER_PRINCIPAL, "notify", []any{"hello"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the signer to be cancelled, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests, got %d", requests)
+	}
+}
+
+func TestAgent_WithIdentity(t *testing.T) {
+	// Every call is signed by the identity of the view it is sent by.
+	var senders []principal.Principal
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req, err := agent.VerifyEnvelope(body, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		senders = append(senders, req.Sender)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	host, _ := url.Parse(srv.URL)
+
+	a, err := agent.New(agent.Config{
+		ClientConfig: []agent.ClientOption{agent.WithHostURL(host)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, _ := identity.NewRandomEd25519Identity()
+	bob, _ := identity.NewRandomSecp256k1Identity()
+	for _, view := range []*agent.Agent{a, a.WithIdentity(alice), a.WithIdentity(bob), a.WithIdentity(alice).WithIdentity(nil)} {
+		if err := view.CallOneway(LEDGER_PRINCIPAL, "notify", []any{"hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []principal.Principal{principal.AnonymousID, alice.Sender(), bob.Sender(), principal.AnonymousID}
+	if len(senders) != len(expected) {
+		t.Fatalf("expected %d calls, got %d", len(expected), len(senders))
+	}
+	for i, sender := range senders {
+		if !sender.Equal(expected[i]) {
+			t.Errorf("call %d: expected sender %s, got %s", i, expected[i], sender)
+		}
+	}
+	if !a.Sender().Equal(principal.AnonymousID) {
+		t.Error("the identity of the agent changed")
+	}
+}
+
 func TestAgent_DiscoverRoutes_RoundTrip(t *testing.T) {
 	a, err := agent.New(agent.DefaultConfig)
 	if err != nil {
@@ -514,3 +622,18 @@ type testLogger struct{}
 func (t testLogger) Printf(format string, v ...any) {
 	fmt.Printf("[TEST]"+format+"\n", v...)
 }
+
+// blockingSigner has the public key of the identity, but never signs: it waits until
+// the context is done.
+type blockingSigner struct {
+	id identity.Identity
+}
+
+func (s blockingSigner) PublicKey(context.Context) ([]byte, error) {
+	return s.id.PublicKey(), nil
+}
+
+func (s blockingSigner) Sign(ctx context.Context, _ []byte) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
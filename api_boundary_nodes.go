@@ -67,6 +67,7 @@ type APIBoundaryNode struct {
 func (a Agent) GetAPIBoundaryNodes() ([]APIBoundaryNode, error) {
 	root := []hashtree.Label{hashtree.Label("api_boundary_nodes")}
 	cert, err := a.readSubnetStateCertificate(
+		a.ctx,
 		principal.MustDecode(certification.RootSubnetID),
 		[][]hashtree.Label{root},
 	)
@@ -20,7 +20,11 @@ func (c APIRequest[_, Out]) CallAndWait(out Out) error {
 // in-flight update call.
 func (c APIRequest[_, Out]) CallAndWaitWithContext(ctx context.Context, out Out) error {
 	c.a.logger.Printf("[AGENT] CALL %s %s (%x)", c.effectiveCanisterID, c.methodName, c.requestID)
-	rawCertificate, err := c.a.call(ctx, c.effectiveCanisterID, c.data)
+	data, err := c.envelope(ctx)
+	if err != nil {
+		return err
+	}
+	rawCertificate, err := c.a.call(ctx, c.effectiveCanisterID, data)
 	if err != nil {
 		return err
 	}
@@ -60,6 +64,37 @@ func (c APIRequest[_, Out]) CallAndWaitWithContext(ctx context.Context, out Out)
 	return c.unmarshal(raw, out)
 }
 
+// RequestID returns the ID of the request, e.g. to poll for its status later, see
+// Agent.RequestStatus.
+func (c APIRequest[_, _]) RequestID() RequestID {
+	return c.requestID
+}
+
+// Send submits the call without waiting for its result. It is meant for oneway
+// methods, which never reply, so it only reports errors of the submission itself.
+func (c APIRequest[_, _]) Send() error {
+	return c.SendWithContext(c.a.ctx)
+}
+
+// SendWithContext is like Send but uses the given context as the parent of the
+// request timeout.
+func (c APIRequest[_, _]) SendWithContext(ctx context.Context) error {
+	c.a.logger.Printf("[AGENT] SEND %s %s (%x)", c.effectiveCanisterID, c.methodName, c.requestID)
+	data, err := c.envelope(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.a.call(ctx, c.effectiveCanisterID, data)
+	return err
+}
+
+// envelope returns the signed request. It is signed with the context of the call,
+// so that a slow signer, e.g. a RemoteIdentity, can be cancelled.
+func (c APIRequest[_, _]) envelope(ctx context.Context) ([]byte, error) {
+	_, data, err := c.a.sign(ctx, c.request)
+	return data, err
+}
+
 // Call calls a method on a canister and unmarshals the result into the given values.
 func (a Agent) Call(canisterID principal.Principal, methodName string, in []any, out []any) error {
 	call, err := a.CreateCandidAPIRequest(RequestTypeCall, canisterID, methodName, in...)
@@ -69,6 +104,22 @@ func (a Agent) Call(canisterID principal.Principal, methodName string, in []any,
 	return call.CallAndWait(out)
 }
 
+// CallOneway calls a oneway method on a canister. It does not wait for the call to be
+// executed, since oneway methods do not reply.
+func (a Agent) CallOneway(canisterID principal.Principal, methodName string, in []any) error {
+	return a.CallOnewayWithContext(a.ctx, canisterID, methodName, in)
+}
+
+// CallOnewayWithContext is like CallOneway but uses the given context as the parent
+// of the request timeout.
+func (a Agent) CallOnewayWithContext(ctx context.Context, canisterID principal.Principal, methodName string, in []any) error {
+	call, err := a.CreateCandidAPIRequest(RequestTypeCall, canisterID, methodName, in...)
+	if err != nil {
+		return err
+	}
+	return call.SendWithContext(ctx)
+}
+
 // CallProto calls a method on a canister and unmarshals the result into the given proto message.
 func (a Agent) CallProto(canisterID principal.Principal, methodName string, in, out proto.Message) error {
 	call, err := a.CreateProtoAPIRequest(RequestTypeCall, canisterID, methodName, in)
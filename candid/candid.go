@@ -44,19 +44,41 @@ func DecodeValuesString(types []idl.Type, values []any) (string, error) {
 
 // EncodeValueString encodes the given candid string into a byte slice.
 func EncodeValueString(value string) ([]byte, error) {
-	p, err := cvalue.NewParser([]rune(value))
+	types, args, err := parseValueString(value)
 	if err != nil {
 		return nil, err
 	}
-	n, err := p.ParseEOF(cvalue.Values)
+	return Encode(types, args)
+}
+
+// ParseValueString parses the given candid string into values. The types of the
+// values are inferred from the string, e.g. `1` is an int and `1 : nat8` a nat8.
+func ParseValueString(value string) ([]idl.Value, error) {
+	types, args, err := parseValueString(value)
 	if err != nil {
 		return nil, err
 	}
-	types, args, err := did.ConvertValues(n)
+	vs := make([]idl.Value, len(types))
+	for i := range types {
+		v, err := idl.NewValue(types[i], args[i])
+		if err != nil {
+			return nil, err
+		}
+		vs[i] = v
+	}
+	return vs, nil
+}
+
+func parseValueString(value string) ([]idl.Type, []any, error) {
+	p, err := cvalue.NewParser([]rune(value))
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := p.ParseEOF(cvalue.Values)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return Encode(types, args)
+	return did.ConvertValues(n)
 }
 
 func valueToString(typ idl.Type, value any) (string, error) {
@@ -83,7 +105,11 @@ func valueToString(typ idl.Type, value any) (string, error) {
 		return "empty", nil
 	case *idl.OptionalType:
 		if value == nil {
-			return "opt null", nil
+			return "null", nil
+		}
+		if opt, ok := value.(idl.Opt[any]); ok {
+			// Present, but nil, e.g. `opt null`.
+			value, _ = opt.Get()
 		}
 		s, err := valueToString(t.Type, value)
 		if err != nil {
@@ -135,6 +161,18 @@ func valueToString(typ idl.Type, value any) (string, error) {
 	case *idl.PrincipalType:
 		p, _ := value.(principal.Principal)
 		return fmt.Sprintf("principal %q", p), nil
+	case *idl.FunctionType:
+		m, ok := value.(*idl.PrincipalMethod)
+		if !ok {
+			return "", fmt.Errorf("invalid func reference: %v", value)
+		}
+		return fmt.Sprintf("func %q.%s", m.Principal, m.Method), nil
+	case *idl.Service:
+		p, ok := value.(*principal.Principal)
+		if !ok {
+			return "", fmt.Errorf("invalid service reference: %v", value)
+		}
+		return fmt.Sprintf("service %q", *p), nil
 	default:
 		panic(fmt.Sprintf("%s, %v", typ, value))
 	}
@@ -2,6 +2,7 @@ package candid_test
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"os"
@@ -12,6 +13,29 @@ import (
 	"github.com/aviate-labs/agent-go/candid/idl"
 )
 
+func ExampleDecodeValues() {
+	desc, _ := did.ParseDID([]rune(`
+type Account = record { owner : text; balance : nat };
+service : {
+	account : (text) -> (variant { Ok : Account; Err : text }) query;
+}`))
+	raw, _ := candid.EncodeValueString(`(variant { Ok = record { owner = "aaaaa-aa"; balance = 100 : nat } })`)
+
+	vs, _ := candid.DecodeValues(raw)
+	fmt.Println(vs[0])
+	_ = desc.AnnotateResults("account", vs)
+	fmt.Println(vs[0])
+	balance, _ := vs[0].Get("Ok", "balance")
+	fmt.Println(balance)
+	j, _ := json.Marshal(vs[0])
+	fmt.Println(string(j))
+	// Output:
+	// variant { 17724 = record { 596483356 = 100 : nat; 947296307 = "aaaaa-aa" } }
+	// variant { Ok = record { balance = 100 : nat; owner = "aaaaa-aa" } }
+	// 100 : nat
+	// {"Ok":{"balance":"100","owner":"aaaaa-aa"}}
+}
+
 func ExampleEncodeValueString() {
 	e, _ := candid.EncodeValueString("0")
 	fmt.Printf("%x\n", e)
@@ -41,7 +65,8 @@ func TestDecodeValue(t *testing.T) {
 		value   string
 		encoded string
 	}{
-		{"(opt null)", "4449444c016e7f010000"},
+		{"(null)", "4449444c016e7f010000"},
+		{"(opt null)", "4449444c016e7f010001"},
 		{"(opt 0)", "4449444c016e7c01000100"},
 
 		{"(0 : nat)", "4449444c00017d00"},
@@ -119,7 +144,7 @@ func TestEncodeValue(t *testing.T) {
 		value   string
 		encoded string
 	}{
-		{"opt null", "4449444c016e7f010000"},
+		{"opt null", "4449444c016e7f010001"},
 		{"opt 0", "4449444c016e7c01000100"},
 
 		{"0", "4449444c00017c00"},
@@ -129,6 +154,7 @@ func TestEncodeValue(t *testing.T) {
 		{"(0 : nat16)", "4449444c00017a0000"},
 		{"(0 : nat32)", "4449444c00017900000000"},
 		{"(0 : nat64)", "4449444c0001780000000000000000"},
+		{"(18_446_744_073_709_551_615 : nat64)", "4449444c000178ffffffffffffffff"},
 		{"(0 : int)", "4449444c00017c00"},
 		{"(0 : int8)", "4449444c00017700"},
 		{"(0 : int16)", "4449444c0001760000"},
@@ -33,6 +33,25 @@ func Decode(bs []byte) ([]idl.Type, []any, error) {
 	return ts, vs, nil
 }
 
+// DecodeValues decodes the given candid message into dynamic values. Record and
+// variant labels are hashes, use Value.Annotate or did.Description.AnnotateResults
+// to resolve their names.
+func DecodeValues(bs []byte) ([]idl.Value, error) {
+	ts, vs, err := Decode(bs)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]idl.Value, len(ts))
+	for i := range ts {
+		v, err := idl.NewValue(ts[i], vs[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
 func Unmarshal(data []byte, values []any) error {
 	ts, r, err := decodeTypes(data)
 	if err != nil {
@@ -408,8 +427,12 @@ func (tc *typeCache) decodeFuncOpCode(r *bytes.Reader, tds []idl.Type) (idl.Type
 		return nil, err
 	}
 	var anns []string
-	if len(ann) != 0 {
-		anns = append(anns, string(ann))
+	for _, b := range ann {
+		a, err := idl.FunctionAnnotation(b)
+		if err != nil {
+			return nil, err
+		}
+		anns = append(anns, a)
 	}
 
 	return &idl.FunctionType{
@@ -0,0 +1,299 @@
+package did
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CheckCompatibility checks whether the (first) service of the description can
+// safely replace the (first) service of the previous description, i.e. whether it
+// is a subtype of the previous service according to the candid subtyping rules.
+// All incompatible methods are reported.
+func (p Description) CheckCompatibility(prev Description) error {
+	if len(p.Services) == 0 || len(prev.Services) == 0 {
+		return fmt.Errorf("no service declared")
+	}
+	c := compatChecker{
+		descs:   [2]Description{p, prev},
+		assumed: make(map[compatPair]bool),
+	}
+	return c.service(0, p.Services[0], prev.Services[0])
+}
+
+// compatChecker checks whether data types of one description are subtypes of data
+// types of the other description. The side (0 or 1) indicates the description of
+// the subtype, the supertype belongs to the other description.
+type compatChecker struct {
+	descs [2]Description
+	// assumed contains the pairs of (recursive) types that are being checked, which
+	// are assumed to be subtypes of each other.
+	assumed map[compatPair]bool
+}
+
+// function checks whether function a is a subtype of function b. Arguments are
+// contravariant, results are covariant.
+func (c *compatChecker) function(side int, a, b Func) error {
+	if annotation(a) != annotation(b) {
+		return fmt.Errorf("annotation %q is not compatible with %q", annotation(a), annotation(b))
+	}
+	if err := c.tuple(1-side, b.ArgTypes, a.ArgTypes, "argument"); err != nil {
+		return err
+	}
+	return c.tuple(side, a.ResTypes, b.ResTypes, "result")
+}
+
+// labels returns the data types of the fields, indexed by their labels.
+func (c *compatChecker) labels(fields []Field, variant bool) (map[string]Data, error) {
+	m := make(map[string]Data)
+	for i, f := range fields {
+		label, data, err := fieldData(f, i, variant)
+		if err != nil {
+			return nil, err
+		}
+		m[label] = data
+	}
+	return m, nil
+}
+
+// optional returns whether the data type can be omitted, i.e. whether it is opt,
+// null or reserved.
+func (c *compatChecker) optional(side int, data Data) (bool, error) {
+	data, err := c.resolve(side, data)
+	if err != nil {
+		return false, err
+	}
+	switch data := data.(type) {
+	case Optional:
+		return true, nil
+	case Primitive:
+		return data == "null" || data == "reserved", nil
+	}
+	return false, nil
+}
+
+// record checks whether record a is a subtype of record b. Fields of b that are
+// missing in a must be optional.
+func (c *compatChecker) record(side int, a, b Record) error {
+	fields, err := c.labels(a, false)
+	if err != nil {
+		return err
+	}
+	for i, f := range b {
+		label, data, err := fieldData(f, i, false)
+		if err != nil {
+			return err
+		}
+		sub, ok := fields[label]
+		if !ok {
+			if ok, err := c.optional(1-side, data); err != nil || !ok {
+				return errors.Join(fmt.Errorf("field %s: missing", label), err)
+			}
+			continue
+		}
+		if err := c.subtype(side, sub, data); err != nil {
+			return fmt.Errorf("field %s: %w", label, err)
+		}
+	}
+	return nil
+}
+
+// resolve resolves references to type definitions.
+func (c *compatChecker) resolve(side int, data Data) (Data, error) {
+	seen := make(map[DataId]bool)
+	for {
+		id, ok := data.(DataId)
+		if !ok {
+			return data, nil
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("invalid recursive type: %s", id)
+		}
+		seen[id] = true
+		d, err := c.descs[side].definition(string(id))
+		if err != nil {
+			return nil, err
+		}
+		data = d
+	}
+}
+
+// service checks whether service a is a subtype of service b, i.e. whether a
+// provides all methods of b with compatible signatures.
+func (c *compatChecker) service(side int, a, b Service) error {
+	as, err := c.descs[side].methods(a)
+	if err != nil {
+		return err
+	}
+	bs, err := c.descs[1-side].methods(b)
+	if err != nil {
+		return err
+	}
+	methods := make(map[string]Func)
+	for _, m := range as {
+		methods[m.Name] = *m.Func
+	}
+	var errs []error
+	for _, m := range bs {
+		f, ok := methods[m.Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("method %s: missing", m.Name))
+			continue
+		}
+		if err := c.function(side, f, *m.Func); err != nil {
+			errs = append(errs, fmt.Errorf("method %s: %w", m.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// subtype checks whether data type a (of the given side) is a subtype of data type
+// b (of the other side).
+func (c *compatChecker) subtype(side int, a, b Data) error {
+	_, aRef := a.(DataId)
+	_, bRef := b.(DataId)
+	if aRef || bRef {
+		pair := compatPair{side: side, a: a.String(), b: b.String()}
+		if c.assumed[pair] {
+			return nil
+		}
+		c.assumed[pair] = true
+	}
+	a, err := c.resolve(side, a)
+	if err != nil {
+		return err
+	}
+	if b, err = c.resolve(1-side, b); err != nil {
+		return err
+	}
+	switch b := b.(type) {
+	case Primitive:
+		if b == "reserved" {
+			return nil
+		}
+	case Optional:
+		// Any type is a subtype of an optional type, values that do not match are
+		// decoded as null.
+		return nil
+	}
+	if a == Primitive("empty") {
+		return nil
+	}
+	if _, ok := a.(Blob); ok {
+		a = Vector{Data: Primitive("nat8")}
+	}
+	if _, ok := b.(Blob); ok {
+		b = Vector{Data: Primitive("nat8")}
+	}
+	switch b := b.(type) {
+	case Primitive:
+		if a == b || (a == Primitive("nat") && b == "int") {
+			return nil
+		}
+	case Principal:
+		if _, ok := a.(Principal); ok {
+			return nil
+		}
+	case Vector:
+		if a, ok := a.(Vector); ok {
+			if err := c.subtype(side, a.Data, b.Data); err != nil {
+				return fmt.Errorf("vec: %w", err)
+			}
+			return nil
+		}
+	case Record:
+		if a, ok := a.(Record); ok {
+			return c.record(side, a, b)
+		}
+	case Variant:
+		if a, ok := a.(Variant); ok {
+			return c.variant(side, a, b)
+		}
+	case Func:
+		if a, ok := a.(Func); ok {
+			return c.function(side, a, b)
+		}
+	case Service:
+		if a, ok := a.(Service); ok {
+			return c.service(side, a, b)
+		}
+	}
+	return fmt.Errorf("%s is not a subtype of %s", kind(a), kind(b))
+}
+
+// tuple checks whether the tuple a is a subtype of tuple b. Additional elements of
+// a are ignored, additional elements of b must be optional.
+func (c *compatChecker) tuple(side int, a, b Tuple, name string) error {
+	for i, arg := range b {
+		if len(a) <= i {
+			if ok, err := c.optional(1-side, arg.Data); err != nil || !ok {
+				return errors.Join(fmt.Errorf("%s %d: missing", name, i), err)
+			}
+			continue
+		}
+		if err := c.subtype(side, a[i].Data, arg.Data); err != nil {
+			return fmt.Errorf("%s %d: %w", name, i, err)
+		}
+	}
+	return nil
+}
+
+// variant checks whether variant a is a subtype of variant b. All arms of a must
+// be present in b.
+func (c *compatChecker) variant(side int, a, b Variant) error {
+	arms, err := c.labels(b, true)
+	if err != nil {
+		return err
+	}
+	for i, f := range a {
+		label, data, err := fieldData(f, i, true)
+		if err != nil {
+			return err
+		}
+		sup, ok := arms[label]
+		if !ok {
+			return fmt.Errorf("variant %s: missing", label)
+		}
+		if err := c.subtype(side, data, sup); err != nil {
+			return fmt.Errorf("variant %s: %w", label, err)
+		}
+	}
+	return nil
+}
+
+// compatPair is a pair of data types, of which a belongs to the given side.
+type compatPair struct {
+	side int
+	a, b string
+}
+
+// annotation returns the annotation of the function, or an empty string.
+func annotation(f Func) FuncAnnotation {
+	if f.Annotation == nil {
+		return ""
+	}
+	return *f.Annotation
+}
+
+// kind returns a short description of the data type, to be used in errors.
+func kind(data Data) string {
+	switch data := data.(type) {
+	case Primitive:
+		return string(data)
+	case Optional:
+		return "opt"
+	case Vector:
+		return "vec"
+	case Record:
+		return "record"
+	case Variant:
+		return "variant"
+	case Func:
+		return "func"
+	case Service:
+		return "service"
+	case Principal:
+		return "principal"
+	default:
+		return data.String()
+	}
+}
@@ -0,0 +1,64 @@
+package did
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescription_CheckCompatibility(t *testing.T) {
+	prev, err := ParseDID([]rune(`type Account = record { owner : principal; subaccount : opt blob };
+type List = opt record { head : nat; tail : List };
+type Status = variant { active; frozen };
+service : {
+	balance : (Account) -> (nat) query;
+	status : () -> (Status) query;
+	list : (List) -> ();
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		did string
+		err string
+	}{
+		{did: `type Account = record { owner : principal };
+type List = opt record { head : int; tail : List };
+type Status = variant { active };
+service : {
+	balance : (Account, opt nat) -> (nat, opt text) query;
+	status : () -> (Status) query;
+	list : (List) -> ();
+	new : () -> ();
+}`},
+		{
+			did: `service : {
+	balance : (record { owner : principal; subaccount : blob }) -> (int) query;
+	status : () -> (variant { active; frozen; closed }) query;
+}`,
+			err: "method balance: argument 0: field subaccount: opt is not a subtype of vec",
+		},
+		{
+			did: `service : {
+	balance : (record { owner : principal }) -> (nat);
+	status : () -> (variant { active }) query;
+	list : (opt nat) -> ();
+}`,
+			err: `method balance: annotation "" is not compatible with "query"`,
+		},
+	} {
+		d, err := ParseDID([]rune(test.did))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.CheckCompatibility(*prev)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("expected error %q, got %v", test.err, err)
+		}
+	}
+}
@@ -26,6 +26,31 @@ func nameValue(n *parser.Node) string {
 	return v
 }
 
+// keywords are the reserved words of a DID, which can only be used as names in quotes.
+var keywords = map[string]bool{
+	"type": true, "import": true, "service": true, "func": true, "query": true, "composite_query": true,
+	"oneway": true, "opt": true, "vec": true, "record": true, "variant": true, "blob": true, "principal": true,
+	"bool": true, "text": true, "null": true, "reserved": true, "empty": true,
+	"nat": true, "nat8": true, "nat16": true, "nat32": true, "nat64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"float32": true, "float64": true,
+}
+
+// quoteName is the counterpart of nameValue, names that are no identifiers, e.g.
+// "my-method" or keywords, are quoted. Escape sequences are kept as written.
+func quoteName(name string) string {
+	if name == "" || keywords[name] {
+		return `"` + name + `"`
+	}
+	for i, r := range name {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || (i != 0 && '0' <= r && r <= '9') {
+			continue
+		}
+		return `"` + name + `"`
+	}
+	return name
+}
+
 func convertNat(n *parser.Node) *big.Int {
 	switch n := strings.ReplaceAll(n.Value(), "_", ""); {
 	case strings.HasPrefix(n, "0x"):
@@ -161,7 +186,7 @@ func (f Field) String() string {
 	if n := f.Nat; n != nil {
 		s += fmt.Sprintf("%s : ", n.String())
 	} else if f.Name != nil {
-		s += fmt.Sprintf("%s : ", *f.Name)
+		s += fmt.Sprintf("%s : ", quoteName(*f.Name))
 	}
 	if f.Data != nil {
 		d := *f.Data
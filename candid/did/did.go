@@ -15,3 +15,39 @@ func ParseDID(raw []rune) (*Description, error) {
 	did := ConvertDescription(n)
 	return &did, nil
 }
+
+// ParseArgs parses the given raw arguments of a service class and returns the type definitions and the arguments,
+// e.g. of the `candid:args` metadata of a canister:
+//
+//	type InitArgs = record { owner : principal };
+//	(opt InitArgs)
+func ParseArgs(raw []rune) (*Description, Tuple, error) {
+	p, err := candid.NewParser(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := p.ParseEOF(candid.Args)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		desc Description
+		args Tuple
+	)
+	for _, n := range n.Children() {
+		switch n.Name {
+		case candid.Type.Name:
+			desc.Definitions = append(desc.Definitions, convertType(n))
+		case candid.Import.Name:
+			desc.Definitions = append(desc.Definitions, convertImport(n))
+		case candid.TupType.Name:
+			args = convertTuple(n)
+		default:
+			if isComment(n) {
+				continue
+			}
+			panic(n)
+		}
+	}
+	return &desc, args, nil
+}
@@ -0,0 +1,82 @@
+package did
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+func TestParseArgs(t *testing.T) {
+	d, args, err := ParseArgs([]rune(`// candid:args
+type InitArgs = record { owner : principal; fee : nat };
+(opt InitArgs, text)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Definitions) != 1 || len(args) != 2 {
+		t.Fatalf("unexpected definitions or arguments: %v, %v", d.Definitions, args)
+	}
+	ts, err := d.Types(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts[0].(*idl.OptionalType); !ok {
+		t.Errorf("expected an optional type, got %T", ts[0])
+	}
+	if ts[1].String() != "text" {
+		t.Errorf("expected text, got %s", ts[1])
+	}
+
+	if _, _, err := ParseArgs([]rune(`type InitArgs = nat;`)); err == nil {
+		t.Error("expected an error without arguments")
+	}
+}
+
+func TestParseDID_initArgs(t *testing.T) {
+	for _, test := range []struct {
+		did  string
+		args string
+	}{
+		{"service : (InitArgs, opt nat) -> {\n\tget : () -> (nat) query;\n}", "(InitArgs, opt nat)"},
+		{"service : (InitArgs) -> {\n\tget : () -> (nat) query;\n}", "(InitArgs)"},
+	} {
+		d, err := ParseDID([]rune("type InitArgs = record { owner : principal };\n" + test.did))
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := d.Services[0].InitArgs
+		if len(args) == 0 || args[0].Data != DataId("InitArgs") {
+			t.Fatalf("unexpected init arguments: %v", args)
+		}
+
+		// The arguments survive a round trip, always in parentheses as required by the candid spec.
+		raw := d.String()
+		if !strings.Contains(raw, "service : "+test.args+" -> {") {
+			t.Errorf("unexpected init arguments:\n%s", raw)
+		}
+		if d, err = ParseDID([]rune(raw)); err != nil {
+			t.Fatalf("%v\n%s", err, raw)
+		}
+		if n := len(d.Services[0].InitArgs); n != len(args) {
+			t.Errorf("expected %d init arguments, got %d", len(args), n)
+		}
+	}
+}
+
+func TestDescription_String_quotedNames(t *testing.T) {
+	d, err := ParseDID([]rune(`type T = record { "a-b" : nat; "x" : text };
+service : { "my-method" : (T) -> (); "query" : ("first name" : text) -> () query }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := d.String()
+	for _, s := range []string{`"a-b" : nat`, `x : text`, `"my-method" : T -> ()`, `"query" : ("first name" : text) -> () query`} {
+		if !strings.Contains(raw, s) {
+			t.Errorf("missing %s in:\n%s", s, raw)
+		}
+	}
+	if _, err := ParseDID([]rune(raw)); err != nil {
+		t.Fatalf("%v\n%s", err, raw)
+	}
+}
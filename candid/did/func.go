@@ -33,7 +33,7 @@ func convertArgument(n *parser.Node) Argument {
 func (a Argument) String() string {
 	var s string
 	if a.Name != nil {
-		s += fmt.Sprintf("%s : ", *a.Name)
+		s += fmt.Sprintf("%s : ", quoteName(*a.Name))
 	}
 	return s + a.Data.String()
 }
@@ -137,7 +137,7 @@ func (d *Description) addType(t Type) error {
 // mergeService folds s into the single composed service, importer-wins on clashes.
 func (d *Description) mergeService(s Service) {
 	if len(d.Services) == 0 {
-		d.Services = append(d.Services, Service{ID: s.ID})
+		d.Services = append(d.Services, Service{ID: s.ID, InitArgs: s.InitArgs})
 	}
 	dst := &d.Services[0]
 	have := map[string]bool{}
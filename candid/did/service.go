@@ -2,6 +2,7 @@ package did
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/0x51-dev/upeg/parser"
 	"github.com/aviate-labs/agent-go/candid/internal/candid"
@@ -20,7 +21,7 @@ type Method struct {
 }
 
 func (m Method) String() string {
-	s := fmt.Sprintf("%s : ", m.Name)
+	s := fmt.Sprintf("%s : ", quoteName(m.Name))
 	if id := m.ID; id != nil {
 		return s + *id
 	}
@@ -42,6 +43,9 @@ func (m Method) String() string {
 type Service struct {
 	// ID represents the optional name given to the service. This only serves as documentation.
 	ID *string
+	// InitArgs are the arguments of a service class, e.g. `service : (InitArgs) -> { ... }`, with which the
+	// service is installed or upgraded.
+	InitArgs Tuple
 
 	// Methods is the list of methods that the service provides.
 	Methods []Method
@@ -62,51 +66,76 @@ func convertService(n *parser.Node) Service {
 			}
 			actor.MethodId = &id
 		case candid.TupType.Name:
+			actor.InitArgs = convertTuple(n)
 		case candid.ActorType.Name:
 			for _, n := range n.Children() {
 				if isComment(n) {
 					continue
 				}
-
-				cs := n.Children()
-
-				name := nameValue(cs[0])
-				switch n := cs[len(cs)-1]; n.Name {
-				case candid.FuncType.Name:
-					f := convertFunc(n)
-					actor.Methods = append(
-						actor.Methods,
-						Method{
-							Name: name,
-							Func: &f,
-						},
-					)
-				case candid.Id.Name, candid.Text.Name:
-					id := n.Value()
-					actor.Methods = append(
-						actor.Methods,
-						Method{
-							Name: name,
-							ID:   &id,
-						},
-					)
-				default:
-					panic(n)
-				}
+				actor.Methods = append(actor.Methods, convertMethod(n))
 			}
+		case candid.MethType.Name:
+			// The methods of a service data type, e.g. `type S = service { ... }`.
+			actor.Methods = append(actor.Methods, convertMethod(n))
 		default:
+			if isComment(n) {
+				continue
+			}
 			panic(n)
 		}
 	}
 	return actor
 }
 
+func convertMethod(n *parser.Node) Method {
+	cs := n.Children()
+	name := nameValue(cs[0])
+	switch n := cs[len(cs)-1]; n.Name {
+	case candid.FuncType.Name:
+		if id, ok := funcReference(n); ok {
+			return Method{Name: name, ID: &id}
+		}
+		f := convertFunc(n)
+		return Method{Name: name, Func: &f}
+	case candid.Id.Name, candid.Text.Name:
+		id := n.Value()
+		return Method{Name: name, ID: &id}
+	default:
+		panic(n)
+	}
+}
+
+// funcReference returns the id of a method type that references a function type
+// definition. The grammar also matches `name : id` as a function type without
+// results, which does not exist in candid.
+func funcReference(n *parser.Node) (string, bool) {
+	for range 3 { // FuncType > TupType > ArgType
+		cs := n.Children()
+		if len(cs) != 1 {
+			return "", false
+		}
+		n = cs[0]
+	}
+	if n.Name != candid.Id.Name {
+		return "", false
+	}
+	return n.Value(), true
+}
+
 func (a Service) String() string {
 	s := "service "
 	if id := a.ID; id != nil {
 		s += fmt.Sprintf("%s ", *id)
 	}
 	s += ": "
+	if a.InitArgs != nil {
+		// Unlike function arguments, init arguments are always in parentheses.
+		var args []string
+		for _, arg := range a.InitArgs {
+			args = append(args, arg.String())
+		}
+		s += fmt.Sprintf("(%s) -> ", strings.Join(args, ", "))
+	}
 	if id := a.MethodId; id != nil {
 		return s + *id
 	}
@@ -0,0 +1,368 @@
+package did
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+// Annotate replaces the hashed labels of the given value by the labels of the given
+// data type, so that `record { 1_224_700_491 = ... }` becomes `record { name = ... }`.
+func (p Description) Annotate(v *idl.Value, data Data) error {
+	t, err := p.TypeOf(data)
+	if err != nil {
+		return err
+	}
+	return v.Annotate(t)
+}
+
+// AnnotateArguments annotates the arguments of the given method, see Annotate.
+func (p Description) AnnotateArguments(method string, vs []idl.Value) error {
+	f, err := p.Method(method)
+	if err != nil {
+		return err
+	}
+	return p.annotateTuple(f.ArgTypes, vs)
+}
+
+// AnnotateResults annotates the results of the given method, see Annotate.
+func (p Description) AnnotateResults(method string, vs []idl.Value) error {
+	f, err := p.Method(method)
+	if err != nil {
+		return err
+	}
+	return p.annotateTuple(f.ResTypes, vs)
+}
+
+// Method returns the signature of the method with the given name of the (first)
+// service of the description. Methods that reference a function type definition
+// are resolved.
+func (p Description) Method(name string) (Func, error) {
+	methods, err := p.Methods()
+	if err != nil {
+		return Func{}, err
+	}
+	for _, m := range methods {
+		if m.Name == name {
+			return *m.Func, nil
+		}
+	}
+	return Func{}, fmt.Errorf("unknown method: %s", name)
+}
+
+// Methods returns the methods of the (first) service of the description. Methods
+// that reference a function type definition are resolved.
+func (p Description) Methods() ([]Method, error) {
+	if len(p.Services) == 0 {
+		return nil, fmt.Errorf("no service declared")
+	}
+	return p.methods(p.Services[0])
+}
+
+// ServiceMethods returns the methods of the given service, e.g. of a service type
+// definition. Methods that reference a function type definition are resolved.
+func (p Description) ServiceMethods(s Service) ([]Method, error) {
+	return p.methods(s)
+}
+
+// TypeOf converts the given data type to its idl type. References to type
+// definitions are resolved against the description, recursive definitions are
+// resolved to an idl.RecursiveType.
+func (p Description) TypeOf(data Data) (idl.Type, error) {
+	return p.resolver().typeOf(data)
+}
+
+// Types converts the given tuple to its idl types, see TypeOf.
+func (p Description) Types(tuple Tuple) ([]idl.Type, error) {
+	return p.resolver().types(tuple)
+}
+
+func (p Description) annotateTuple(tuple Tuple, vs []idl.Value) error {
+	ts, err := p.Types(tuple)
+	if err != nil {
+		return err
+	}
+	for i := range vs {
+		if len(ts) <= i {
+			// Additional values are ignored by the receiver.
+			break
+		}
+		if err := vs[i].Annotate(ts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// definition returns the data of the type definition with the given id.
+func (p Description) definition(id string) (Data, error) {
+	for _, d := range p.Definitions {
+		if t, ok := d.(Type); ok && t.Id == id {
+			return t.Data, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown type: %s", id)
+}
+
+// methods returns the methods of the service, resolving a reference to a service
+// type definition and methods that reference a function type definition.
+func (p Description) methods(s Service) ([]Method, error) {
+	methods := s.Methods
+	if s.MethodId != nil {
+		data, err := p.definition(*s.MethodId)
+		if err != nil {
+			return nil, err
+		}
+		ref, ok := data.(Service)
+		if !ok {
+			return nil, fmt.Errorf("invalid service type: %s", *s.MethodId)
+		}
+		return p.methods(ref)
+	}
+	resolved := make([]Method, len(methods))
+	for i, m := range methods {
+		if m.ID != nil {
+			data, err := p.definition(*m.ID)
+			if err != nil {
+				return nil, err
+			}
+			f, ok := data.(Func)
+			if !ok {
+				return nil, fmt.Errorf("invalid function type: %s", *m.ID)
+			}
+			m = Method{Name: m.Name, Func: &f}
+		}
+		resolved[i] = m
+	}
+	return resolved, nil
+}
+
+func (p Description) resolver() *typeResolver {
+	return &typeResolver{
+		desc:      p,
+		resolving: make(map[string]*idl.RecursiveType),
+		used:      make(map[string]bool),
+		resolved:  make(map[string]idl.Type),
+	}
+}
+
+// typeResolver converts data types to idl types. Every type definition is
+// converted once, references to a definition that is being converted are
+// recursive.
+type typeResolver struct {
+	desc      Description
+	resolving map[string]*idl.RecursiveType
+	used      map[string]bool
+	resolved  map[string]idl.Type
+}
+
+func (r *typeResolver) field(f Field, i int, variant bool) (string, idl.Type, error) {
+	label, data, err := fieldData(f, i, variant)
+	if err != nil {
+		return "", nil, err
+	}
+	t, err := r.typeOf(data)
+	if err != nil {
+		return "", nil, err
+	}
+	return label, t, nil
+}
+
+func (r *typeResolver) fields(fields []Field, variant bool) (map[string]idl.Type, error) {
+	m := make(map[string]idl.Type, len(fields))
+	for i, f := range fields {
+		label, t, err := r.field(f, i, variant)
+		if err != nil {
+			return nil, err
+		}
+		m[label] = t
+	}
+	return m, nil
+}
+
+func (r *typeResolver) function(f Func) (*idl.FunctionType, error) {
+	args, err := r.types(f.ArgTypes)
+	if err != nil {
+		return nil, err
+	}
+	results, err := r.types(f.ResTypes)
+	if err != nil {
+		return nil, err
+	}
+	var annotations []string
+	if f.Annotation != nil {
+		annotations = append(annotations, string(*f.Annotation))
+	}
+	return idl.NewFunctionType(parameters(args), parameters(results), annotations), nil
+}
+
+func (r *typeResolver) reference(id string) (idl.Type, error) {
+	if t, ok := r.resolved[id]; ok {
+		return t, nil
+	}
+	if rec, ok := r.resolving[id]; ok {
+		r.used[id] = true
+		return rec, nil
+	}
+	data, err := r.desc.definition(id)
+	if err != nil {
+		return nil, err
+	}
+	rec := idl.NewRecursiveType(id)
+	r.resolving[id] = rec
+	t, err := r.typeOf(data)
+	delete(r.resolving, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.used[id] {
+		rec.Resolve(t)
+		t = rec
+	}
+	r.resolved[id] = t
+	return t, nil
+}
+
+func (r *typeResolver) typeOf(data Data) (idl.Type, error) {
+	switch data := data.(type) {
+	case Blob:
+		return idl.NewVectorType(idl.Nat8Type()), nil
+	case DataId:
+		return r.reference(string(data))
+	case Optional:
+		t, err := r.typeOf(data.Data)
+		if err != nil {
+			return nil, err
+		}
+		return idl.NewOptionalType(t), nil
+	case Vector:
+		t, err := r.typeOf(data.Data)
+		if err != nil {
+			return nil, err
+		}
+		return idl.NewVectorType(t), nil
+	case Record:
+		fields, err := r.fields(data, false)
+		if err != nil {
+			return nil, err
+		}
+		return idl.NewRecordType(fields), nil
+	case Variant:
+		fields, err := r.fields(data, true)
+		if err != nil {
+			return nil, err
+		}
+		return idl.NewVariantType(fields), nil
+	case Func:
+		return r.function(data)
+	case Service:
+		methods, err := r.desc.methods(data)
+		if err != nil {
+			return nil, err
+		}
+		fs := make(map[string]*idl.FunctionType, len(methods))
+		for _, m := range methods {
+			f, err := r.function(*m.Func)
+			if err != nil {
+				return nil, err
+			}
+			fs[m.Name] = f
+		}
+		return idl.NewServiceType(fs), nil
+	case Principal:
+		return new(idl.PrincipalType), nil
+	case Primitive:
+		return primitiveType(data)
+	default:
+		return nil, fmt.Errorf("unknown data type: %s", data)
+	}
+}
+
+func (r *typeResolver) types(tuple Tuple) ([]idl.Type, error) {
+	ts := make([]idl.Type, len(tuple))
+	for i, a := range tuple {
+		t, err := r.typeOf(a.Data)
+		if err != nil {
+			return nil, err
+		}
+		ts[i] = t
+	}
+	return ts, nil
+}
+
+func parameters(ts []idl.Type) []idl.FunctionParameter {
+	ps := make([]idl.FunctionParameter, len(ts))
+	for i, t := range ts {
+		ps[i] = idl.FunctionParameter{Type: t}
+	}
+	return ps
+}
+
+func primitiveType(p Primitive) (idl.Type, error) {
+	switch p {
+	case "nat":
+		return new(idl.NatType), nil
+	case "nat8":
+		return idl.Nat8Type(), nil
+	case "nat16":
+		return idl.Nat16Type(), nil
+	case "nat32":
+		return idl.Nat32Type(), nil
+	case "nat64":
+		return idl.Nat64Type(), nil
+	case "int":
+		return new(idl.IntType), nil
+	case "int8":
+		return idl.Int8Type(), nil
+	case "int16":
+		return idl.Int16Type(), nil
+	case "int32":
+		return idl.Int32Type(), nil
+	case "int64":
+		return idl.Int64Type(), nil
+	case "float32":
+		return idl.Float32Type(), nil
+	case "float64":
+		return idl.Float64Type(), nil
+	case "bool":
+		return new(idl.BoolType), nil
+	case "text":
+		return new(idl.TextType), nil
+	case "null":
+		return new(idl.NullType), nil
+	case "reserved":
+		return new(idl.ReservedType), nil
+	case "empty":
+		return new(idl.EmptyType), nil
+	default:
+		return nil, fmt.Errorf("unknown primitive type: %s", p)
+	}
+}
+
+// fieldData returns the label and the data type of the i-th field of a record or
+// variant. Unlabelled record fields are labelled by their position.
+func fieldData(f Field, i int, variant bool) (string, Data, error) {
+	if variant && f.Name == nil && f.Nat == nil {
+		// A variant arm without a type, e.g. `variant { a; 1 }`.
+		if f.NatData != nil {
+			return f.NatData.String(), Primitive("null"), nil
+		}
+		return *f.NameData, Primitive("null"), nil
+	}
+	label := strconv.Itoa(i)
+	switch {
+	case f.Name != nil:
+		label = *f.Name
+	case f.Nat != nil:
+		label = f.Nat.String()
+	}
+	switch {
+	case f.Data != nil:
+		return label, *f.Data, nil
+	case f.NameData != nil:
+		return label, DataId(*f.NameData), nil
+	default:
+		return "", nil, fmt.Errorf("invalid field: %s", f)
+	}
+}
@@ -0,0 +1,64 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+func TestDescription_Method(t *testing.T) {
+	d, err := ParseDID([]rune(`
+type get = func (nat) -> (text) query;
+service : {
+	get : get;
+	set : (nat, text) -> ();
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := d.Method("get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Annotation == nil || *f.Annotation != AnnQuery {
+		t.Errorf("expected query annotation, got %v", f.Annotation)
+	}
+	ts, err := d.Types(f.ArgTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 1 || ts[0].String() != "nat" {
+		t.Errorf("unexpected argument types: %v", ts)
+	}
+	if _, err := d.Method("unknown"); err == nil {
+		t.Error("expected error for unknown method")
+	}
+}
+
+func TestDescription_TypeOf_recursive(t *testing.T) {
+	d, err := ParseDID([]rune(`
+type List = opt record { head : nat; tail : List };
+type Tree = variant { leaf : nat; node : record { Tree; Tree } };`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"List", "Tree"} {
+		typ, err := d.TypeOf(DataId(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := typ.(*idl.RecursiveType); !ok {
+			t.Fatalf("%s: expected a recursive type, got %T", id, typ)
+		}
+		tdt := &idl.TypeDefinitionTable{Indexes: make(map[string]int)}
+		if err := typ.AddTypeDefinition(tdt); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+	}
+
+	list, _ := d.TypeOf(DataId("List"))
+	v := map[string]any{"head": idl.NewNat(uint(1)), "tail": nil}
+	if _, err := list.EncodeValue(v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,7 @@
 package did
 
 import (
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"github.com/0x51-dev/upeg/parser"
 	"github.com/aviate-labs/agent-go/candid/idl"
 	"github.com/aviate-labs/agent-go/candid/internal/cvalue"
+	"github.com/aviate-labs/agent-go/principal"
 )
 
 func ConvertValues(n *parser.Node) ([]idl.Type, []any, error) {
@@ -34,6 +36,10 @@ func ConvertValues(n *parser.Node) ([]idl.Type, []any, error) {
 		if err != nil {
 			return nil, nil, err
 		}
+		if args[0] == nil {
+			// opt null is present, unlike null.
+			return []idl.Type{idl.NewOptionalType(types[0])}, []any{idl.Some[any](nil)}, nil
+		}
 		return []idl.Type{idl.NewOptionalType(types[0])}, []any{args[0]}, nil
 	case cvalue.Record.Name:
 		if len(n.Children()) == 0 {
@@ -52,6 +58,13 @@ func ConvertValues(n *parser.Node) ([]idl.Type, []any, error) {
 			args[id] = arg[0]
 		}
 		return []idl.Type{idl.NewRecordType(types)}, []any{args}, nil
+	case cvalue.Principal.Name:
+		n := n.Children()[0]
+		p, err := principal.Decode(strings.TrimPrefix(strings.TrimSuffix(n.Value(), "\""), "\""))
+		if err != nil {
+			return nil, nil, err
+		}
+		return []idl.Type{new(idl.PrincipalType)}, []any{p}, nil
 	case cvalue.Text.Name:
 		n := n.Children()[0]
 		s := strings.TrimPrefix(strings.TrimSuffix(n.Value(), "\""), "\"")
@@ -134,11 +147,11 @@ func convertNum(n *parser.Node) (idl.Type, any, error) {
 
 		// int
 		v := strings.ReplaceAll(n.Value(), "_", "")
-		i, err := strconv.ParseInt(v, 10, 64)
-		if err != nil {
-			return nil, nil, err
+		bi, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid number: %s", n.Value())
 		}
-		return new(idl.IntType), idl.NewInt(i), nil
+		return new(idl.IntType), idl.NewBigInt(bi), nil
 	case 2:
 		vArg := n[0].Value()
 		vType := n[1].Value()
@@ -164,36 +177,41 @@ func convertNum(n *parser.Node) (idl.Type, any, error) {
 
 		// ints
 		v := strings.ReplaceAll(vArg, "_", "")
-		i, err := strconv.ParseInt(v, 10, 64)
-		if err != nil {
-			return nil, nil, err
+		bi, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid number: %s", vArg)
 		}
+		var typ idl.Type
 		switch vType {
 		case "nat":
-			bi := big.NewInt(i)
-			return new(idl.NatType), idl.NewBigNat(bi), nil
+			typ = new(idl.NatType)
 		case "nat8":
-			return idl.Nat8Type(), uint8(i), nil
+			typ = idl.Nat8Type()
 		case "nat16":
-			return idl.Nat16Type(), uint16(i), nil
+			typ = idl.Nat16Type()
 		case "nat32":
-			return idl.Nat32Type(), uint32(i), nil
+			typ = idl.Nat32Type()
 		case "nat64":
-			return idl.Nat64Type(), uint64(i), nil
+			typ = idl.Nat64Type()
 		case "int":
-			bi := big.NewInt(i)
-			return new(idl.IntType), idl.NewBigInt(bi), nil
+			typ = new(idl.IntType)
 		case "int8":
-			return idl.Int8Type(), int8(i), nil
+			typ = idl.Int8Type()
 		case "int16":
-			return idl.Int16Type(), int16(i), nil
+			typ = idl.Int16Type()
 		case "int32":
-			return idl.Int32Type(), int32(i), nil
+			typ = idl.Int32Type()
 		case "int64":
-			return idl.Int64Type(), i, nil
+			typ = idl.Int64Type()
 		default:
 			panic(n)
 		}
+		// Checks the range of the annotated type.
+		i, err := idl.Value{Type: new(idl.IntType), Value: idl.NewBigInt(bi)}.Convert(typ)
+		if err != nil {
+			return nil, nil, err
+		}
+		return typ, i.Value, nil
 	default:
 		panic(n)
 	}
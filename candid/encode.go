@@ -80,3 +80,14 @@ func Marshal(args []any) ([]byte, error) {
 	}
 	return Encode(types, args)
 }
+
+// EncodeValues encodes the given dynamic values into a candid message.
+func EncodeValues(values []idl.Value) ([]byte, error) {
+	ts := make([]idl.Type, len(values))
+	vs := make([]any, len(values))
+	for i, v := range values {
+		ts[i] = v.Type
+		vs[i] = v.Any()
+	}
+	return Encode(ts, vs)
+}
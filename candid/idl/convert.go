@@ -95,6 +95,11 @@ func EmptyOf(t Type) (any, error) {
 	return nil, UnknownTypeError{Type: t}
 }
 
+// TypeFor returns the candid type of the Go type T, see TypeOfReflect.
+func TypeFor[T any]() (Type, error) {
+	return TypeOfReflect(reflect.TypeFor[T]())
+}
+
 func TypeOf(v any) (Type, error) {
 	switch v := v.(type) {
 	case Null:
@@ -157,6 +162,10 @@ func TypeOf(v any) (Type, error) {
 		return NewVariantType(fields), nil
 	case principal.Principal:
 		return new(PrincipalType), nil
+	case FunctionReference:
+		return v.FunctionType(), nil
+	case ServiceReference:
+		return v.ServiceType(), nil
 	default:
 		if v == nil {
 			return new(NullType), nil
@@ -170,6 +179,15 @@ func TypeOf(v any) (Type, error) {
 	}
 }
 
+// TypeOfReflect returns the candid type of the given Go type. Unlike TypeOf it does
+// not need a value, so it also works for interface method signatures and nil
+// pointers. Pointers (and Opt) are optional values, slices and arrays are vectors,
+// and structs are records, or variants and tuples if their fields are tagged with
+// `ic:"name,variant"` or `ic:"0,tuple"`.
+func TypeOfReflect(t reflect.Type) (Type, error) {
+	return typeOfType(t, map[reflect.Type]*RecursiveType{})
+}
+
 // typeOfType derives a candid Type from a Go reflect.Type. visited holds the
 // recursive placeholders for struct types currently being expanded on the
 // path from the root, so re-entering a type returns its placeholder instead
@@ -218,6 +236,30 @@ func typeOfType(t reflect.Type, visited map[reflect.Type]*RecursiveType) (Type,
 		}
 		return NewOptionalType(elem), nil
 	case reflect.Struct:
+		if t.Implements(reflect.TypeFor[FunctionReference]()) {
+			return reflect.Zero(t).Interface().(FunctionReference).FunctionType(), nil
+		}
+		if t.Implements(reflect.TypeFor[ServiceReference]()) {
+			return reflect.Zero(t).Interface().(ServiceReference).ServiceType(), nil
+		}
+		if t.Implements(reflect.TypeFor[optional]()) {
+			elem, err := typeOfType(reflect.Zero(t).Interface().(optional).optionalElem(), visited)
+			if err != nil {
+				return nil, err
+			}
+			return NewOptionalType(elem), nil
+		}
+		if t.Implements(reflect.TypeFor[variantValue]()) {
+			fields := make(map[string]Type)
+			for name, arm := range reflect.Zero(t).Interface().(variantValue).variantArms() {
+				ft, err := typeOfType(arm, visited)
+				if err != nil {
+					return nil, err
+				}
+				fields[name] = ft
+			}
+			return NewVariantType(fields), nil
+		}
 		// Special idl value-carrying structs (Nat, Int, Reserved, Empty, Null,
 		// principal.Principal) are primitives, not records: defer to the
 		// value-based TypeOf on a zero value so their canonical types are used.
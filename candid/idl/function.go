@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 
 	"github.com/aviate-labs/agent-go/leb128"
@@ -26,11 +27,93 @@ func encodeTypes(ts []Type, tdt *TypeDefinitionTable) ([]byte, error) {
 	return append(l, vs...), nil
 }
 
+// functionAnnotations are the binary encodings of the function annotations.
+var functionAnnotations = map[string]byte{
+	"query":           0x01,
+	"oneway":          0x02,
+	"composite_query": 0x03,
+}
+
+// FunctionAnnotation returns the name of the annotation with the given binary
+// encoding, e.g. 0x03 is "composite_query".
+func FunctionAnnotation(b byte) (string, error) {
+	for name, v := range functionAnnotations {
+		if v == b {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("invalid function annotation: %x", b)
+}
+
 type Function struct {
 	Types  FunctionType
 	Method PrincipalMethod
 }
 
+// FunctionType returns the type of the referenced method, as it was decoded.
+func (f Function) FunctionType() *FunctionType {
+	return &f.Types
+}
+
+// FunctionReference is implemented by typed references to methods, e.g. the ones
+// generated by gen. The underlying type must be Function, e.g.
+// `type Callback idl.Function`, and FunctionType must not depend on the value.
+type FunctionReference interface {
+	FunctionType() *FunctionType
+}
+
+// FunctionTypeFor returns the type of a method with the given Go argument and
+// result types, see TypeOfReflect.
+func FunctionTypeFor(args, results []reflect.Type, annotations ...string) (*FunctionType, error) {
+	parameters := func(ts []reflect.Type) ([]FunctionParameter, error) {
+		ps := make([]FunctionParameter, len(ts))
+		for i, t := range ts {
+			typ, err := TypeOfReflect(t)
+			if err != nil {
+				return nil, err
+			}
+			ps[i] = FunctionParameter{Type: typ}
+		}
+		return ps, nil
+	}
+	as, err := parameters(args)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := parameters(results)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range annotations {
+		if _, ok := functionAnnotations[a]; !ok {
+			return nil, fmt.Errorf("invalid function annotation: %s", a)
+		}
+	}
+	return NewFunctionType(as, rs, annotations), nil
+}
+
+// MustFunctionTypeFor is like FunctionTypeFor but panics on error.
+func MustFunctionTypeFor(args, results []reflect.Type, annotations ...string) *FunctionType {
+	t, err := FunctionTypeFor(args, results, annotations...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// functionReference returns the function of the given reference, if it is one.
+func functionReference(v any) (*Function, bool) {
+	if _, ok := v.(FunctionReference); !ok {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.Type().ConvertibleTo(reflect.TypeFor[Function]()) {
+		return nil, false
+	}
+	f := rv.Convert(reflect.TypeFor[Function]()).Interface().(Function)
+	return &f, true
+}
+
 type FunctionParameter struct {
 	Type  Type
 	Index OpCode
@@ -80,14 +163,11 @@ func (f FunctionType) AddTypeDefinition(tdt *TypeDefinitionTable) error {
 	}
 	var vs []byte
 	for _, t := range f.Annotations {
-		switch t {
-		case "query":
-			vs = []byte{0x01}
-		case "oneway":
-			vs = []byte{0x02}
-		default:
+		b, ok := functionAnnotations[t]
+		if !ok {
 			return fmt.Errorf("invalid function annotation: %s", t)
 		}
+		vs = append(vs, b)
 	}
 
 	tdt.Add(f, concat(id, vsa, vsr, l, vs))
@@ -148,7 +228,11 @@ func (f FunctionType) EncodeType(tdt *TypeDefinitionTable) ([]byte, error) {
 func (f FunctionType) EncodeValue(v any) ([]byte, error) {
 	pm, ok := v.(*PrincipalMethod)
 	if !ok {
-		return nil, NewEncodeValueError(v, FuncOpCode)
+		fn, ok := functionReference(v)
+		if !ok {
+			return nil, NewEncodeValueError(v, FuncOpCode)
+		}
+		pm = &fn.Method
 	}
 	l, err := leb128.EncodeUnsigned(big.NewInt(int64(len(pm.Principal.Raw))))
 	if err != nil {
@@ -240,7 +324,16 @@ func (f FunctionType) UnmarshalGo(raw any, _v any) error {
 	}
 	v, ok := _v.(*Function)
 	if !ok {
-		return NewUnmarshalGoError(raw, _v)
+		// Typed references, e.g. `type Callback idl.Function`.
+		rv := reflect.ValueOf(_v)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return NewUnmarshalGoError(raw, _v)
+		}
+		if _, ok := functionReference(rv.Elem().Interface()); !ok {
+			return NewUnmarshalGoError(raw, _v)
+		}
+		rv.Elem().Set(reflect.ValueOf(Function{Types: f, Method: *pm}).Convert(rv.Elem().Type()))
+		return nil
 	}
 	v.Types = f
 	v.Method = *pm
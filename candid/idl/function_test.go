@@ -1,6 +1,10 @@
 package idl_test
 
 import (
+	"reflect"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid"
 	"github.com/aviate-labs/agent-go/candid/idl"
 	"github.com/aviate-labs/agent-go/principal"
 )
@@ -24,3 +28,73 @@ func ExampleFunctionType() {
 	// Output:
 	// 4449444c016a0171017d000100010103caffee03666f6f
 }
+
+func ExampleFunctionType_annotations() {
+	for _, ann := range []string{"query", "oneway", "composite_query"} {
+		test(
+			[]idl.Type{
+				idl.NewFunctionType(nil, nil, []string{ann}),
+			},
+			[]any{
+				&idl.PrincipalMethod{
+					Principal: principal.MustDecode("w7x7r-cok77-xa"),
+					Method:    "foo",
+				},
+			},
+		)
+	}
+	// Output:
+	// 4449444c016a000001010100010103caffee03666f6f
+	// 4449444c016a000001020100010103caffee03666f6f
+	// 4449444c016a000001030100010103caffee03666f6f
+}
+
+type callback idl.Function
+
+func (callback) FunctionType() *idl.FunctionType {
+	return idl.MustFunctionTypeFor([]reflect.Type{reflect.TypeFor[string]()}, []reflect.Type{reflect.TypeFor[idl.Nat]()}, "query")
+}
+
+type token principal.Principal
+
+func (token) ServiceType() *idl.Service {
+	return idl.NewServiceType(map[string]*idl.FunctionType{
+		"callback": callback{}.FunctionType(),
+	})
+}
+
+func TestFunctionReference(t *testing.T) {
+	type subscription struct {
+		Callback callback `ic:"callback"`
+		Token    token    `ic:"token"`
+	}
+	in := subscription{
+		Callback: callback{Method: idl.PrincipalMethod{
+			Principal: principal.MustDecode("w7x7r-cok77-xa"),
+			Method:    "notify",
+		}},
+		Token: token(principal.MustDecode("aaaaa-aa")),
+	}
+	raw, err := candid.Marshal([]any{in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, _, err := candid.Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ts[0].String(); s != "record {338395897:service {callback:(text) -> (nat) query}; 2131139013:(text) -> (nat) query}" {
+		t.Error(s)
+	}
+
+	var out subscription
+	if err := candid.Unmarshal(raw, []any{&out}); err != nil {
+		t.Fatal(err)
+	}
+	if m := out.Callback.Method; m.Principal.String() != "w7x7r-cok77-xa" || m.Method != "notify" {
+		t.Errorf("unexpected callback: %v", m)
+	}
+	if principal.Principal(out.Token).String() != "aaaaa-aa" {
+		t.Errorf("unexpected token: %v", out.Token)
+	}
+}
@@ -1,6 +1,19 @@
 package idl
 
-import "math/big"
+import (
+	"math/big"
+	"strconv"
+)
+
+// FieldID returns the id of a record or variant field label. Labels that are numbers
+// (e.g. `record { 0 : nat }`, or labels decoded without their names) are their own
+// id, all other labels are hashed.
+func FieldID(label string) *big.Int {
+	if id, err := strconv.ParseUint(label, 10, 32); err == nil {
+		return new(big.Int).SetUint64(id)
+	}
+	return Hash(label)
+}
 
 // Hash hashes a string to a number.
 // ( Sum_(i=0..k) utf8(id)[i] * 223^(k-i) ) mod 2^32 where k = |utf8(id)|-1
@@ -2,11 +2,13 @@ package idl
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"encoding/binary"
 	"fmt"
 	"math/big"
 
 	"github.com/aviate-labs/agent-go/leb128"
+	"github.com/fxamacker/cbor/v2"
 )
 
 func anyToInt16(v any) (int16, bool) {
@@ -138,11 +140,86 @@ func (i Int) BigInt() *big.Int {
 	return i.i
 }
 
+// MarshalBinary returns the signed LEB128 encoding of the Int.
+func (i Int) MarshalBinary() ([]byte, error) {
+	return leb128.EncodeSigned(i.bigInt())
+}
+
+// MarshalCBOR encodes the Int as a CBOR integer, or as a bignum if it does not fit
+// into 64 bits. The binary representation is only used by MarshalBinary.
+func (i Int) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(i.bigInt())
+}
+
+// MarshalText returns the decimal representation of the Int, which is also its JSON
+// representation, a string.
+func (i Int) MarshalText() ([]byte, error) {
+	return i.bigInt().Append(nil, 10), nil
+}
+
+// Scan implements the sql.Scanner interface, it expects an integer or the decimal
+// representation, e.g. of a NUMERIC column.
+func (i *Int) Scan(src any) error {
+	text, err := numberText(src)
+	if err != nil {
+		return err
+	}
+	return i.UnmarshalText(text)
+}
+
 // String returns the string representation of the Int.
 func (i Int) String() string {
 	return i.i.String()
 }
 
+// UnmarshalBinary decodes the signed LEB128 encoding of an Int.
+func (i *Int) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	bi, err := leb128.DecodeSigned(r)
+	if err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("invalid int: %d trailing bytes", r.Len())
+	}
+	i.i = bi
+	return nil
+}
+
+// UnmarshalCBOR decodes an Int from a CBOR integer or bignum.
+func (i *Int) UnmarshalCBOR(data []byte) error {
+	var bi big.Int
+	if err := cbor.Unmarshal(data, &bi); err != nil {
+		return err
+	}
+	i.i = &bi
+	return nil
+}
+
+// UnmarshalText decodes the decimal representation of an Int.
+func (i *Int) UnmarshalText(text []byte) error {
+	bi, ok := new(big.Int).SetString(string(text), 10)
+	if !ok {
+		return fmt.Errorf("invalid int: %q", text)
+	}
+	i.i = bi
+	return nil
+}
+
+// Value implements the driver.Valuer interface, it returns the decimal
+// representation.
+func (i Int) Value() (driver.Value, error) {
+	return i.bigInt().String(), nil
+}
+
+// bigInt returns the underlying big.Int, or zero if the Int is not set.
+func (i Int) bigInt() *big.Int {
+	if i.i == nil {
+		return new(big.Int)
+	}
+	return i.i
+}
+
 // IntType is either a type of int8, int16, int32, int64, or int.
 type IntType struct {
 	size uint8
@@ -1,10 +1,13 @@
 package idl_test
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"math/big"
 	"testing"
 
 	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/fxamacker/cbor/v2"
 )
 
 func ExampleInt() {
@@ -186,3 +189,68 @@ func TestIntType_UnmarshalGo(t *testing.T) {
 		expectErr(t, idl.UnmarshalGo(nt, 0, &a))
 	})
 }
+
+func TestInt_encoding(t *testing.T) {
+	for _, test := range []struct {
+		i    idl.Int
+		json string
+		cbor string
+	}{
+		{i: idl.NewInt(42), json: `"42"`, cbor: "182a"},
+		{i: idl.NewInt(-42), json: `"-42"`, cbor: "3829"},
+		{i: idl.NewIntFromString("-340282366920938463463374607431768211457"), json: `"-340282366920938463463374607431768211457"`, cbor: "c3510100000000000000000000000000000000"},
+	} {
+		j, err := json.Marshal(test.i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := cbor.Marshal(test.i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(j) != test.json || hex.EncodeToString(c) != test.cbor {
+			t.Errorf("%s: unexpected encoding: %s, %x", test.i, j, c)
+		}
+		var fromJSON, fromCBOR idl.Int
+		if err := json.Unmarshal(j, &fromJSON); err != nil {
+			t.Fatal(err)
+		}
+		if err := cbor.Unmarshal(c, &fromCBOR); err != nil {
+			t.Fatal(err)
+		}
+		if fromJSON.BigInt().Cmp(test.i.BigInt()) != 0 || fromCBOR.BigInt().Cmp(test.i.BigInt()) != 0 {
+			t.Errorf("expected %s, got %s and %s", test.i, fromJSON, fromCBOR)
+		}
+	}
+}
+
+func TestInt_sql(t *testing.T) {
+	for _, i := range []idl.Int{idl.NewInt(0), idl.NewInt(-129), idl.NewIntFromString("-340282366920938463463374607431768211456")} {
+		text, err := i.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromText idl.Int
+		if err := fromText.Scan(text); err != nil {
+			t.Fatal(err)
+		}
+		raw, err := i.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromBinary idl.Int
+		if err := fromBinary.UnmarshalBinary(raw); err != nil {
+			t.Fatal(err)
+		}
+		if fromText.BigInt().Cmp(i.BigInt()) != 0 || fromBinary.BigInt().Cmp(i.BigInt()) != 0 {
+			t.Errorf("expected %s, got %s and %s", i, fromText, fromBinary)
+		}
+	}
+	var i idl.Int
+	if err := i.Scan(int64(-42)); err != nil || i.BigInt().Int64() != -42 {
+		t.Error(i, err)
+	}
+	if err := i.Scan(1.5); err == nil {
+		t.Error("scanned a float")
+	}
+}
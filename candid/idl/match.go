@@ -0,0 +1,149 @@
+package idl
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Cases maps the arm names of a variant to their handlers, see Case for handlers of
+// a typed value.
+type Cases map[string]func(value any) error
+
+// Case returns a handler of an arm with a value of type T. The handler returns an
+// error instead of being called if the value of the arm is of another type.
+func Case[T any](f func(value T) error) func(value any) error {
+	return func(value any) error {
+		v, ok := value.(T)
+		if !ok {
+			return fmt.Errorf("invalid value of arm: expected %s, got %T", reflect.TypeFor[T](), value)
+		}
+		return f(v)
+	}
+}
+
+// Match calls the handler of the selected arm of the given variant. The variant is
+// either a Variant (or *Variant) or a struct of pointers tagged with `ic:"name,variant"`,
+// as generated by the gen package.
+//
+// The match is exhaustive: if the arms of the variant are known and cases does not
+// contain a handler for every one of them, no handler is called and an error listing
+// the missing arms is returned. This makes a new arm in a canister interface show up
+// as an error instead of a silently ignored case. Likewise, cases for arms the variant
+// does not have, e.g. misspelled or removed arms, are reported as an error.
+func Match(v any, cases Cases) error {
+	name, value, arms, err := selectedArm(v)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, arm := range arms {
+		if _, ok := lookupCase(cases, arm); !ok {
+			missing = append(missing, arm)
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("non-exhaustive match: missing %s", strings.Join(missing, ", "))
+	}
+	if unknown := unknownCases(cases, arms); len(unknown) != 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("invalid match: unknown %s", strings.Join(unknown, ", "))
+	}
+	f, ok := lookupCase(cases, name)
+	if !ok {
+		return fmt.Errorf("non-exhaustive match: missing %s", name)
+	}
+	return f(value)
+}
+
+// lookupCase finds the handler for the given arm, which is either the arm name or
+// its hash if the value was decoded without a matching Go type.
+func lookupCase(cases Cases, name string) (func(any) error, bool) {
+	if f, ok := cases[name]; ok {
+		return f, true
+	}
+	for k, f := range cases {
+		if HashString(k) == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// unknownCases returns the names of the cases that do not belong to any of the arms,
+// if the arms are known.
+func unknownCases(cases Cases, arms []string) []string {
+	if len(arms) == 0 {
+		return nil
+	}
+	var unknown []string
+	for k := range cases {
+		if !slices.ContainsFunc(arms, func(arm string) bool {
+			return arm == k || arm == HashString(k)
+		}) {
+			unknown = append(unknown, k)
+		}
+	}
+	return unknown
+}
+
+// selectedArm returns the name and value of the selected arm, and the names of all
+// arms if they are known.
+func selectedArm(v any) (string, any, []string, error) {
+	switch v := v.(type) {
+	case *Variant:
+		return selectedArm(*v)
+	case Variant:
+		var arms []string
+		switch t := v.Type.(type) {
+		case *VariantType:
+			arms = fieldNames(t.Fields)
+		case VariantType:
+			arms = fieldNames(t.Fields)
+		}
+		return v.Name, v.Value, arms, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return "", nil, nil, fmt.Errorf("invalid variant kind: %s", rv.Kind())
+	}
+	var (
+		name  string
+		value any
+		arms  []string
+	)
+	for i := range rv.NumField() {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := ParseTags(field)
+		if !tag.VariantType {
+			return "", nil, nil, fmt.Errorf("invalid variant field: %s", rv.Type())
+		}
+		arms = append(arms, tag.Name)
+		if f := rv.Field(i); f.Kind() == reflect.Pointer && !f.IsNil() && name == "" {
+			name = tag.Name
+			value = f.Elem().Interface()
+		}
+	}
+	if name == "" {
+		return "", nil, nil, fmt.Errorf("invalid variant: no variant selected")
+	}
+	return name, value, arms, nil
+}
+
+func fieldNames(fields []FieldType) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	return names
+}
@@ -2,11 +2,13 @@ package idl
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"encoding/binary"
 	"fmt"
 	"math/big"
 
 	"github.com/aviate-labs/agent-go/leb128"
+	"github.com/fxamacker/cbor/v2"
 )
 
 func anyToUint16(v any) (uint16, bool) {
@@ -141,11 +143,92 @@ func (n Nat) BigInt() *big.Int {
 	return n.n
 }
 
+// MarshalBinary returns the LEB128 encoding of the Nat.
+func (n Nat) MarshalBinary() ([]byte, error) {
+	return leb128.EncodeUnsigned(n.bigInt())
+}
+
+// MarshalCBOR encodes the Nat as a CBOR integer, or as a bignum if it does not fit
+// into 64 bits. The binary representation is only used by MarshalBinary.
+func (n Nat) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(n.bigInt())
+}
+
+// MarshalText returns the decimal representation of the Nat, which is also its JSON
+// representation, a string.
+func (n Nat) MarshalText() ([]byte, error) {
+	return n.bigInt().Append(nil, 10), nil
+}
+
+// Scan implements the sql.Scanner interface, it expects an integer or the decimal
+// representation, e.g. of a NUMERIC column.
+func (n *Nat) Scan(src any) error {
+	text, err := numberText(src)
+	if err != nil {
+		return err
+	}
+	return n.UnmarshalText(text)
+}
+
 // String returns the string representation of the Nat.
 func (n Nat) String() string {
 	return n.n.String()
 }
 
+// UnmarshalBinary decodes the LEB128 encoding of a Nat.
+func (n *Nat) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	bi, err := leb128.DecodeUnsigned(r)
+	if err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("invalid nat: %d trailing bytes", r.Len())
+	}
+	n.n = bi
+	return nil
+}
+
+// UnmarshalCBOR decodes a Nat from a CBOR non-negative integer or bignum.
+func (n *Nat) UnmarshalCBOR(data []byte) error {
+	var bi big.Int
+	if err := cbor.Unmarshal(data, &bi); err != nil {
+		return err
+	}
+	if bi.Sign() < 0 {
+		return fmt.Errorf("invalid nat: %s is negative", &bi)
+	}
+	n.n = &bi
+	return nil
+}
+
+// UnmarshalText decodes the decimal representation of a Nat.
+func (n *Nat) UnmarshalText(text []byte) error {
+	bi, ok := new(big.Int).SetString(string(text), 10)
+	if !ok {
+		return fmt.Errorf("invalid nat: %q", text)
+	}
+	if bi.Sign() < 0 {
+		return fmt.Errorf("invalid nat: %q is negative", text)
+	}
+	n.n = bi
+	return nil
+}
+
+// Value implements the driver.Valuer interface, it returns the decimal
+// representation.
+func (n Nat) Value() (driver.Value, error) {
+	return n.bigInt().String(), nil
+}
+
+// bigInt returns the underlying big.Int, or zero if the Nat is not set.
+func (n Nat) bigInt() *big.Int {
+	if n.n == nil {
+		return new(big.Int)
+	}
+	return n.n
+}
+
 // NatType is either a type of nat8, nat16, nat32, nat64, or nat.
 type NatType struct {
 	size uint8
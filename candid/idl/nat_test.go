@@ -1,10 +1,13 @@
 package idl_test
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"math/big"
 	"testing"
 
 	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/fxamacker/cbor/v2"
 )
 
 func ExampleNat16Type() {
@@ -196,3 +199,78 @@ func TestNatType_UnmarshalGo(t *testing.T) {
 		expectErr(t, idl.UnmarshalGo(nt, 0, &a))
 	})
 }
+
+func TestNat_encoding(t *testing.T) {
+	for _, test := range []struct {
+		n    idl.Nat
+		json string
+		cbor string
+	}{
+		{n: idl.Nat{}, json: `"0"`, cbor: "00"},
+		{n: idl.NewNat(uint64(42)), json: `"42"`, cbor: "182a"},
+		{n: idl.NewNatFromString("340282366920938463463374607431768211456"), json: `"340282366920938463463374607431768211456"`, cbor: "c2510100000000000000000000000000000000"},
+	} {
+		j, err := json.Marshal(test.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := cbor.Marshal(test.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(j) != test.json || hex.EncodeToString(c) != test.cbor {
+			t.Errorf("%s: unexpected encoding: %s, %x", test.n, j, c)
+		}
+		var fromJSON, fromCBOR idl.Nat
+		if err := json.Unmarshal(j, &fromJSON); err != nil {
+			t.Fatal(err)
+		}
+		if err := cbor.Unmarshal(c, &fromCBOR); err != nil {
+			t.Fatal(err)
+		}
+		if fromJSON.String() != test.json[1:len(test.json)-1] || fromCBOR.String() != fromJSON.String() {
+			t.Errorf("expected %s, got %s and %s", test.n, fromJSON, fromCBOR)
+		}
+	}
+	if err := cbor.Unmarshal([]byte{0x20}, new(idl.Nat)); err == nil { // -1
+		t.Error("decoded a negative nat")
+	}
+}
+
+func TestNat_sql(t *testing.T) {
+	n := idl.NewNatFromString("340282366920938463463374607431768211456") // 2^128
+	text, err := n.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "340282366920938463463374607431768211456" {
+		t.Error(text)
+	}
+	var fromText, fromInt idl.Nat
+	if err := fromText.Scan([]byte(text.(string))); err != nil {
+		t.Fatal(err)
+	}
+	if err := fromInt.Scan(int64(42)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := n.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary idl.Nat
+	if err := fromBinary.UnmarshalBinary(raw); err != nil {
+		t.Fatal(err)
+	}
+	if fromText.BigInt().Cmp(n.BigInt()) != 0 || fromBinary.BigInt().Cmp(n.BigInt()) != 0 || fromInt.BigInt().Int64() != 42 {
+		t.Error(fromText, fromBinary, fromInt)
+	}
+	if err := fromInt.Scan(int64(-1)); err == nil {
+		t.Error("scanned a negative nat")
+	}
+	if err := fromBinary.UnmarshalBinary(append(raw, 0x00)); err == nil {
+		t.Error("trailing bytes")
+	}
+	if text, _ := (idl.Nat{}).MarshalText(); string(text) != "0" {
+		t.Error(string(text))
+	}
+}
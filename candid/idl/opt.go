@@ -0,0 +1,129 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// optional is implemented by Opt[T]. It lets OptionalType encode the value and
+// lets TypeOf derive `opt T` without knowing T.
+type optional interface {
+	optional() (any, bool)
+	optionalElem() reflect.Type
+}
+
+// optionalSetter is implemented by *Opt[T], the decoding counterpart of optional.
+type optionalSetter interface {
+	setOptional(t Type, raw any, present bool) error
+}
+
+// Opt is a candid `opt T` value. The zero value is None.
+//
+// Unlike *T it can not be confused with an absent record field or a variant arm,
+// and it encodes and decodes natively, both as a record field and as a top-level
+// argument.
+type Opt[T any] struct {
+	v *T
+}
+
+// None returns an empty optional value.
+func None[T any]() Opt[T] {
+	return Opt[T]{}
+}
+
+// OptFromPtr returns an optional value that is None if p is nil.
+func OptFromPtr[T any](p *T) Opt[T] {
+	return Opt[T]{v: p}
+}
+
+// Some returns an optional value containing v.
+func Some[T any](v T) Opt[T] {
+	return Opt[T]{v: &v}
+}
+
+// Get returns the contained value and whether it is present.
+func (o Opt[T]) Get() (T, bool) {
+	if o.v == nil {
+		var zero T
+		return zero, false
+	}
+	return *o.v, true
+}
+
+// IsNone reports whether the value is absent.
+func (o Opt[T]) IsNone() bool {
+	return o.v == nil
+}
+
+// IsSome reports whether the value is present.
+func (o Opt[T]) IsSome() bool {
+	return o.v != nil
+}
+
+// MarshalJSON encodes None as null and Some(v) as v.
+func (o Opt[T]) MarshalJSON() ([]byte, error) {
+	if o.v == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(*o.v)
+}
+
+// OrElse returns the contained value, or d if it is absent.
+func (o Opt[T]) OrElse(d T) T {
+	if o.v == nil {
+		return d
+	}
+	return *o.v
+}
+
+// Ptr returns a pointer to the contained value, or nil if it is absent.
+func (o Opt[T]) Ptr() *T {
+	return o.v
+}
+
+// String returns "null" for None and the formatted value otherwise.
+func (o Opt[T]) String() string {
+	if o.v == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", *o.v)
+}
+
+// UnmarshalJSON decodes null as None and any other value as Some(v).
+func (o *Opt[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		o.v = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.v = &v
+	return nil
+}
+
+func (o Opt[T]) optional() (any, bool) {
+	if o.v == nil {
+		return nil, false
+	}
+	return *o.v, true
+}
+
+func (Opt[T]) optionalElem() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+func (o *Opt[T]) setOptional(t Type, raw any, present bool) error {
+	if !present {
+		o.v = nil
+		return nil
+	}
+	var v T
+	if err := UnmarshalGo(t, raw, &v); err != nil {
+		return err
+	}
+	o.v = &v
+	return nil
+}
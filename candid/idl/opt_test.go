@@ -0,0 +1,71 @@
+package idl_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid"
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+func ExampleOpt_generic() {
+	for _, v := range []idl.Opt[uint8]{idl.None[uint8](), idl.Some[uint8](1)} {
+		raw, err := candid.Marshal([]any{v})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		var o idl.Opt[uint8]
+		if err := candid.Unmarshal(raw, []any{&o}); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%x %s\n", raw, o)
+	}
+	// Output:
+	// 4449444c016e7b010000 null
+	// 4449444c016e7b01000101 1
+}
+
+func TestOpt_record(t *testing.T) {
+	type node struct {
+		Value uint8           `ic:"value"`
+		Next  idl.Opt[node]   `ic:"next"`
+		Name  idl.Opt[string] `ic:"name"`
+	}
+	in := node{Value: 1, Next: idl.Some(node{Value: 2})}
+	typ, err := idl.TypeOf(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := candid.Encode([]idl.Type{typ}, []any{in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out node
+	if err := candid.Unmarshal(raw, []any{&out}); err != nil {
+		t.Fatal(err)
+	}
+	next, ok := out.Next.Get()
+	if !ok || out.Value != 1 || next.Value != 2 || next.Next.IsSome() || out.Name.IsSome() {
+		t.Errorf("unexpected value: %+v", out)
+	}
+}
+
+func TestOpt_JSON(t *testing.T) {
+	raw, err := json.Marshal([]idl.Opt[string]{idl.Some("a"), idl.None[string]()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `["a",null]` {
+		t.Fatalf("unexpected json: %s", raw)
+	}
+	var out []idl.Opt[string]
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out[0].OrElse("") != "a" || out[1].IsSome() {
+		t.Errorf("unexpected value: %v", out)
+	}
+}
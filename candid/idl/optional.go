@@ -45,6 +45,7 @@ func (o OptionalType) AddTypeDefinition(tdt *TypeDefinitionTable) error {
 }
 
 // Decode decodes the value from the given reader into either `nil` or a value (of the subtype of the optional type).
+// Present values that are `nil` themselves, e.g. of `opt null` or `opt opt T`, are decoded into Some(nil).
 func (o OptionalType) Decode(r *bytes.Reader) (any, error) {
 	b, err := r.ReadByte()
 	if err != nil {
@@ -54,7 +55,15 @@ func (o OptionalType) Decode(r *bytes.Reader) (any, error) {
 	case 0x00:
 		return nil, nil
 	case 0x01:
-		return o.Type.Decode(r)
+		v, err := o.Type.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil && nullable(o.Type) {
+			// Present, e.g. `opt null`, unlike an absent value.
+			return Some[any](nil), nil
+		}
+		return v, nil
 	default:
 		return nil, fmt.Errorf("invalid option value: %x", b)
 	}
@@ -70,8 +79,21 @@ func (o OptionalType) EncodeType(tdt *TypeDefinitionTable) ([]byte, error) {
 }
 
 // EncodeValue encodes the value into a byte array.
-// Accepts `nil` or a value (of the subtype of the optional type).
+// Accepts `nil`, an Opt or a value (of the subtype of the optional type).
 func (o OptionalType) EncodeValue(v any) ([]byte, error) {
+	if opt, ok := v.(optional); ok {
+		if v, ok = opt.optional(); !ok {
+			return []byte{0x00}, nil
+		}
+		if v == nil {
+			// Some(nil), e.g. `opt null` or `opt opt T` with an absent value.
+			v_, err := o.Type.EncodeValue(nil)
+			if err != nil {
+				return nil, err
+			}
+			return append([]byte{0x01}, v_...), nil
+		}
+	}
 	if v == nil {
 		return []byte{0x00}, nil
 	}
@@ -113,7 +135,11 @@ func (o OptionalType) String() string {
 }
 
 func (o OptionalType) UnmarshalGo(raw any, _v any) error {
-	if raw == nil {
+	raw, present := optionalValue(raw)
+	if opt, ok := _v.(optionalSetter); ok {
+		return opt.setOptional(o.Type, raw, present)
+	}
+	if !present {
 		// Optional value is `nil`.
 		return nil
 	}
@@ -136,3 +162,12 @@ func (o OptionalType) UnmarshalGo(raw any, _v any) error {
 	// Nothing to assign to v.
 	return NewUnmarshalGoError(raw, _v)
 }
+
+// optionalValue returns the value of an optional value, which is either `nil`, an Opt
+// or the value itself, and whether it is present.
+func optionalValue(raw any) (any, bool) {
+	if opt, ok := raw.(optional); ok {
+		return opt.optional()
+	}
+	return raw, raw != nil
+}
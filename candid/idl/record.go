@@ -46,7 +46,7 @@ func NewRecordType(fields map[string]Type) *RecordType {
 		})
 	}
 	sort.Slice(rec.Fields, func(i, j int) bool {
-		return Hash(rec.Fields[i].Name).Cmp(Hash(rec.Fields[j].Name)) < 0
+		return FieldID(rec.Fields[i].Name).Cmp(FieldID(rec.Fields[j].Name)) < 0
 	})
 	return &rec
 }
@@ -72,7 +72,7 @@ func (record RecordType) AddTypeDefinition(tdt *TypeDefinitionTable) error {
 		if record.IsTuple {
 			h = big.NewInt(int64(i))
 		} else {
-			h = Hash(f.Name)
+			h = FieldID(f.Name)
 		}
 		l, err := leb128.EncodeUnsigned(h)
 		if err != nil {
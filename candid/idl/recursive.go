@@ -25,13 +25,18 @@ type RecursiveType struct {
 }
 
 // NewRecursiveType creates an unresolved placeholder with the given name.
-// Call setInner once the real type is built.
+// Call Resolve once the real type is built.
 func NewRecursiveType(name string) *RecursiveType {
 	return &RecursiveType{name: name}
 }
 
 func (r *RecursiveType) setInner(t Type) { r.inner = t }
 
+// Resolve sets the type the placeholder stands in for. It is the exported
+// counterpart of what TypeOf does internally, for recursive types that are built
+// outside this package, e.g. from the definitions of a .did file.
+func (r *RecursiveType) Resolve(t Type) { r.inner = t }
+
 // Used reports whether this placeholder was referenced during type expansion,
 // i.e. whether the type is genuinely recursive.
 func (r *RecursiveType) Used() bool { return r.used }
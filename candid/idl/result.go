@@ -0,0 +1,182 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// variantValue is implemented by the generic variant helpers (e.g. Result). It
+// lets VariantType encode the selected arm and lets TypeOf derive the variant
+// type from the arm types.
+type variantValue interface {
+	variant() (string, any, bool)
+	variantArms() map[string]reflect.Type
+}
+
+// variantSetter is implemented by pointers to the generic variant helpers, the
+// decoding counterpart of variantValue.
+type variantSetter interface {
+	setVariant(t VariantType, name string, raw any) error
+}
+
+// Result is a candid `variant { Ok : T; Err : E }` value, the common shape of
+// fallible canister methods. The zero value has no arm selected and can not be
+// encoded.
+type Result[T, E any] struct {
+	ok  *T
+	err *E
+}
+
+// Err returns a Result with the Err arm selected.
+func Err[T, E any](e E) Result[T, E] {
+	return Result[T, E]{err: &e}
+}
+
+// Ok returns a Result with the Ok arm selected.
+func Ok[T, E any](v T) Result[T, E] {
+	return Result[T, E]{ok: &v}
+}
+
+// Err returns the Err value and whether the Err arm is selected.
+func (r Result[T, E]) Err() (E, bool) {
+	if r.err == nil {
+		var zero E
+		return zero, false
+	}
+	return *r.err, true
+}
+
+// IsErr reports whether the Err arm is selected.
+func (r Result[T, E]) IsErr() bool {
+	return r.err != nil
+}
+
+// IsOk reports whether the Ok arm is selected.
+func (r Result[T, E]) IsOk() bool {
+	return r.ok != nil
+}
+
+// MarshalJSON encodes the result as a single-key object, {"Ok": v} or {"Err": e}.
+// Like encoding, it fails for the zero value.
+func (r Result[T, E]) MarshalJSON() ([]byte, error) {
+	switch {
+	case r.ok != nil:
+		return json.Marshal(map[string]T{"Ok": *r.ok})
+	case r.err != nil:
+		return json.Marshal(map[string]E{"Err": *r.err})
+	default:
+		return nil, fmt.Errorf("invalid result: no variant selected")
+	}
+}
+
+// Match calls ok or err depending on the selected arm. Both handlers are
+// required, so every case is handled at compile time.
+func (r Result[T, E]) Match(ok func(T) error, err func(E) error) error {
+	switch {
+	case r.ok != nil:
+		return ok(*r.ok)
+	case r.err != nil:
+		return err(*r.err)
+	default:
+		return fmt.Errorf("invalid result: no variant selected")
+	}
+}
+
+// Ok returns the Ok value and whether the Ok arm is selected.
+func (r Result[T, E]) Ok() (T, bool) {
+	if r.ok == nil {
+		var zero T
+		return zero, false
+	}
+	return *r.ok, true
+}
+
+// Unwrap returns the Ok value, or an error wrapping the Err value.
+func (r Result[T, E]) Unwrap() (T, error) {
+	var zero T
+	switch {
+	case r.ok != nil:
+		return *r.ok, nil
+	case r.err != nil:
+		if err, ok := any(*r.err).(error); ok {
+			return zero, err
+		}
+		return zero, fmt.Errorf("%v", *r.err)
+	default:
+		return zero, fmt.Errorf("invalid result: no variant selected")
+	}
+}
+
+// UnmarshalJSON decodes a single-key object, {"Ok": v} or {"Err": e}.
+func (r *Result[T, E]) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if len(m) != 1 {
+		return fmt.Errorf("invalid result: expected a single key, got %d", len(m))
+	}
+	*r = Result[T, E]{}
+	if raw, ok := m["Ok"]; ok {
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		r.ok = &v
+		return nil
+	}
+	if raw, ok := m["Err"]; ok {
+		var e E
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+		r.err = &e
+		return nil
+	}
+	return fmt.Errorf("invalid result: expected Ok or Err")
+}
+
+func (r *Result[T, E]) setVariant(t VariantType, name string, raw any) error {
+	for _, f := range t.Fields {
+		if f.Name != name {
+			continue
+		}
+		*r = Result[T, E]{}
+		switch name {
+		case "Ok", HashString("Ok"):
+			var v T
+			if err := UnmarshalGo(f.Type, raw, &v); err != nil {
+				return err
+			}
+			r.ok = &v
+			return nil
+		case "Err", HashString("Err"):
+			var e E
+			if err := UnmarshalGo(f.Type, raw, &e); err != nil {
+				return err
+			}
+			r.err = &e
+			return nil
+		}
+	}
+	return NewUnmarshalGoError(raw, r)
+}
+
+func (r Result[T, E]) variant() (string, any, bool) {
+	switch {
+	case r.ok != nil:
+		return "Ok", *r.ok, true
+	case r.err != nil:
+		return "Err", *r.err, true
+	default:
+		return "", nil, false
+	}
+}
+
+func (Result[T, E]) variantArms() map[string]reflect.Type {
+	return map[string]reflect.Type{
+		"Ok":  reflect.TypeFor[T](),
+		"Err": reflect.TypeFor[E](),
+	}
+}
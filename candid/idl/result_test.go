@@ -0,0 +1,146 @@
+package idl_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid"
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+func ExampleResult() {
+	type transferResult = idl.Result[idl.Nat, string]
+	raw, err := candid.Marshal([]any{idl.Ok[idl.Nat, string](idl.NewNat(uint(5)))})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%x\n", raw)
+
+	var r transferResult
+	if err := candid.Unmarshal(raw, []any{&r}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	_ = r.Match(
+		func(n idl.Nat) error { fmt.Println("ok:", n); return nil },
+		func(e string) error { fmt.Println("err:", e); return nil },
+	)
+	// Output:
+	// 4449444c016b02bc8a017dc5fed2017101000005
+	// ok: 5
+}
+
+func TestResult_struct(t *testing.T) {
+	// The generated struct-of-pointers form and Result share the same wire format.
+	type result struct {
+		Ok  *uint64 `ic:"Ok,variant"`
+		Err *string `ic:"Err,variant"`
+	}
+	msg := "insufficient funds"
+	raw, err := candid.Marshal([]any{result{Err: &msg}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r idl.Result[uint64, string]
+	if err := candid.Unmarshal(raw, []any{&r}); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := r.Err(); !ok || e != msg {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	if _, err := r.Unwrap(); err == nil || err.Error() != msg {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	raw2, err := candid.Marshal([]any{r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != string(raw2) {
+		t.Errorf("expected %x, got %x", raw, raw2)
+	}
+
+	if _, err := candid.Marshal([]any{idl.Result[uint64, string]{}}); err == nil {
+		t.Error("expected error for empty result")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	type status struct {
+		Running  *idl.Null `ic:"running,variant"`
+		Stopping *idl.Null `ic:"stopping,variant"`
+		Stopped  *uint64   `ic:"stopped,variant"`
+	}
+	v := status{Stopped: new(uint64(42))}
+	var got any
+	cases := idl.Cases{
+		"running":  func(any) error { return nil },
+		"stopping": func(any) error { return nil },
+	}
+	if err := idl.Match(v, cases); err == nil || err.Error() != "non-exhaustive match: missing stopped" {
+		t.Fatalf("expected non-exhaustive error, got %v", err)
+	}
+	cases["stopped"] = func(v any) error { got = v; return nil }
+	if err := idl.Match(&v, cases); err != nil {
+		t.Fatal(err)
+	}
+	if got != uint64(42) {
+		t.Errorf("unexpected value: %v", got)
+	}
+
+	// Decoded values carry hashed arm names.
+	raw, err := candid.Marshal([]any{v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vs, err := candid.Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	if err := idl.Match(vs[0], cases); err != nil {
+		t.Fatal(err)
+	}
+	if got != uint64(42) {
+		t.Errorf("unexpected value: %v", got)
+	}
+
+	typed := idl.Cases{
+		"running":  func(any) error { return nil },
+		"stopping": func(any) error { return nil },
+		"stopped":  idl.Case(func(n uint64) error { got = n + 1; return nil }),
+	}
+	if err := idl.Match(v, typed); err != nil || got != uint64(43) {
+		t.Errorf("unexpected result: %v, %v", got, err)
+	}
+	typed["stopped"] = idl.Case(func(string) error { return nil })
+	if err := idl.Match(v, typed); err == nil || err.Error() != "invalid value of arm: expected string, got uint64" {
+		t.Errorf("expected a type error, got %v", err)
+	}
+	typed["stoped"] = func(any) error { return nil }
+	if err := idl.Match(v, typed); err == nil || err.Error() != "invalid match: unknown stoped" {
+		t.Errorf("expected an unknown arm error, got %v", err)
+	}
+}
+
+func TestResult_JSON(t *testing.T) {
+	for _, r := range []idl.Result[uint64, string]{idl.Ok[uint64, string](42), idl.Err[uint64, string]("insufficient funds")} {
+		raw, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded idl.Result[uint64, string]
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(decoded.Unwrap()) != fmt.Sprint(r.Unwrap()) {
+			t.Errorf("expected %v, got %v", r, decoded)
+		}
+	}
+	// Like encoding, the zero value has no JSON representation.
+	if _, err := json.Marshal(idl.Result[uint64, string]{}); err == nil {
+		t.Error("expected an error for an empty result")
+	}
+}
@@ -3,7 +3,9 @@ package idl
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math/big"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -20,6 +22,25 @@ type Service struct {
 	Methods []Method
 }
 
+// ServiceReference is implemented by typed references to services, e.g. the ones
+// generated by gen. The underlying type must be principal.Principal, e.g.
+// `type Ledger principal.Principal`, and ServiceType must not depend on the value.
+type ServiceReference interface {
+	ServiceType() *Service
+}
+
+// serviceReference returns the principal of the given reference, if it is one.
+func serviceReference(v any) (principal.Principal, bool) {
+	if _, ok := v.(ServiceReference); !ok {
+		return principal.Principal{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.Type().ConvertibleTo(reflect.TypeFor[principal.Principal]()) {
+		return principal.Principal{}, false
+	}
+	return rv.Convert(reflect.TypeFor[principal.Principal]()).Interface().(principal.Principal), true
+}
+
 func NewServiceType(methods map[string]*FunctionType) *Service {
 	var service Service
 	for k, v := range methods {
@@ -82,13 +103,10 @@ func (s Service) Decode(r *bytes.Reader) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The principal is the last part of the value, and can be empty (aaaaa-aa).
 	pid := make([]byte, l)
-	n, err := r.Read(pid)
-	if err != nil {
-		return nil, err
-	}
-	if n != l {
-		return nil, fmt.Errorf("invalid principal id: %d", pid)
+	if _, err := io.ReadFull(r, pid); err != nil {
+		return nil, fmt.Errorf("invalid principal id: %w", err)
 	}
 	return &principal.Principal{Raw: pid}, nil
 }
@@ -104,7 +122,9 @@ func (s Service) EncodeType(tdt *TypeDefinitionTable) ([]byte, error) {
 func (s Service) EncodeValue(v any) ([]byte, error) {
 	p, ok := v.(principal.Principal)
 	if !ok {
-		return nil, NewEncodeValueError(v, ServiceOpCode)
+		if p, ok = serviceReference(v); !ok {
+			return nil, NewEncodeValueError(v, ServiceOpCode)
+		}
 	}
 	l, err := leb128.EncodeUnsigned(big.NewInt(int64(len(p.Raw))))
 	if err != nil {
@@ -134,14 +154,8 @@ func (s Service) Read(r *bytes.Reader) ([]byte, error) {
 		return nil, err
 	}
 	pid := make([]byte, l)
-	{
-		n, err := r.Read(pid)
-		if err != nil {
-			return nil, err
-		}
-		if n != l {
-			return nil, fmt.Errorf("invalid principal id: %d", pid)
-		}
+	if _, err := io.ReadFull(r, pid); err != nil {
+		return nil, fmt.Errorf("invalid principal id: %w", err)
 	}
 	return concat([]byte{b}, raw, pid), nil
 }
@@ -155,5 +169,27 @@ func (s Service) String() string {
 }
 
 func (Service) UnmarshalGo(raw any, _v any) error {
-	return NewUnmarshalGoError(raw, _v)
+	var p principal.Principal
+	switch raw := raw.(type) {
+	case principal.Principal:
+		p = raw
+	case *principal.Principal:
+		p = *raw
+	default:
+		return NewUnmarshalGoError(raw, _v)
+	}
+	if v, ok := _v.(*principal.Principal); ok {
+		*v = p
+		return nil
+	}
+	// Typed references, e.g. `type Ledger principal.Principal`.
+	rv := reflect.ValueOf(_v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return NewUnmarshalGoError(raw, _v)
+	}
+	if _, ok := serviceReference(rv.Elem().Interface()); !ok {
+		return NewUnmarshalGoError(raw, _v)
+	}
+	rv.Elem().Set(reflect.ValueOf(p).Convert(rv.Elem().Type()))
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"math"
 	"math/big"
 	"reflect"
+	"strconv"
 
 	"github.com/aviate-labs/agent-go/leb128"
 )
@@ -56,6 +57,20 @@ func decodeLen(r *bytes.Reader) (int, error) {
 	return checkLen(l, r)
 }
 
+// numberText returns the decimal representation of a number scanned from a database.
+func numberText(src any) ([]byte, error) {
+	switch src := src.(type) {
+	case int64:
+		return strconv.AppendInt(nil, src, 10), nil
+	case string:
+		return []byte(src), nil
+	case []byte:
+		return src, nil
+	default:
+		return nil, fmt.Errorf("can not scan %T into a number", src)
+	}
+}
+
 func log2(n uint8) uint8 {
 	return uint8(math.Log2(float64(n)))
 }
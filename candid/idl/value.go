@@ -0,0 +1,708 @@
+package idl
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// underlying unwraps recursive placeholders and returns composite types in their
+// pointer form, so callers only have to switch on one form of every type.
+func underlying(t Type) Type {
+	switch t := t.(type) {
+	case *RecursiveType:
+		return underlying(t.inner)
+	case NullType:
+		return &t
+	case BoolType:
+		return &t
+	case NatType:
+		return &t
+	case IntType:
+		return &t
+	case FloatType:
+		return &t
+	case TextType:
+		return &t
+	case ReservedType:
+		return &t
+	case EmptyType:
+		return &t
+	case PrincipalType:
+		return &t
+	case OptionalType:
+		return &t
+	case VectorType:
+		return &t
+	case RecordType:
+		return &t
+	case VariantType:
+		return &t
+	case FunctionType:
+		return &t
+	case Service:
+		return &t
+	default:
+		return t
+	}
+}
+
+// nullable reports whether the type has a value that is represented as nil, i.e.
+// opt, null and reserved. Present optional values of these types are explicit.
+func nullable(t Type) bool {
+	switch underlying(t).(type) {
+	case *OptionalType, *NullType, *ReservedType:
+		return true
+	default:
+		return false
+	}
+}
+
+// isBlob reports whether the type is `vec nat8`.
+func isBlob(t *VectorType) bool {
+	n, ok := underlying(t.Type).(*NatType)
+	return ok && n.size == 1
+}
+
+// Value is a candid value that is decoded without a Go type. It keeps its type next
+// to the value, so it can be inspected, printed, converted to JSON and encoded again.
+//
+// Field labels of decoded records and variants are hashes (e.g. "17724") unless the
+// value is annotated with the expected type, see Annotate.
+type Value struct {
+	// Type is the type of the value.
+	Type Type
+	// Value is the primitive value: nil (null, reserved), bool, Nat, Int, uint8,
+	// uint16, uint32, uint64, int8, int16, int32, int64, float32, float64, string,
+	// principal.Principal (principal, service), *PrincipalMethod (func) or []byte
+	// (blob).
+	Value any
+	// Fields are the fields of a record, or the selected arm of a variant.
+	Fields []ValueField
+	// Elements are the elements of a vector (except for blobs), or the value of a
+	// present optional.
+	Elements []Value
+}
+
+// NewValue creates a value from the given type and the value as returned by
+// Type.Decode.
+func NewValue(t Type, v any) (Value, error) {
+	switch u := underlying(t).(type) {
+	case *OptionalType:
+		if opt, ok := v.(optional); ok {
+			inner, ok := opt.optional()
+			if !ok {
+				return Value{Type: t}, nil
+			}
+			e, err := NewValue(u.Type, inner)
+			if err != nil {
+				return Value{}, err
+			}
+			return Value{Type: t, Elements: []Value{e}}, nil
+		}
+		if v == nil {
+			return Value{Type: t}, nil
+		}
+		e, err := NewValue(u.Type, v)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Type: t, Elements: []Value{e}}, nil
+	case *VectorType:
+		vs, ok := v.([]any)
+		if !ok && v != nil {
+			if bs, ok := v.([]byte); ok && isBlob(u) {
+				return Value{Type: t, Value: bs}, nil
+			}
+			return Value{}, NewUnmarshalGoError(v, Value{})
+		}
+		if isBlob(u) {
+			bs := make([]byte, len(vs))
+			for i, b := range vs {
+				b, ok := b.(uint8)
+				if !ok {
+					return Value{}, NewUnmarshalGoError(vs[i], Value{})
+				}
+				bs[i] = b
+			}
+			return Value{Type: t, Value: bs}, nil
+		}
+		elements := make([]Value, len(vs))
+		for i, v := range vs {
+			e, err := NewValue(u.Type, v)
+			if err != nil {
+				return Value{}, err
+			}
+			elements[i] = e
+		}
+		return Value{Type: t, Elements: elements}, nil
+	case *RecordType:
+		m, ok := v.(map[string]any)
+		if !ok && v != nil {
+			return Value{}, NewUnmarshalGoError(v, Value{})
+		}
+		fields := make([]ValueField, len(u.Fields))
+		for i, f := range u.Fields {
+			fv, err := NewValue(f.Type, m[f.Name])
+			if err != nil {
+				return Value{}, err
+			}
+			fields[i] = ValueField{Name: fieldName(u, i), Value: fv}
+		}
+		return Value{Type: t, Fields: fields}, nil
+	case *VariantType:
+		var variant Variant
+		switch v := v.(type) {
+		case *Variant:
+			variant = *v
+		case Variant:
+			variant = v
+		default:
+			return Value{}, NewUnmarshalGoError(v, Value{})
+		}
+		for _, f := range u.Fields {
+			if f.Name != variant.Name {
+				continue
+			}
+			fv, err := NewValue(f.Type, variant.Value)
+			if err != nil {
+				return Value{}, err
+			}
+			return Value{Type: t, Fields: []ValueField{{Name: f.Name, Value: fv}}}, nil
+		}
+		return Value{}, fmt.Errorf("unknown variant: %s", variant.Name)
+	case *Service:
+		if p, ok := v.(*principal.Principal); ok {
+			return Value{Type: t, Value: *p}, nil
+		}
+		return Value{Type: t, Value: v}, nil
+	default:
+		return Value{Type: t, Value: v}, nil
+	}
+}
+
+// Any returns the value in the form accepted by Type.EncodeValue.
+func (v Value) Any() any {
+	switch u := underlying(v.Type).(type) {
+	case *OptionalType:
+		if len(v.Elements) == 0 {
+			return nil
+		}
+		if nullable(u.Type) {
+			// nil would be absent.
+			return Some(v.Elements[0].Any())
+		}
+		return v.Elements[0].Any()
+	case *VectorType:
+		if bs, ok := v.Value.([]byte); ok {
+			return bs
+		}
+		vs := make([]any, len(v.Elements))
+		for i, e := range v.Elements {
+			vs[i] = e.Any()
+		}
+		return vs
+	case *RecordType:
+		m := make(map[string]any)
+		for i, f := range v.Fields {
+			name := f.Name
+			if i < len(u.Fields) {
+				name = fieldName(u, i)
+			}
+			m[name] = f.Value.Any()
+		}
+		return m
+	case *VariantType:
+		if len(v.Fields) == 0 {
+			return nil
+		}
+		f := v.Fields[0]
+		name := f.Name
+		if i := fieldIndex(u.Fields, f.Name); i != -1 {
+			name = u.Fields[i].Name
+		}
+		return Variant{Name: name, Value: f.Value.Any(), Type: v.Type}
+	default:
+		return v.Value
+	}
+}
+
+// Annotate replaces the hashed labels of the value by the labels of the expected
+// type, e.g. the type declared in a .did file. Fields that are not part of the
+// expected type keep their hashed label.
+func (v *Value) Annotate(expected Type) error {
+	if expected == nil {
+		return nil
+	}
+	switch e := underlying(expected).(type) {
+	case *OptionalType:
+		if _, ok := underlying(v.Type).(*OptionalType); !ok {
+			return v.Annotate(e.Type)
+		}
+		for i := range v.Elements {
+			if err := v.Elements[i].Annotate(e.Type); err != nil {
+				return err
+			}
+		}
+	case *VectorType:
+		for i := range v.Elements {
+			if err := v.Elements[i].Annotate(e.Type); err != nil {
+				return err
+			}
+		}
+	case *RecordType:
+		if _, ok := underlying(v.Type).(*RecordType); !ok {
+			return fmt.Errorf("can not annotate a non-record value with a record type")
+		}
+		return annotateFields(v.Fields, recordFields(e))
+	case *VariantType:
+		if _, ok := underlying(v.Type).(*VariantType); !ok {
+			return fmt.Errorf("can not annotate a non-variant value with a variant type")
+		}
+		return annotateFields(v.Fields, e.Fields)
+	}
+	return nil
+}
+
+// Convert converts the value to the given type, e.g. a value parsed from the textual
+// format, of which the types are inferred, to the declared type. Numbers are converted
+// without loss of precision and checked against the range of the type.
+func (v Value) Convert(t Type) (Value, error) {
+	switch u := underlying(t).(type) {
+	case *NullType:
+		if _, ok := underlying(v.Type).(*NullType); !ok {
+			return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+		}
+		return Value{Type: t}, nil
+	case *ReservedType:
+		return Value{Type: t}, nil
+	case *NatType:
+		bi, ok := valueInteger(v)
+		if !ok {
+			return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+		}
+		return natValue(t, u, bi)
+	case *IntType:
+		bi, ok := valueInteger(v)
+		if !ok {
+			return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+		}
+		return intValue(t, u, bi)
+	case *FloatType:
+		var f float64
+		switch x := v.Value.(type) {
+		case float32:
+			f = float64(x)
+		case float64:
+			f = x
+		default:
+			bi, ok := valueInteger(v)
+			if !ok {
+				return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+			}
+			f, _ = new(big.Float).SetInt(bi).Float64()
+		}
+		if u.size == 4 {
+			return Value{Type: t, Value: float32(f)}, nil
+		}
+		return Value{Type: t, Value: f}, nil
+	case *OptionalType:
+		switch underlying(v.Type).(type) {
+		case *NullType:
+			return Value{Type: t}, nil
+		case *OptionalType:
+			if len(v.Elements) == 0 {
+				return Value{Type: t}, nil
+			}
+			v = v.Elements[0]
+		}
+		e, err := v.Convert(u.Type)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Type: t, Elements: []Value{e}}, nil
+	case *VectorType:
+		if _, ok := underlying(v.Type).(*VectorType); !ok {
+			return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+		}
+		if bs, ok := v.Value.([]byte); ok {
+			if isBlob(u) {
+				return Value{Type: t, Value: bs}, nil
+			}
+			for _, b := range bs {
+				v.Elements = append(v.Elements, Value{Type: Nat8Type(), Value: b})
+			}
+		}
+		elements := make([]Value, len(v.Elements))
+		for i, e := range v.Elements {
+			e, err := e.Convert(u.Type)
+			if err != nil {
+				return Value{}, fmt.Errorf("%d: %w", i, err)
+			}
+			elements[i] = e
+		}
+		if isBlob(u) {
+			bs := make([]byte, len(elements))
+			for i, e := range elements {
+				bs[i] = e.Value.(uint8)
+			}
+			return Value{Type: t, Value: bs}, nil
+		}
+		return Value{Type: t, Elements: elements}, nil
+	case *RecordType:
+		if _, ok := underlying(v.Type).(*RecordType); !ok {
+			return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+		}
+		fields := recordFields(u)
+		values := make([]ValueField, len(fields))
+		used := make([]bool, len(v.Fields))
+		for i, f := range fields {
+			fv := Value{Type: new(NullType)} // Missing fields are only valid for opt, null and reserved.
+			for j, vf := range v.Fields {
+				if !used[j] && FieldID(vf.Name).Cmp(FieldID(f.Name)) == 0 {
+					fv, used[j] = vf.Value, true
+					break
+				}
+			}
+			e, err := fv.Convert(f.Type)
+			if err != nil {
+				return Value{}, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			values[i] = ValueField{Name: f.Name, Value: e}
+		}
+		for j, ok := range used {
+			if !ok {
+				return Value{}, fmt.Errorf("unknown field: %s", v.Fields[j].Name)
+			}
+		}
+		return Value{Type: t, Fields: values}, nil
+	case *VariantType:
+		if _, ok := underlying(v.Type).(*VariantType); !ok || len(v.Fields) != 1 {
+			return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+		}
+		f := v.Fields[0]
+		i := fieldIndex(u.Fields, f.Name)
+		if i == -1 {
+			return Value{}, fmt.Errorf("unknown variant: %s", f.Name)
+		}
+		e, err := f.Value.Convert(u.Fields[i].Type)
+		if err != nil {
+			return Value{}, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		return Value{Type: t, Fields: []ValueField{{Name: u.Fields[i].Name, Value: e}}}, nil
+	default:
+		if reflect.TypeOf(underlying(v.Type)) != reflect.TypeOf(u) {
+			return Value{}, fmt.Errorf("can not convert %s to %s", v.Type, u)
+		}
+		v.Type = t
+		return v, nil
+	}
+}
+
+// Field returns the field (or selected variant arm) with the given name. The name
+// matches both annotated and hashed labels.
+func (v Value) Field(name string) (Value, bool) {
+	for _, f := range v.Fields {
+		if f.Name == name || f.Name == HashString(name) {
+			return f.Value, true
+		}
+	}
+	return Value{}, false
+}
+
+// Get returns the value at the given path. Every element of the path is either a
+// record field, the name of the selected variant arm, or an index into a vector.
+// Present optional values are unwrapped on the way.
+//
+// Example:
+//
+//	balance, err := v.Get("Ok", "balance")
+func (v Value) Get(path ...string) (Value, error) {
+	current := v
+	for i, p := range path {
+		for {
+			if _, ok := underlying(current.Type).(*OptionalType); !ok {
+				break
+			}
+			if len(current.Elements) == 0 {
+				return Value{}, fmt.Errorf("%s: null optional value", strings.Join(path[:i], "."))
+			}
+			current = current.Elements[0]
+		}
+		switch underlying(current.Type).(type) {
+		case *RecordType, *VariantType:
+			next, ok := current.Field(p)
+			if !ok {
+				return Value{}, fmt.Errorf("%s: no field %q", strings.Join(path[:i], "."), p)
+			}
+			current = next
+		case *VectorType:
+			idx, err := strconv.Atoi(p)
+			if err != nil {
+				return Value{}, fmt.Errorf("%s: invalid index %q", strings.Join(path[:i], "."), p)
+			}
+			if bs, ok := current.Value.([]byte); ok {
+				if idx < 0 || len(bs) <= idx {
+					return Value{}, fmt.Errorf("%s: index out of range: %d", strings.Join(path[:i], "."), idx)
+				}
+				current = Value{Type: Nat8Type(), Value: bs[idx]}
+				continue
+			}
+			if idx < 0 || len(current.Elements) <= idx {
+				return Value{}, fmt.Errorf("%s: index out of range: %d", strings.Join(path[:i], "."), idx)
+			}
+			current = current.Elements[idx]
+		default:
+			return Value{}, fmt.Errorf("%s: can not access %q in a primitive value", strings.Join(path[:i], "."), p)
+		}
+	}
+	return current, nil
+}
+
+// String returns the candid textual representation of the value.
+func (v Value) String() string {
+	var b strings.Builder
+	v.writeString(&b)
+	return b.String()
+}
+
+func (v Value) writeString(b *strings.Builder) {
+	switch u := underlying(v.Type).(type) {
+	case *NullType:
+		b.WriteString("null")
+	case *ReservedType:
+		b.WriteString("reserved")
+	case *BoolType:
+		fmt.Fprintf(b, "%t", v.Value)
+	case *NatType, *IntType:
+		fmt.Fprintf(b, "%v : %s", v.Value, u)
+	case *FloatType:
+		switch f := v.Value.(type) {
+		case float32:
+			fmt.Fprintf(b, "%s : %s", strconv.FormatFloat(float64(f), 'g', -1, 32), u)
+		case float64:
+			fmt.Fprintf(b, "%s : %s", strconv.FormatFloat(f, 'g', -1, 64), u)
+		default:
+			fmt.Fprintf(b, "%v : %s", v.Value, u)
+		}
+	case *TextType:
+		b.WriteString(strconv.Quote(fmt.Sprint(v.Value)))
+	case *PrincipalType:
+		fmt.Fprintf(b, "principal %q", v.Value)
+	case *Service:
+		fmt.Fprintf(b, "service %q", v.Value)
+	case *FunctionType:
+		if pm, ok := v.Value.(*PrincipalMethod); ok {
+			fmt.Fprintf(b, "func %q.%s", pm.Principal, quoteLabel(pm.Method))
+			return
+		}
+		fmt.Fprintf(b, "%v", v.Value)
+	case *OptionalType:
+		if len(v.Elements) == 0 {
+			b.WriteString("null")
+			return
+		}
+		b.WriteString("opt ")
+		v.Elements[0].writeString(b)
+	case *VectorType:
+		if bs, ok := v.Value.([]byte); ok {
+			b.WriteString(`blob "`)
+			for _, c := range bs {
+				fmt.Fprintf(b, "\\%02x", c)
+			}
+			b.WriteString(`"`)
+			return
+		}
+		if len(v.Elements) == 0 {
+			b.WriteString("vec {}")
+			return
+		}
+		b.WriteString("vec { ")
+		for i, e := range v.Elements {
+			if i != 0 {
+				b.WriteString("; ")
+			}
+			e.writeString(b)
+		}
+		b.WriteString(" }")
+	case *RecordType:
+		if len(v.Fields) == 0 {
+			b.WriteString("record {}")
+			return
+		}
+		b.WriteString("record { ")
+		for i, f := range v.Fields {
+			if i != 0 {
+				b.WriteString("; ")
+			}
+			fmt.Fprintf(b, "%s = ", quoteLabel(f.Name))
+			f.Value.writeString(b)
+		}
+		b.WriteString(" }")
+	case *VariantType:
+		if len(v.Fields) == 0 {
+			b.WriteString("variant {}")
+			return
+		}
+		f := v.Fields[0]
+		if _, ok := underlying(f.Value.Type).(*NullType); ok {
+			fmt.Fprintf(b, "variant { %s }", quoteLabel(f.Name))
+			return
+		}
+		fmt.Fprintf(b, "variant { %s = ", quoteLabel(f.Name))
+		f.Value.writeString(b)
+		b.WriteString(" }")
+	default:
+		fmt.Fprintf(b, "%v", v.Value)
+	}
+}
+
+// ValueField is a labelled field of a record or variant value.
+type ValueField struct {
+	// Name is the label of the field, or its hash if the label is unknown.
+	Name string
+	// Value is the value of the field.
+	Value Value
+}
+
+// annotateFields renames the given fields to the labels of the matching expected
+// fields and annotates their values.
+func annotateFields(fields []ValueField, expected []FieldType) error {
+	for i, f := range fields {
+		j := fieldIndex(expected, f.Name)
+		if j == -1 {
+			continue
+		}
+		fields[i].Name = expected[j].Name
+		if err := fields[i].Value.Annotate(expected[j].Type); err != nil {
+			return fmt.Errorf("%s: %w", expected[j].Name, err)
+		}
+	}
+	return nil
+}
+
+// fieldIndex returns the index of the field with the same id as the given label,
+// or -1 if there is no such field.
+func fieldIndex(fields []FieldType, label string) int {
+	id := FieldID(label)
+	for i, f := range fields {
+		if FieldID(f.Name).Cmp(id) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordFields returns the fields of the record, with tuple fields labelled by
+// their position.
+func recordFields(r *RecordType) []FieldType {
+	fields := make([]FieldType, len(r.Fields))
+	for i, f := range r.Fields {
+		fields[i] = FieldType{Name: fieldName(r, i), Type: f.Type}
+	}
+	return fields
+}
+
+// fieldName returns the label of the i-th field of the record, which is its
+// position for tuples.
+func fieldName(r *RecordType, i int) string {
+	if r.Fields[i].Name == "" {
+		return strconv.Itoa(i)
+	}
+	return r.Fields[i].Name
+}
+
+// natValue returns the value of a nat type, checked against the size of the type.
+func natValue(t Type, u *NatType, bi *big.Int) (Value, error) {
+	if bi.Sign() < 0 {
+		return Value{}, fmt.Errorf("invalid %s: %s", u, bi)
+	}
+	if u.size == 0 {
+		return Value{Type: t, Value: NewBigNat(bi)}, nil
+	}
+	if bi.BitLen() > int(u.size)*8 {
+		return Value{}, fmt.Errorf("%s out of range: %s", u, bi)
+	}
+	n := bi.Uint64()
+	switch u.size {
+	case 1:
+		return Value{Type: t, Value: uint8(n)}, nil
+	case 2:
+		return Value{Type: t, Value: uint16(n)}, nil
+	case 4:
+		return Value{Type: t, Value: uint32(n)}, nil
+	default:
+		return Value{Type: t, Value: n}, nil
+	}
+}
+
+// intValue returns the value of an int type, checked against the size of the type.
+func intValue(t Type, u *IntType, bi *big.Int) (Value, error) {
+	if u.size == 0 {
+		return Value{Type: t, Value: NewBigInt(bi)}, nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(u.size)*8-1)
+	if bi.Cmp(limit) >= 0 || bi.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return Value{}, fmt.Errorf("%s out of range: %s", u, bi)
+	}
+	i := bi.Int64()
+	switch u.size {
+	case 1:
+		return Value{Type: t, Value: int8(i)}, nil
+	case 2:
+		return Value{Type: t, Value: int16(i)}, nil
+	case 4:
+		return Value{Type: t, Value: int32(i)}, nil
+	default:
+		return Value{Type: t, Value: i}, nil
+	}
+}
+
+// valueInteger returns the integer of a nat or int value.
+func valueInteger(v Value) (*big.Int, bool) {
+	switch x := v.Value.(type) {
+	case Nat:
+		return x.bigInt(), true
+	case Int:
+		return x.bigInt(), true
+	case uint8:
+		return new(big.Int).SetUint64(uint64(x)), true
+	case uint16:
+		return new(big.Int).SetUint64(uint64(x)), true
+	case uint32:
+		return new(big.Int).SetUint64(uint64(x)), true
+	case uint64:
+		return new(big.Int).SetUint64(x), true
+	case int8:
+		return big.NewInt(int64(x)), true
+	case int16:
+		return big.NewInt(int64(x)), true
+	case int32:
+		return big.NewInt(int64(x)), true
+	case int64:
+		return big.NewInt(x), true
+	default:
+		return nil, false
+	}
+}
+
+// quoteLabel quotes labels that are not valid candid identifiers or numbers.
+func quoteLabel(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if _, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return s
+	}
+	for i, r := range s {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || (i != 0 && '0' <= r && r <= '9') {
+			continue
+		}
+		return strconv.Quote(s)
+	}
+	return s
+}
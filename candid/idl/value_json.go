@@ -0,0 +1,358 @@
+package idl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// ValueFromJSON creates a value of the given type from its JSON representation.
+// See Value.MarshalJSON for the mapping. Record fields can be given by label or by
+// hash, absent optional fields are null.
+func ValueFromJSON(t Type, data []byte) (Value, error) {
+	return JSONEncoding{}.Unmarshal(t, data)
+}
+
+// BlobEncoding is the encoding of blobs in JSON.
+type BlobEncoding int
+
+const (
+	// BlobHex encodes blobs as hex strings, e.g. "00ff".
+	BlobHex BlobEncoding = iota
+	// BlobBase64 encodes blobs as standard base64 strings, e.g. "AP8=".
+	BlobBase64
+)
+
+// JSONEncoding converts values to and from JSON. The zero value encodes blobs as
+// hex strings, the mapping is described by Value.MarshalJSON.
+type JSONEncoding struct {
+	// Blob is the encoding of blobs.
+	Blob BlobEncoding
+}
+
+// Marshal encodes the value as JSON.
+func (e JSONEncoding) Marshal(v Value) ([]byte, error) {
+	return json.Marshal(e.jsonValue(v))
+}
+
+// Unmarshal creates a value of the given type from its JSON representation.
+func (e JSONEncoding) Unmarshal(t Type, data []byte) (Value, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var raw any
+	if err := d.Decode(&raw); err != nil {
+		return Value{}, err
+	}
+	if d.More() {
+		return Value{}, fmt.Errorf("invalid json: trailing data")
+	}
+	return e.fromJSON(t, raw)
+}
+
+func (e JSONEncoding) decodeBlob(s string) ([]byte, error) {
+	if e.Blob == BlobBase64 {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return hex.DecodeString(s)
+}
+
+func (e JSONEncoding) encodeBlob(bs []byte) string {
+	if e.Blob == BlobBase64 {
+		return base64.StdEncoding.EncodeToString(bs)
+	}
+	return hex.EncodeToString(bs)
+}
+
+func (e JSONEncoding) fromJSON(t Type, raw any) (Value, error) {
+	switch u := underlying(t).(type) {
+	case *NullType, *ReservedType:
+		if raw != nil {
+			return Value{}, jsonTypeError(raw, u.String())
+		}
+		return Value{Type: t}, nil
+	case *BoolType:
+		b, ok := raw.(bool)
+		if !ok {
+			return Value{}, jsonTypeError(raw, u.String())
+		}
+		return Value{Type: t, Value: b}, nil
+	case *NatType:
+		bi, err := jsonInteger(raw, u)
+		if err != nil {
+			return Value{}, err
+		}
+		return natValue(t, u, bi)
+	case *IntType:
+		bi, err := jsonInteger(raw, u)
+		if err != nil {
+			return Value{}, err
+		}
+		return intValue(t, u, bi)
+	case *FloatType:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return Value{}, jsonTypeError(raw, u.String())
+		}
+		bitSize := 64
+		if u.size == 4 {
+			bitSize = 32
+		}
+		f, err := strconv.ParseFloat(n.String(), bitSize)
+		if err != nil {
+			return Value{}, err
+		}
+		if bitSize == 32 {
+			return Value{Type: t, Value: float32(f)}, nil
+		}
+		return Value{Type: t, Value: f}, nil
+	case *TextType:
+		s, ok := raw.(string)
+		if !ok {
+			return Value{}, jsonTypeError(raw, u.String())
+		}
+		return Value{Type: t, Value: s}, nil
+	case *PrincipalType, *Service:
+		s, ok := raw.(string)
+		if !ok {
+			return Value{}, jsonTypeError(raw, u.String())
+		}
+		p, err := principal.Decode(s)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Type: t, Value: p}, nil
+	case *FunctionType:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return Value{}, jsonTypeError(raw, "func")
+		}
+		s, _ := m["principal"].(string)
+		method, ok := m["method"].(string)
+		if !ok {
+			return Value{}, fmt.Errorf("invalid func reference: missing method")
+		}
+		p, err := principal.Decode(s)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Type: t, Value: &PrincipalMethod{Principal: p, Method: method}}, nil
+	case *OptionalType:
+		if raw == nil {
+			return Value{Type: t}, nil
+		}
+		if nullable(u.Type) {
+			// The value is wrapped, otherwise null would be ambiguous.
+			vs, ok := raw.([]any)
+			if !ok || len(vs) != 1 {
+				return Value{}, jsonTypeError(raw, u.String())
+			}
+			raw = vs[0]
+		}
+		v, err := e.fromJSON(u.Type, raw)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Type: t, Elements: []Value{v}}, nil
+	case *VectorType:
+		if isBlob(u) {
+			if s, ok := raw.(string); ok {
+				bs, err := e.decodeBlob(s)
+				if err != nil {
+					return Value{}, fmt.Errorf("invalid blob: %w", err)
+				}
+				return Value{Type: t, Value: bs}, nil
+			}
+		}
+		vs, ok := raw.([]any)
+		if !ok {
+			return Value{}, jsonTypeError(raw, "vec")
+		}
+		elements := make([]Value, len(vs))
+		for i, raw := range vs {
+			v, err := e.fromJSON(u.Type, raw)
+			if err != nil {
+				return Value{}, fmt.Errorf("%d: %w", i, err)
+			}
+			elements[i] = v
+		}
+		if isBlob(u) {
+			bs := make([]byte, len(elements))
+			for i, v := range elements {
+				bs[i] = v.Value.(uint8)
+			}
+			return Value{Type: t, Value: bs}, nil
+		}
+		return Value{Type: t, Elements: elements}, nil
+	case *RecordType:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			if vs, ok := raw.([]any); ok {
+				// Tuples can also be given as arrays.
+				m = make(map[string]any, len(vs))
+				for i, v := range vs {
+					m[strconv.Itoa(i)] = v
+				}
+			} else {
+				return Value{}, jsonTypeError(raw, "record")
+			}
+		}
+		fields := recordFields(u)
+		values := make([]ValueField, len(fields))
+		for i, f := range fields {
+			var fv any
+			for k, v := range m {
+				if FieldID(k).Cmp(FieldID(f.Name)) == 0 {
+					fv = v
+					delete(m, k)
+					break
+				}
+			}
+			v, err := e.fromJSON(f.Type, fv)
+			if err != nil {
+				return Value{}, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			values[i] = ValueField{Name: f.Name, Value: v}
+		}
+		for k := range m {
+			return Value{}, fmt.Errorf("unknown field: %s", k)
+		}
+		return Value{Type: t, Fields: values}, nil
+	case *VariantType:
+		var (
+			name  string
+			value any
+		)
+		switch raw := raw.(type) {
+		case string:
+			// Arms without a value can also be given by name.
+			name = raw
+		case map[string]any:
+			if len(raw) != 1 {
+				return Value{}, fmt.Errorf("invalid variant: expected a single key, got %d", len(raw))
+			}
+			for k, v := range raw {
+				name, value = k, v
+			}
+		default:
+			return Value{}, jsonTypeError(raw, "variant")
+		}
+		i := fieldIndex(u.Fields, name)
+		if i == -1 {
+			return Value{}, fmt.Errorf("unknown variant: %s", name)
+		}
+		v, err := e.fromJSON(u.Fields[i].Type, value)
+		if err != nil {
+			return Value{}, fmt.Errorf("%s: %w", name, err)
+		}
+		return Value{Type: t, Fields: []ValueField{{Name: u.Fields[i].Name, Value: v}}}, nil
+	default:
+		return Value{}, fmt.Errorf("can not decode json into %T", u)
+	}
+}
+
+// MarshalJSON encodes the value as JSON:
+//
+//   - null and reserved are null, bool is a boolean and text is a string.
+//   - nat8 to nat32, int8 to int32 and floats are numbers. nat, int, nat64 and
+//     int64 are strings, so they do not lose precision in JSON parsers.
+//   - blob is a hex string (see JSONEncoding for base64), other vectors are arrays.
+//   - principal and service are their textual representation.
+//   - opt is null or the value. If the value itself can be null, i.e. it is an opt,
+//     null or reserved, it is wrapped in an array: `opt opt nat` is null, [null]
+//     or [1].
+//   - record is an object keyed by the field labels.
+//   - variant is an object with a single key, the selected arm.
+//   - func is an object with a "principal" and a "method".
+func (v Value) MarshalJSON() ([]byte, error) {
+	return JSONEncoding{}.Marshal(v)
+}
+
+// UnmarshalJSON decodes JSON into the value. The type of the value must be set.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	if v.Type == nil {
+		return fmt.Errorf("can not decode json into a value without type")
+	}
+	value, err := JSONEncoding{}.Unmarshal(v.Type, data)
+	if err != nil {
+		return err
+	}
+	*v = value
+	return nil
+}
+
+func (e JSONEncoding) jsonValue(v Value) any {
+	switch u := underlying(v.Type).(type) {
+	case *NatType:
+		if u.size == 0 || u.size == 8 {
+			return fmt.Sprint(v.Value)
+		}
+		return v.Value
+	case *IntType:
+		if u.size == 0 || u.size == 8 {
+			return fmt.Sprint(v.Value)
+		}
+		return v.Value
+	case *PrincipalType, *Service:
+		return fmt.Sprint(v.Value)
+	case *FunctionType:
+		pm, ok := v.Value.(*PrincipalMethod)
+		if !ok {
+			return nil
+		}
+		return map[string]string{"principal": pm.Principal.String(), "method": pm.Method}
+	case *OptionalType:
+		if len(v.Elements) == 0 {
+			return nil
+		}
+		if nullable(u.Type) {
+			return []any{e.jsonValue(v.Elements[0])}
+		}
+		return e.jsonValue(v.Elements[0])
+	case *VectorType:
+		if bs, ok := v.Value.([]byte); ok {
+			return e.encodeBlob(bs)
+		}
+		vs := make([]any, len(v.Elements))
+		for i, elem := range v.Elements {
+			vs[i] = e.jsonValue(elem)
+		}
+		return vs
+	case *RecordType, *VariantType:
+		m := make(map[string]any, len(v.Fields))
+		for _, f := range v.Fields {
+			m[f.Name] = e.jsonValue(f.Value)
+		}
+		return m
+	case *NullType, *ReservedType:
+		return nil
+	default:
+		return v.Value
+	}
+}
+
+func jsonInteger(raw any, t Type) (*big.Int, error) {
+	var s string
+	switch raw := raw.(type) {
+	case json.Number:
+		s = raw.String()
+	case string:
+		s = raw
+	default:
+		return nil, jsonTypeError(raw, t.String())
+	}
+	bi, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: %q", t, s)
+	}
+	return bi, nil
+}
+
+func jsonTypeError(raw any, kind string) error {
+	return fmt.Errorf("invalid json value for %s: %v", kind, raw)
+}
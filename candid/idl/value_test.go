@@ -0,0 +1,149 @@
+package idl_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+func ExampleValue_Get() {
+	typ := idl.NewRecordType(map[string]idl.Type{
+		"name":  new(idl.TextType),
+		"tags":  idl.NewVectorType(new(idl.TextType)),
+		"owner": idl.NewOptionalType(idl.NewRecordType(map[string]idl.Type{"id": idl.Nat64Type()})),
+	})
+	v, _ := idl.ValueFromJSON(typ, []byte(`{"name":"agent","tags":["go","ic"],"owner":{"id":"1"}}`))
+	fmt.Println(v)
+	tag, _ := v.Get("tags", "1")
+	fmt.Println(tag)
+	id, _ := v.Get("owner", "id")
+	fmt.Println(id)
+	// Output:
+	// record { owner = opt record { id = 1 : nat64 }; name = "agent"; tags = vec { "go"; "ic" } }
+	// "ic"
+	// 1 : nat64
+}
+
+func TestValue_Annotate(t *testing.T) {
+	expected := idl.NewVariantType(map[string]idl.Type{
+		"Ok":  idl.NewRecordType(map[string]idl.Type{"balance": new(idl.NatType)}),
+		"Err": new(idl.TextType),
+	})
+	// The wire type has the hashes as labels, as if it was decoded.
+	wire := idl.NewVariantType(map[string]idl.Type{
+		idl.HashString("Ok"): idl.NewRecordType(map[string]idl.Type{
+			idl.HashString("balance"): new(idl.NatType),
+			idl.HashString("extra"):   new(idl.TextType),
+		}),
+		idl.HashString("Err"): new(idl.TextType),
+	})
+	v, err := idl.NewValue(wire, &idl.Variant{
+		Name: idl.HashString("Ok"),
+		Value: map[string]any{
+			idl.HashString("balance"): idl.NewNat(uint(5)),
+			idl.HashString("extra"):   "?",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Annotate(expected); err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != fmt.Sprintf(`variant { Ok = record { balance = 5 : nat; %s = "?" } }`, idl.HashString("extra")) {
+		t.Error(s)
+	}
+	// Annotated values still encode against their wire type.
+	if _, err := wire.EncodeValue(v.Any()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValue_JSON(t *testing.T) {
+	typ := idl.NewRecordType(map[string]idl.Type{
+		"a": new(idl.IntType),
+		"b": idl.NewVectorType(idl.Nat8Type()),
+		"c": new(idl.PrincipalType),
+		"d": idl.NewVariantType(map[string]idl.Type{"x": new(idl.NullType), "y": idl.Int8Type()}),
+		"e": idl.NewOptionalType(new(idl.BoolType)),
+		"f": idl.Float64Type(),
+	})
+	for _, test := range []struct {
+		in, out string
+	}{
+		{
+			in:  `{"a":"-123456789012345678901234567890","b":"00ff","c":"aaaaa-aa","d":{"y":-1},"f":1.5}`,
+			out: `{"a":"-123456789012345678901234567890","b":"00ff","c":"aaaaa-aa","d":{"y":-1},"e":null,"f":1.5}`,
+		},
+		{
+			in:  `{"a":1,"b":[1,2],"c":"aaaaa-aa","d":"x","e":true,"f":0}`,
+			out: `{"a":"1","b":"0102","c":"aaaaa-aa","d":{"x":null},"e":true,"f":0}`,
+		},
+	} {
+		v := idl.Value{Type: typ}
+		if err := json.Unmarshal([]byte(test.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != test.out {
+			t.Errorf("expected %s, got %s", test.out, raw)
+		}
+	}
+
+	for _, in := range []string{
+		`{"a":"1","b":"","c":"aaaaa-aa","d":{"y":128}}`,  // int8 out of range.
+		`{"a":"1","b":"","c":"aaaaa-aa","d":{"z":null}}`, // Unknown variant.
+		`{"b":"","c":"aaaaa-aa","d":"x"}`,                // Missing field.
+		`{"a":"1","b":"","c":"aaaaa-aa","d":"x","g":1}`,  // Unknown field.
+	} {
+		if _, err := idl.ValueFromJSON(typ, []byte(in)); err == nil {
+			t.Errorf("expected error for %s", in)
+		}
+	}
+}
+
+func TestValue_JSON_null(t *testing.T) {
+	typ := idl.NewOptionalType(idl.NewOptionalType(new(idl.NatType)))
+	for _, test := range []struct {
+		in, out string
+	}{
+		{in: `null`, out: `null`},
+		{in: `[null]`, out: `opt null`},
+		{in: `["1"]`, out: `opt opt 1 : nat`},
+	} {
+		v, err := idl.ValueFromJSON(typ, []byte(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != test.in {
+			t.Errorf("expected %s, got %s", test.in, raw)
+		}
+		if s := v.String(); s != test.out {
+			t.Errorf("expected %s, got %s", test.out, s)
+		}
+	}
+
+	for _, test := range []struct {
+		typ idl.Type
+		in  string
+	}{
+		{typ: new(idl.NullType), in: `1`},
+		{typ: new(idl.ReservedType), in: `"x"`},
+		{typ: typ, in: `"1"`}, // Not wrapped.
+		{typ: typ, in: `[]`},
+		{typ: idl.NewOptionalType(new(idl.NullType)), in: `[1]`},
+	} {
+		if _, err := idl.ValueFromJSON(test.typ, []byte(test.in)); err == nil {
+			t.Errorf("expected error for %s", test.in)
+		}
+	}
+}
@@ -30,7 +30,7 @@ func NewVariantType(fields map[string]Type) *VariantType {
 		})
 	}
 	sort.Slice(variant.Fields, func(i, j int) bool {
-		return Hash(variant.Fields[i].Name).Cmp(Hash(variant.Fields[j].Name)) < 0
+		return FieldID(variant.Fields[i].Name).Cmp(FieldID(variant.Fields[j].Name)) < 0
 	})
 	return &variant
 }
@@ -52,7 +52,7 @@ func (variant VariantType) AddTypeDefinition(tdt *TypeDefinitionTable) error {
 	}
 	var vs []byte
 	for _, f := range variant.Fields {
-		id, err := leb128.EncodeUnsigned(Hash(f.Name))
+		id, err := leb128.EncodeUnsigned(FieldID(f.Name))
 		if err != nil {
 			return nil
 		}
@@ -96,6 +96,13 @@ func (variant VariantType) EncodeType(tdt *TypeDefinitionTable) ([]byte, error)
 }
 
 func (variant VariantType) EncodeValue(value any) ([]byte, error) {
+	if v, ok := value.(variantValue); ok {
+		name, value, ok := v.variant()
+		if !ok {
+			return nil, fmt.Errorf("invalid variant: no variant selected")
+		}
+		return variant.EncodeValue(Variant{Name: name, Value: value})
+	}
 	fs, ok := value.(Variant)
 	if !ok {
 		v, err := variant.structToVariant(value)
@@ -186,6 +193,9 @@ func (variant VariantType) UnmarshalGo(raw any, _v any) error {
 		}
 	}
 
+	if v, ok := _v.(variantSetter); ok {
+		return v.setVariant(variant, name, value)
+	}
 	if v, ok := _v.(*map[string]any); ok {
 		return variant.unmarshalMap(name, value, v)
 	}
@@ -8,6 +8,7 @@ import (
 
 var (
 	Prog             = op.Capture{Name: "Prog", Value: op.And{op.Optional{Value: op.And{OWs, Def, op.ZeroOrMore{Value: op.And{';', OWs, Def}}}}, op.Optional{Value: ';'}, OWs, op.Optional{Value: op.And{OWs, Actor, op.ZeroOrMore{Value: op.And{';', OWs, Actor}}}}, op.Optional{Value: ';'}, OWs}}
+	Args             = op.Capture{Name: "Args", Value: op.And{op.Optional{Value: op.And{OWs, Def, op.ZeroOrMore{Value: op.And{';', OWs, Def}}}}, op.Optional{Value: ';'}, OWs, TupType, OWs}}
 	Def              = op.Or{Type, Import}
 	Type             = op.Capture{Name: "Type", Value: op.And{"type", Sp, Id, Sp, '=', OWs, DataType}}
 	Import           = op.Capture{Name: "Import", Value: op.And{"import", Sp, op.Optional{Value: op.And{ImportService, Sp}}, Text}}
@@ -0,0 +1,88 @@
+package candid
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+// FromJSON encodes the JSON array of values into a candid message of the given
+// types. The mapping between candid and JSON is:
+//
+//   - null and reserved are null, bool is a boolean and text is a string.
+//   - nat8 to nat32, int8 to int32 and floats are numbers. nat, int, nat64 and
+//     int64 are strings, numbers are accepted too.
+//   - blob is a hex string (or base64, see WithBase64Blobs), other vectors are
+//     arrays.
+//   - principal and service are their textual representation.
+//   - opt is null or the value. If the value itself can be null, i.e. it is an opt,
+//     null or reserved, it is wrapped in an array: `opt opt nat` is null, [null]
+//     or ["1"].
+//   - record is an object keyed by the field labels, tuples can also be arrays.
+//   - variant is an object with a single key, the selected arm. Arms without a
+//     value can also be a string.
+//   - func is an object with a "principal" and a "method".
+func FromJSON(types []idl.Type, data []byte, opts ...JSONOption) ([]byte, error) {
+	e := jsonEncoding(opts)
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid json: expected an array of values: %w", err)
+	}
+	if len(raw) != len(types) {
+		return nil, fmt.Errorf("invalid number of values: expected %d, got %d", len(types), len(raw))
+	}
+	vs := make([]idl.Value, len(types))
+	for i := range types {
+		v, err := e.Unmarshal(types[i], raw[i])
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		vs[i] = v
+	}
+	return EncodeValues(vs)
+}
+
+// ToJSON decodes the candid message into a JSON array of values, see FromJSON for
+// the mapping. The labels of records and variants are taken from the given types,
+// additional values in the message are ignored.
+func ToJSON(types []idl.Type, raw []byte, opts ...JSONOption) ([]byte, error) {
+	e := jsonEncoding(opts)
+	vs, err := DecodeValues(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(vs) < len(types) {
+		return nil, fmt.Errorf("invalid number of values: expected %d, got %d", len(types), len(vs))
+	}
+	values := make([]json.RawMessage, len(types))
+	for i := range types {
+		if err := vs[i].Annotate(types[i]); err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		v, err := e.Marshal(vs[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return json.Marshal(values)
+}
+
+// JSONOption configures the JSON mapping of FromJSON and ToJSON.
+type JSONOption func(e *idl.JSONEncoding)
+
+// WithBase64Blobs encodes blobs as standard base64 strings instead of hex strings.
+func WithBase64Blobs() JSONOption {
+	return func(e *idl.JSONEncoding) {
+		e.Blob = idl.BlobBase64
+	}
+}
+
+func jsonEncoding(opts []JSONOption) idl.JSONEncoding {
+	var e idl.JSONEncoding
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
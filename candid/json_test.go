@@ -0,0 +1,57 @@
+package candid_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid"
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/candid/idl"
+)
+
+func ExampleFromJSON() {
+	desc, _ := did.ParseDID([]rune(`type Transfer = record { to : principal; amount : nat; memo : opt blob };`))
+	transfer, _ := desc.TypeOf(did.DataId("Transfer"))
+
+	raw, _ := candid.FromJSON([]idl.Type{transfer}, []byte(`[{"to":"aaaaa-aa","amount":"340282366920938463463374607431768211455","memo":"AP8="}]`), candid.WithBase64Blobs())
+	s, _ := candid.DecodeValueString(raw)
+	fmt.Println(s)
+	j, _ := candid.ToJSON([]idl.Type{transfer}, raw)
+	fmt.Println(string(j))
+	// Output:
+	// (record { 25979 = principal "aaaaa-aa"; 1213809850 = opt vec { 0 : nat8; 255 : nat8 }; 3573748184 = 340282366920938463463374607431768211455 : nat })
+	// [{"amount":"340282366920938463463374607431768211455","memo":"00ff","to":"aaaaa-aa"}]
+}
+
+func TestFromJSON(t *testing.T) {
+	types := []idl.Type{
+		idl.NewVariantType(map[string]idl.Type{"a": new(idl.NullType), "b": idl.Nat16Type()}),
+		idl.NewOptionalType(new(idl.TextType)),
+	}
+	for _, test := range []string{
+		`[{"b":1},null]`,
+		`[{"a":null},"ok"]`,
+	} {
+		raw, err := candid.FromJSON(types, []byte(test))
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := candid.ToJSON(types, raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(j) != test {
+			t.Errorf("expected %s, got %s", test, j)
+		}
+	}
+	for _, test := range []string{
+		`[{"b":1}]`,             // Missing value.
+		`{"b":1}`,               // Not an array.
+		`[{"b":65536},null]`,    // Out of range.
+		`[{"a":null,"b":1},""]`, // Multiple arms.
+	} {
+		if _, err := candid.FromJSON(types, []byte(test)); err == nil {
+			t.Errorf("expected error for %s", test)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package bls
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// batchScalarLen is the length of the random scalars of batch verification, a
+// forged batch is accepted with a probability of at most 2^-128.
+const batchScalarLen = 16
+
+// AggregatePublicKeys returns the sum of the public keys, see Signature.VerifyMulti.
+func AggregatePublicKeys(publicKeys ...*PublicKey) (*PublicKey, error) {
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("no public keys to aggregate")
+	}
+	var sum bls.G2Jac
+	for _, pk := range publicKeys {
+		sum.AddMixed((*bls.G2Affine)(pk))
+	}
+	var aggregate bls.G2Affine
+	aggregate.FromJacobian(&sum)
+	return (*PublicKey)(&aggregate), nil
+}
+
+// AggregateSignatures returns the sum of the signatures.
+func AggregateSignatures(signatures ...*Signature) (*Signature, error) {
+	if len(signatures) == 0 {
+		return nil, fmt.Errorf("no signatures to aggregate")
+	}
+	var sum bls.G1Jac
+	for _, sig := range signatures {
+		sum.AddMixed((*bls.G1Affine)(sig))
+	}
+	var aggregate bls.G1Affine
+	aggregate.FromJacobian(&sum)
+	return (*Signature)(&aggregate), nil
+}
+
+// CombinePublicKeyShares returns the threshold public key of the public key shares
+// of a t-of-n threshold key, see CombineSignatureShares.
+func CombinePublicKeyShares(indexes []uint64, shares []*PublicKey) (*PublicKey, error) {
+	if len(indexes) != len(shares) {
+		return nil, fmt.Errorf("%d indexes for %d public key shares", len(indexes), len(shares))
+	}
+	coefficients, err := lagrangeCoefficients(indexes)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]bls.G2Affine, len(shares))
+	for i, share := range shares {
+		points[i] = bls.G2Affine(*share)
+	}
+	var combined bls.G2Affine
+	if _, err := combined.MultiExp(points, coefficients, ecc.MultiExpConfig{}); err != nil {
+		return nil, err
+	}
+	return (*PublicKey)(&combined), nil
+}
+
+// CombineSignatureShares returns the threshold signature of the signature shares of
+// a t-of-n threshold key, the share at position i is signed by the key share with the
+// given index. The index of a share is zero based, like the indexes of the nodes of a
+// subnet, its key share is the secret polynomial evaluated at index + 1. The shares
+// are combined by Lagrange interpolation at zero, so any t shares result in the same
+// signature, but the shares themselves are not verified and less than t shares result
+// in an invalid signature. Verify the shares with the public key shares first, or
+// the combined signature with the threshold public key.
+func CombineSignatureShares(indexes []uint64, shares []*Signature) (*Signature, error) {
+	if len(indexes) != len(shares) {
+		return nil, fmt.Errorf("%d indexes for %d signature shares", len(indexes), len(shares))
+	}
+	coefficients, err := lagrangeCoefficients(indexes)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]bls.G1Affine, len(shares))
+	for i, share := range shares {
+		points[i] = bls.G1Affine(*share)
+	}
+	var combined bls.G1Affine
+	if _, err := combined.MultiExp(points, coefficients, ecc.MultiExpConfig{}); err != nil {
+		return nil, err
+	}
+	return (*Signature)(&combined), nil
+}
+
+// VerifyBatch verifies many signatures at once, the signature at index i has to be
+// a signature of the message at index i by the public key at index i. It checks a
+// random linear combination of the signatures, which needs a single pairing per
+// distinct public key instead of two pairings per signature. It only reports
+// whether all signatures are valid, verify the signatures one by one to find the
+// invalid ones.
+func VerifyBatch(signatures []*Signature, publicKeys []*PublicKey, messages [][]byte) bool {
+	if len(signatures) == 0 || len(signatures) != len(publicKeys) || len(signatures) != len(messages) {
+		return false
+	}
+	scalars, err := batchScalars(len(signatures))
+	if err != nil {
+		return false
+	}
+
+	// The hashes of the messages are grouped by public key, since certificates are
+	// mostly signed by a few subnets.
+	type group struct {
+		publicKey *PublicKey
+		hashes    []bls.G1Affine
+		scalars   []fr.Element
+	}
+	var (
+		groups  []*group
+		index   = make(map[[bls.SizeOfG2AffineCompressed]byte]*group)
+		sigs    = make([]bls.G1Affine, len(signatures))
+		g1s     []bls.G1Affine
+		g2s     []bls.G2Affine
+		sigSum  bls.G1Affine
+		hashSum bls.G1Affine
+	)
+	for i, msg := range messages {
+		h, err := bls.HashToG1(msg, []byte(dstG1))
+		if err != nil {
+			return false
+		}
+		key := (*bls.G2Affine)(publicKeys[i]).Bytes()
+		g, ok := index[key]
+		if !ok {
+			g = &group{publicKey: publicKeys[i]}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.hashes = append(g.hashes, h)
+		g.scalars = append(g.scalars, scalars[i])
+		sigs[i] = bls.G1Affine(*signatures[i])
+	}
+
+	if _, err := sigSum.MultiExp(sigs, scalars, ecc.MultiExpConfig{}); err != nil {
+		return false
+	}
+	g1s = append(g1s, sigSum)
+	g2s = append(g2s, g2)
+	for _, g := range groups {
+		if _, err := hashSum.MultiExp(g.hashes, g.scalars, ecc.MultiExpConfig{}); err != nil {
+			return false
+		}
+		g1s = append(g1s, hashSum)
+		g2s = append(g2s, bls.G2Affine(*g.publicKey))
+	}
+	valid, err := bls.PairingCheck(g1s, g2s)
+	if err != nil {
+		return false
+	}
+	return valid
+}
+
+// VerifyAggregate verifies an aggregate signature of distinct messages, the message
+// at index i is signed by the public key at index i. Aggregates of the same message
+// have to be verified with VerifyMulti.
+func (sig *Signature) VerifyAggregate(publicKeys []*PublicKey, messages [][]byte) bool {
+	if len(publicKeys) == 0 || len(publicKeys) != len(messages) {
+		return false
+	}
+	seen := make(map[string]struct{}, len(messages))
+	g1s := []bls.G1Affine{bls.G1Affine(*sig)}
+	g2s := []bls.G2Affine{g2}
+	for i, msg := range messages {
+		if _, ok := seen[string(msg)]; ok {
+			return false
+		}
+		seen[string(msg)] = struct{}{}
+		h, err := bls.HashToG1(msg, []byte(dstG1))
+		if err != nil {
+			return false
+		}
+		g1s = append(g1s, h)
+		g2s = append(g2s, bls.G2Affine(*publicKeys[i]))
+	}
+	valid, err := bls.PairingCheck(g1s, g2s)
+	if err != nil {
+		return false
+	}
+	return valid
+}
+
+// VerifyMulti verifies an aggregate signature of the same message by all public
+// keys. The public keys have to be trusted, e.g. by a proof of possession, since
+// the signature scheme is prone to rogue key attacks otherwise.
+func (sig *Signature) VerifyMulti(publicKeys []*PublicKey, msg []byte) bool {
+	pk, err := AggregatePublicKeys(publicKeys...)
+	if err != nil {
+		return false
+	}
+	return sig.Verify(pk, msg)
+}
+
+// batchScalars returns n random non-zero scalars.
+func batchScalars(n int) ([]fr.Element, error) {
+	raw := make([]byte, n*batchScalarLen)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	scalars := make([]fr.Element, n)
+	for i := range scalars {
+		scalars[i].SetBytes(raw[i*batchScalarLen : (i+1)*batchScalarLen])
+		if scalars[i].IsZero() {
+			scalars[i].SetOne()
+		}
+	}
+	return scalars, nil
+}
+
+// lagrangeCoefficients returns the Lagrange coefficients at zero of the shares with
+// the given indexes, at the x-coordinates index + 1.
+func lagrangeCoefficients(indexes []uint64) ([]fr.Element, error) {
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no shares to combine")
+	}
+	xs := make([]fr.Element, len(indexes))
+	seen := make(map[uint64]struct{}, len(indexes))
+	for i, index := range indexes {
+		if _, ok := seen[index]; ok {
+			return nil, fmt.Errorf("duplicate share index: %d", index)
+		}
+		seen[index] = struct{}{}
+		xs[i].SetUint64(index)
+		xs[i].Add(&xs[i], new(fr.Element).SetOne())
+	}
+	coefficients := make([]fr.Element, len(xs))
+	for i := range xs {
+		// λ_i = Π_{j != i} x_j / (x_j - x_i)
+		numerator, denominator := new(fr.Element).SetOne(), new(fr.Element).SetOne()
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			var diff fr.Element
+			diff.Sub(&xs[j], &xs[i])
+			numerator.Mul(numerator, &xs[j])
+			denominator.Mul(denominator, &diff)
+		}
+		coefficients[i].Div(numerator, denominator)
+	}
+	return coefficients, nil
+}
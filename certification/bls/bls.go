@@ -1,7 +1,10 @@
 package bls
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	bls "github.com/consensys/gnark-crypto/ecc/bls12-381"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	"math/big"
@@ -9,6 +12,10 @@ import (
 
 const dstG1 = "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_"
 
+// keyGenSalt is the initial salt of KeyGen, as defined by the BLS signature draft of
+// the IRTF (draft-irtf-cfrg-bls-signature-05).
+const keyGenSalt = "BLS-SIG-KEYGEN-SALT-"
+
 var g2, g2Gen bls.G2Affine
 
 func init() {
@@ -36,8 +43,42 @@ func PublicKeyFromHexString(s string) (*PublicKey, error) {
 	return PublicKeyFromBytes(b)
 }
 
+// Bytes returns the compressed encoding of the public key.
+func (pk *PublicKey) Bytes() []byte {
+	b := (*bls.G2Affine)(pk).Bytes()
+	return b[:]
+}
+
 type SecretKey fr.Element
 
+// NewSecretKeyFromSeed derives a SecretKey from a seed of at least 32 bytes with
+// KeyGen of the BLS signature draft of the IRTF. The same seed always results in
+// the same key, e.g. for reproducible test certificates.
+func NewSecretKeyFromSeed(seed []byte) (*SecretKey, error) {
+	if len(seed) < 32 {
+		return nil, fmt.Errorf("seed too short: %d bytes", len(seed))
+	}
+	const l = 48 // ceil((3 * ceil(log2(r))) / 16)
+	salt := []byte(keyGenSalt)
+	for {
+		h := sha256.Sum256(salt)
+		salt = h[:]
+		prk, err := hkdf.Extract(sha256.New, append(seed[:len(seed):len(seed)], 0), salt)
+		if err != nil {
+			return nil, err
+		}
+		okm, err := hkdf.Expand(sha256.New, prk, string([]byte{0, l}), l)
+		if err != nil {
+			return nil, err
+		}
+		var secretKey fr.Element
+		secretKey.SetBigInt(new(big.Int).SetBytes(okm))
+		if !secretKey.IsZero() {
+			return (*SecretKey)(&secretKey), nil
+		}
+	}
+}
+
 // NewSecretKeyByCSPRNG returns a new SecretKey generated by CSPRNG.
 func NewSecretKeyByCSPRNG() *SecretKey {
 	var secretKey fr.Element
@@ -47,6 +88,36 @@ func NewSecretKeyByCSPRNG() *SecretKey {
 	return (*SecretKey)(&secretKey)
 }
 
+// SecretKeyFromBytes returns a SecretKey from its 32 byte big-endian encoding.
+func SecretKeyFromBytes(b []byte) (*SecretKey, error) {
+	if len(b) != fr.Bytes {
+		return nil, fmt.Errorf("invalid secret key length: %d", len(b))
+	}
+	var secretKey fr.Element
+	if err := secretKey.SetBytesCanonical(b); err != nil {
+		return nil, err
+	}
+	if secretKey.IsZero() {
+		return nil, fmt.Errorf("invalid secret key: zero")
+	}
+	return (*SecretKey)(&secretKey), nil
+}
+
+// SecretKeyFromHexString returns a SecretKey from a hex string.
+func SecretKeyFromHexString(s string) (*SecretKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return SecretKeyFromBytes(b)
+}
+
+// Bytes returns the 32 byte big-endian encoding of the secret key.
+func (sk *SecretKey) Bytes() []byte {
+	b := (*fr.Element)(sk).Bytes()
+	return b[:]
+}
+
 func (sk *SecretKey) PublicKey() *PublicKey {
 	element := fr.Element(*sk)
 	var pk bls.G2Affine
@@ -86,6 +157,12 @@ func SignatureFromHexString(s string) (*Signature, error) {
 	return SignatureFromBytes(b)
 }
 
+// Bytes returns the compressed encoding of the signature.
+func (sig *Signature) Bytes() []byte {
+	b := (*bls.G1Affine)(sig).Bytes()
+	return b[:]
+}
+
 func (sig *Signature) Verify(pk *PublicKey, msg []byte) bool {
 	g1, err := bls.HashToG1(msg, []byte(dstG1))
 	if err != nil {
@@ -1,8 +1,11 @@
 package bls
 
 import (
+	"bytes"
 	"encoding/hex"
 	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 )
 
 func TestSecretKey(t *testing.T) {
@@ -64,3 +67,242 @@ func TestVerify_hex(t *testing.T) {
 		t.Error()
 	}
 }
+
+func TestAggregateSignatures(t *testing.T) {
+	var (
+		sks  []*SecretKey
+		pks  []*PublicKey
+		msgs [][]byte
+		same []*Signature
+		diff []*Signature
+	)
+	for i := range 3 {
+		sk := NewSecretKeyByCSPRNG()
+		sks = append(sks, sk)
+		pks = append(pks, sk.PublicKey())
+		msgs = append(msgs, []byte{byte(i)})
+		s, err := sk.Sign([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		same = append(same, s)
+		if s, err = sk.Sign(msgs[i]); err != nil {
+			t.Fatal(err)
+		}
+		diff = append(diff, s)
+	}
+
+	multi, err := AggregateSignatures(same...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !multi.VerifyMulti(pks, []byte("hello")) {
+		t.Error("valid multi-signature rejected")
+	}
+	if multi.VerifyMulti(pks[:2], []byte("hello")) {
+		t.Error("multi-signature accepted for a subset of the keys")
+	}
+
+	aggregate, err := AggregateSignatures(diff...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !aggregate.VerifyAggregate(pks, msgs) {
+		t.Error("valid aggregate signature rejected")
+	}
+	if aggregate.VerifyAggregate(pks, [][]byte{msgs[0], msgs[2], msgs[1]}) {
+		t.Error("aggregate signature accepted for swapped messages")
+	}
+	if multi.VerifyAggregate(pks, [][]byte{[]byte("hello"), []byte("hello"), []byte("hello")}) {
+		t.Error("aggregate signature accepted for duplicate messages")
+	}
+
+	if _, err := AggregateSignatures(); err == nil {
+		t.Error("expected an error for no signatures")
+	}
+	if _, err := AggregatePublicKeys(); err == nil {
+		t.Error("expected an error for no public keys")
+	}
+}
+
+func TestCombineSignatureShares(t *testing.T) {
+	// The key shares of a 3-of-5 threshold key are the evaluations of a random
+	// polynomial of degree 2 at index + 1, the threshold key is its evaluation at 0.
+	coefficients := make([]fr.Element, 3)
+	for i := range coefficients {
+		if _, err := coefficients[i].SetRandom(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	evaluate := func(x uint64) *SecretKey {
+		var xe, y fr.Element
+		xe.SetUint64(x)
+		for i := len(coefficients) - 1; i >= 0; i-- {
+			y.Mul(&y, &xe)
+			y.Add(&y, &coefficients[i])
+		}
+		return (*SecretKey)(&y)
+	}
+	msg := []byte("hello")
+	sk := evaluate(0)
+	expected, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		shares    []*Signature
+		pkShares  []*PublicKey
+		allShares []uint64
+	)
+	for index := range uint64(5) {
+		share := evaluate(index + 1)
+		sig, err := share.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, sig)
+		pkShares = append(pkShares, share.PublicKey())
+		allShares = append(allShares, index)
+	}
+
+	for _, indexes := range [][]uint64{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var sigs []*Signature
+		var pks []*PublicKey
+		for _, index := range indexes {
+			sigs = append(sigs, shares[index])
+			pks = append(pks, pkShares[index])
+		}
+		combined, err := CombineSignatureShares(indexes, sigs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(combined.Bytes(), expected.Bytes()) {
+			t.Errorf("%v: unexpected signature", indexes)
+		}
+		pk, err := CombinePublicKeyShares(indexes, pks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pk.Bytes(), sk.PublicKey().Bytes()) {
+			t.Errorf("%v: unexpected public key", indexes)
+		}
+		if !combined.Verify(pk, msg) {
+			t.Errorf("%v: valid threshold signature rejected", indexes)
+		}
+	}
+
+	combined, err := CombineSignatureShares(allShares[:2], shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if combined.Verify(sk.PublicKey(), msg) {
+		t.Error("threshold signature of less than t shares accepted")
+	}
+	if _, err := CombineSignatureShares([]uint64{0, 0, 1}, shares[:3]); err == nil {
+		t.Error("expected an error for duplicate indexes")
+	}
+	if _, err := CombineSignatureShares(allShares[:2], shares[:3]); err == nil {
+		t.Error("expected an error for missing indexes")
+	}
+	if _, err := CombinePublicKeyShares(nil, nil); err == nil {
+		t.Error("expected an error for no shares")
+	}
+}
+
+func TestSecretKeyFromBytes(t *testing.T) {
+	sk := NewSecretKeyByCSPRNG()
+	restored, err := SecretKeyFromHexString(hex.EncodeToString(sk.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := restored.Sign([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Verify(sk.PublicKey(), []byte("hello")) {
+		t.Error("restored secret key signs for another public key")
+	}
+
+	pk, err := PublicKeyFromBytes(sk.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = SignatureFromBytes(s.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Verify(pk, []byte("hello")) {
+		t.Error("encoded signature rejected")
+	}
+
+	for _, raw := range [][]byte{
+		make([]byte, 32),
+		make([]byte, 31),
+		bytes.Repeat([]byte{0xff}, 32),
+	} {
+		if _, err := SecretKeyFromBytes(raw); err == nil {
+			t.Errorf("expected an error for %x", raw)
+		}
+	}
+}
+
+func TestNewSecretKeyFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x01}, 32)
+	sk1, err := NewSecretKeyFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk2, err := NewSecretKeyFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sk1.Bytes(), sk2.Bytes()) {
+		t.Error("secret key is not deterministic")
+	}
+	sk3, _ := NewSecretKeyFromSeed(bytes.Repeat([]byte{0x02}, 32))
+	if bytes.Equal(sk1.Bytes(), sk3.Bytes()) {
+		t.Error("different seeds result in the same secret key")
+	}
+	if _, err := NewSecretKeyFromSeed(make([]byte, 16)); err == nil {
+		t.Error("expected an error for a short seed")
+	}
+}
+
+func TestVerifyBatch(t *testing.T) {
+	var (
+		sigs []*Signature
+		pks  []*PublicKey
+		msgs [][]byte
+	)
+	keys := []*SecretKey{NewSecretKeyByCSPRNG(), NewSecretKeyByCSPRNG()}
+	for i := range 10 {
+		sk := keys[i%len(keys)]
+		msg := []byte{byte(i)}
+		s, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs = append(sigs, s)
+		pks = append(pks, sk.PublicKey())
+		msgs = append(msgs, msg)
+	}
+	if !VerifyBatch(sigs, pks, msgs) {
+		t.Error("valid batch rejected")
+	}
+
+	// Swapping two signatures keeps the sum of the signatures.
+	swapped := append([]*Signature{}, sigs...)
+	swapped[0], swapped[2] = swapped[2], swapped[0]
+	if VerifyBatch(swapped, pks, msgs) {
+		t.Error("batch with swapped signatures accepted")
+	}
+	if VerifyBatch(sigs, pks, append(append([][]byte{}, msgs[:9]...), []byte("other"))) {
+		t.Error("batch with another message accepted")
+	}
+	if VerifyBatch(sigs, pks[:9], msgs) {
+		t.Error("batch with missing public key accepted")
+	}
+	if VerifyBatch(nil, nil, nil) {
+		t.Error("empty batch accepted")
+	}
+}
@@ -122,6 +122,68 @@ func VerifyCertificate(
 	return verifyCertificateSignature(certificate, key)
 }
 
+// VerifyCertificates is like VerifyCertificate for many certificates of the same
+// canister, but verifies the signatures of the certificates and their delegations
+// in one batch, see bls.VerifyBatch. If the batch is invalid, the certificates are
+// verified one by one to report the first invalid certificate.
+func VerifyCertificates(
+	certificates []Certificate,
+	canisterID principal.Principal,
+	rootPublicKey []byte,
+) error {
+	publicKey, err := PublicBLSKeyFromDER(rootPublicKey)
+	if err != nil {
+		return err
+	}
+	var (
+		signatures []*bls.Signature
+		publicKeys []*bls.PublicKey
+		messages   [][]byte
+		// Certificates often share the same delegation.
+		delegations = make(map[string]*bls.PublicKey)
+	)
+	add := func(certificate Certificate, publicKey *bls.PublicKey) error {
+		signature, message, err := certificateSignature(certificate)
+		if err != nil {
+			return err
+		}
+		signatures = append(signatures, signature)
+		publicKeys = append(publicKeys, publicKey)
+		messages = append(messages, message)
+		return nil
+	}
+	for i, certificate := range certificates {
+		key := publicKey
+		if delegation := certificate.Delegation; delegation != nil {
+			digest := delegation.Certificate.Tree.Digest()
+			id := string(delegation.SubnetId.Raw) + string(digest[:]) + string(delegation.Certificate.Signature)
+			k, ok := delegations[id]
+			if !ok {
+				if k, err = delegationPublicKey(delegation, canisterID); err != nil {
+					return fmt.Errorf("certificate %d: %w", i, err)
+				}
+				if err := add(delegation.Certificate, publicKey); err != nil {
+					return fmt.Errorf("certificate %d: %w", i, err)
+				}
+				delegations[id] = k
+			}
+			key = k
+		}
+		if err := add(certificate, key); err != nil {
+			return fmt.Errorf("certificate %d: %w", i, err)
+		}
+	}
+	if len(signatures) == 0 || bls.VerifyBatch(signatures, publicKeys, messages) {
+		return nil
+	}
+	for i, certificate := range certificates {
+		if err := VerifyCertificate(certificate, canisterID, rootPublicKey); err != nil {
+			return fmt.Errorf("certificate %d: %w", i, err)
+		}
+	}
+	return fmt.Errorf("signature verification failed")
+}
+
 func VerifyCertifiedData(
 	certificate Certificate,
 	canisterID principal.Principal,
@@ -157,31 +219,25 @@ func VerifySubnetCertificate(
 	return verifySubnetCertificate(certificate, subnetID, publicKey)
 }
 
-func verifyCertificateSignature(certificate Certificate, publicKey *bls.PublicKey) error {
+// certificateSignature returns the signature of the certificate and the message it
+// signs.
+func certificateSignature(certificate Certificate) (*bls.Signature, []byte, error) {
 	rootHash := certificate.Tree.Digest()
 	message := append(hashtree.DomainSeparator("ic-state-root"), rootHash[:]...)
 	signature, err := bls.SignatureFromBytes(certificate.Signature)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	if !signature.Verify(publicKey, message) {
-		return fmt.Errorf("signature verification failed")
-	}
-	return nil
+	return signature, message, nil
 }
 
-func verifyDelegationCertificate(
-	delegation *Delegation,
-	rootPublicKey *bls.PublicKey,
-	canisterID principal.Principal,
-) (*bls.PublicKey, error) {
+// delegationPublicKey returns the public key of the subnet of the delegation, after
+// checking that the canister is in the range of the subnet. The signature of the
+// delegation is not verified.
+func delegationPublicKey(delegation *Delegation, canisterID principal.Principal) (*bls.PublicKey, error) {
 	if delegation.Certificate.Delegation != nil {
 		return nil, fmt.Errorf("multiple delegations are not supported")
 	}
-	if err := verifyCertificateSignature(delegation.Certificate, rootPublicKey); err != nil {
-		return nil, err
-	}
-
 	canisterRanges, err := LookupCanisterRanges(delegation.Certificate.Tree, delegation.SubnetId)
 	if err != nil {
 		return nil, err
@@ -201,6 +257,31 @@ func verifyDelegationCertificate(
 	return PublicBLSKeyFromDER(rawPublicKey)
 }
 
+func verifyCertificateSignature(certificate Certificate, publicKey *bls.PublicKey) error {
+	signature, message, err := certificateSignature(certificate)
+	if err != nil {
+		return err
+	}
+	if !signature.Verify(publicKey, message) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+func verifyDelegationCertificate(
+	delegation *Delegation,
+	rootPublicKey *bls.PublicKey,
+	canisterID principal.Principal,
+) (*bls.PublicKey, error) {
+	if delegation.Certificate.Delegation != nil {
+		return nil, fmt.Errorf("multiple delegations are not supported")
+	}
+	if err := verifyCertificateSignature(delegation.Certificate, rootPublicKey); err != nil {
+		return nil, err
+	}
+	return delegationPublicKey(delegation, canisterID)
+}
+
 func verifySubnetCertificate(
 	certificate Certificate,
 	subnetID principal.Principal,
@@ -5,6 +5,7 @@ import (
 	"github.com/aviate-labs/agent-go/certification/hashtree"
 	"github.com/aviate-labs/agent-go/principal"
 	"github.com/fxamacker/cbor/v2"
+	"strings"
 	"testing"
 )
 
@@ -156,3 +157,30 @@ func TestLookupCanisterRangesSharded(t *testing.T) {
 		t.Fatalf("did not expect %s in range", out)
 	}
 }
+
+func TestVerifyCertificates(t *testing.T) {
+	var certificate Certificate
+	if err := cbor.Unmarshal(hexToBytes(SampleCert), &certificate); err != nil {
+		t.Fatal(err)
+	}
+	canisterID := principal.Principal{Raw: hexToBytes("00000000002000000101")}
+	if certificate.Delegation == nil {
+		t.Fatal("expected a delegation")
+	}
+
+	certificates := []Certificate{certificate, certificate, certificate}
+	if err := VerifyCertificates(certificates, canisterID, hexToBytes(RootKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := certificate
+	invalid.Tree = hashtree.NewHashTree(hashtree.Leaf("invalid"))
+	err := VerifyCertificates(append(certificates, invalid), canisterID, hexToBytes(RootKey))
+	if err == nil || !strings.HasPrefix(err.Error(), "certificate 3:") {
+		t.Errorf("expected an error for certificate 3, got %v", err)
+	}
+
+	if err := VerifyCertificates(certificates, principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai"), hexToBytes(RootKey)); err == nil {
+		t.Error("expected an error for a canister outside of the delegation")
+	}
+}
@@ -2,8 +2,12 @@ package ii
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"github.com/aviate-labs/agent-go/certification"
+	"github.com/aviate-labs/agent-go/certification/hashtree"
 	"github.com/aviate-labs/agent-go/principal"
+	"github.com/fxamacker/cbor/v2"
 )
 
 var (
@@ -59,3 +63,35 @@ func (s *CanisterSigPublicKey) Raw() []byte {
 	raw.Write(s.Seed)
 	return raw.Bytes()
 }
+
+// VerifyCanisterSig verifies the canister signature of the message, the certified
+// hash tree of the canister that contains sha256(seed) and sha256(message) below
+// "sig".
+func VerifyCanisterSig(publicKey *CanisterSigPublicKey, message, signature, rootPublicKey []byte) error {
+	var wrapper struct {
+		Certificate []byte            `cbor:"certificate"`
+		Tree        hashtree.HashTree `cbor:"tree"`
+	}
+	if err := cbor.Unmarshal(signature, &wrapper); err != nil {
+		return err
+	}
+	var certificate certification.Certificate
+	if err := cbor.Unmarshal(wrapper.Certificate, &certificate); err != nil {
+		return err
+	}
+	tree := wrapper.Tree.Digest()
+	if err := certification.VerifyCertifiedData(
+		certificate,
+		publicKey.CanisterID,
+		rootPublicKey,
+		tree[:],
+	); err != nil {
+		return err
+	}
+	seed := sha256.Sum256(publicKey.Seed)
+	msg := sha256.Sum256(message)
+	if _, err := wrapper.Tree.Lookup(hashtree.Label("sig"), seed[:], msg[:]); err != nil {
+		return err
+	}
+	return nil
+}
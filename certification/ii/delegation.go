@@ -2,15 +2,12 @@ package ii
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/aviate-labs/agent-go/certification"
-	"github.com/aviate-labs/agent-go/certification/hashtree"
 	"github.com/aviate-labs/agent-go/principal"
-	"github.com/fxamacker/cbor/v2"
 )
 
 type BEHexUint64 uint64
@@ -83,37 +80,11 @@ func (d DelegationChain) VerifyChallenge(
 		return fmt.Errorf("delegation expired")
 	}
 
-	sig := []byte(signedDelegation.Signature)
 	message, err := delegation.SignatureMessage()
 	if err != nil {
 		return err
 	}
-	var wrapper struct {
-		Certificate []byte            `cbor:"certificate"`
-		Tree        hashtree.HashTree `cbor:"tree"`
-	}
-	if err := cbor.Unmarshal(sig, &wrapper); err != nil {
-		return err
-	}
-	var certificate certification.Certificate
-	if err := cbor.Unmarshal(wrapper.Certificate, &certificate); err != nil {
-		return err
-	}
-	tree := wrapper.Tree.Digest()
-	if err := certification.VerifyCertifiedData(
-		certificate,
-		canisterSig.CanisterID,
-		rootPublicKey,
-		tree[:],
-	); err != nil {
-		return err
-	}
-	seed := sha256.Sum256(canisterSig.Seed)
-	msg := sha256.Sum256(message)
-	if _, err := wrapper.Tree.Lookup(hashtree.Label("sig"), seed[:], msg[:]); err != nil {
-		return err
-	}
-	return nil
+	return VerifyCanisterSig(canisterSig, message, []byte(signedDelegation.Signature), rootPublicKey)
 }
 
 type HexString string
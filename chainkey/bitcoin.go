@@ -0,0 +1,118 @@
+package chainkey
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// Human readable parts of the segwit addresses of the Bitcoin networks.
+const (
+	BitcoinMainnet = "bc"
+	BitcoinTestnet = "tb"
+	BitcoinRegtest = "bcrt"
+)
+
+// Checksum constants of bech32 (BIP-173) and bech32m (BIP-350).
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// BitcoinP2TRAddress returns the pay-to-taproot address (BIP-86) of the public key,
+// without a script path.
+func (k Secp256k1PublicKey) BitcoinP2TRAddress(network string) (string, error) {
+	outputKey, err := k.TaprootOutputKey(nil)
+	if err != nil {
+		return "", err
+	}
+	return segwitAddress(network, 1, outputKey)
+}
+
+// BitcoinP2WPKHAddress returns the pay-to-witness-public-key-hash address (BIP-173)
+// of the public key.
+func (k Secp256k1PublicKey) BitcoinP2WPKHAddress(network string) (string, error) {
+	sum := sha256.Sum256(k.Bytes())
+	h := ripemd160.New()
+	h.Write(sum[:])
+	return segwitAddress(network, 0, h.Sum(nil))
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (b>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32Encode encodes the 5-bit data with the given human readable part and
+// checksum constant.
+func bech32Encode(hrp string, data []byte, checksumConst uint32) string {
+	values := make([]byte, 0, 2*len(hrp)+1+len(data)+6)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	values = append(values, data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ checksumConst
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range data {
+		b.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		b.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+// convertBits regroups 8-bit bytes into 5-bit groups, padding the last group.
+func convertBits(data []byte) []byte {
+	var (
+		acc  uint32
+		bits uint
+		out  = make([]byte, 0, (len(data)*8+4)/5)
+	)
+	for _, v := range data {
+		acc = acc<<8 | uint32(v)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out = append(out, byte(acc>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		out = append(out, byte(acc<<(5-bits))&31)
+	}
+	return out
+}
+
+// segwitAddress encodes a witness program of the given version, with bech32 for
+// version 0 and bech32m for later versions (BIP-350).
+func segwitAddress(network string, version byte, program []byte) (string, error) {
+	if network == "" || strings.ToLower(network) != network {
+		return "", fmt.Errorf("invalid network: %q", network)
+	}
+	checksumConst := uint32(bech32mConst)
+	if version == 0 {
+		checksumConst = bech32Const
+	}
+	return bech32Encode(network, append([]byte{version}, convertBits(program)...), checksumConst), nil
+}
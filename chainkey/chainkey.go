@@ -0,0 +1,58 @@
+// Package chainkey derives the public keys of the threshold (chain-key) signatures
+// of the Internet Computer offline, as returned by the ecdsa_public_key and
+// schnorr_public_key methods of the management canister, and verifies the
+// signatures of sign_with_ecdsa and sign_with_schnorr.
+//
+// The keys of a canister are derived from the master key of the subnet along the
+// derivation path of the caller: the principal of the canister followed by the
+// derivation path of the request. The master key is derived with a zero chain code.
+// Instead of the master key, the key of the canister can be used, as returned by
+// ecdsa_public_key for an empty derivation path, together with its chain code.
+//
+//	canisterKey, _ := chainkey.NewSecp256k1PublicKey(resp.PublicKey, resp.ChainCode)
+//	userKey := canisterKey.Derive(chainkey.DerivationPath{user.Raw})
+//	address, _ := userKey.BitcoinP2WPKHAddress(chainkey.BitcoinMainnet)
+package chainkey
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// chainCodeLen is the length of a chain code.
+const chainCodeLen = 32
+
+// DerivationPath is a derivation path of chain-key signatures, a list of arbitrary
+// byte strings.
+type DerivationPath [][]byte
+
+// NewDerivationPath returns the full derivation path of a key of the given caller,
+// e.g. a canister, as used to derive the key from the master key.
+func NewDerivationPath(caller principal.Principal, path ...[]byte) DerivationPath {
+	return append(DerivationPath{caller.Raw}, path...)
+}
+
+// ckd returns the HMAC-SHA512 of the public key and the index, keyed by the chain
+// code, the first step of the derivation of a child key.
+func ckd(chainCode, publicKey, index []byte) []byte {
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(publicKey)
+	mac.Write(index)
+	return mac.Sum(nil)
+}
+
+// chainCodeOf returns the chain code, or the zero chain code of a master key.
+func chainCodeOf(raw []byte) ([chainCodeLen]byte, bool) {
+	var chainCode [chainCodeLen]byte
+	switch len(raw) {
+	case 0:
+		return chainCode, true
+	case chainCodeLen:
+		copy(chainCode[:], raw)
+		return chainCode, true
+	default:
+		return chainCode, false
+	}
+}
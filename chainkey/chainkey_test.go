@@ -0,0 +1,214 @@
+package chainkey_test
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/chainkey"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// The derivation of secp256k1 keys with 4 byte indexes is the public derivation of
+// BIP-32, it is tested with the non-hardened steps of the test vectors of BIP-32. The
+// vector of the Ed25519 derivation is computed with a reference implementation of
+// ic-ed25519 (HKDF-SHA512 of the key and the index, with the chain code as salt), from
+// the master key of the seed 0x00..1f.
+var (
+	testCaller = principal.Principal{Raw: []byte{0, 0, 0, 0, 0, 0, 0, 1, 1, 1}}
+	testPath   = chainkey.NewDerivationPath(testCaller, []byte("user"), []byte{0, 1})
+)
+
+func ExampleSecp256k1PublicKey_BitcoinP2WPKHAddress() {
+	// The generator of secp256k1, the public key of the private key 1.
+	key, _ := chainkey.NewSecp256k1PublicKey(mustDecodeHex("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"), nil)
+	address, _ := key.BitcoinP2WPKHAddress(chainkey.BitcoinMainnet)
+	fmt.Println(address)
+	fmt.Println(key.EthereumAddress())
+	// Output:
+	// bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4
+	// 0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf
+}
+
+func ExampleSecp256k1PublicKey_BitcoinP2TRAddress() {
+	// The internal key of the first receiving address of BIP-86.
+	key, _ := chainkey.NewSecp256k1PublicKey(mustDecodeHex("02cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115"), nil)
+	outputKey, _ := key.TaprootOutputKey(nil)
+	fmt.Printf("%x\n", outputKey)
+	address, _ := key.BitcoinP2TRAddress(chainkey.BitcoinMainnet)
+	fmt.Println(address)
+	// Output:
+	// a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c
+	// bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr
+}
+
+func TestEd25519PublicKey(t *testing.T) {
+	privateKey := ed25519.NewKeyFromSeed(mustDecodeHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"))
+	master, err := chainkey.NewEd25519PublicKey(privateKey.Public().(ed25519.PublicKey), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !master.Verify([]byte("hello"), ed25519.Sign(privateKey, []byte("hello"))) {
+		t.Error("valid signature rejected")
+	}
+
+	key := master.Derive(testPath)
+	if got, want := hex.EncodeToString(key.Bytes()), "b3ee96d3c56b8b8473bf78341bc6ec83874c92f909f425f2bfcb71ba253cb315"; got != want {
+		t.Errorf("expected public key %s, got %s", want, got)
+	}
+	if got, want := hex.EncodeToString(key.ChainCode()), "cd15c91121b3484a978a4d18f1d8a036ee567ae675362ab7b1e2d857a1e520e0"; got != want {
+		t.Errorf("expected chain code %s, got %s", want, got)
+	}
+
+	// Deriving from an intermediate key gives the same key.
+	canister := master.Derive(chainkey.DerivationPath{testCaller.Raw})
+	intermediate, err := chainkey.NewEd25519PublicKey(canister.Bytes(), canister.ChainCode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := intermediate.Derive(testPath[1:]); hex.EncodeToString(got.Bytes()) != hex.EncodeToString(key.Bytes()) {
+		t.Error("derivation from the canister key differs")
+	}
+
+	if _, err := chainkey.NewEd25519PublicKey(key.Bytes(), make([]byte, 16)); err == nil {
+		t.Error("expected an error for an invalid chain code")
+	}
+}
+
+func TestSecp256k1PublicKey(t *testing.T) {
+	master, err := chainkey.NewSecp256k1PublicKey(mustDecodeHex("03f973a0b87062c389d125d8199e803b832b6ac6bf7867a4f6cd87506060fc4c58"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := master.Derive(testPath)
+	uncompressed, err := chainkey.NewSecp256k1PublicKey(key.UncompressedBytes(), key.ChainCode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(uncompressed.Bytes()) != hex.EncodeToString(key.Bytes()) {
+		t.Error("uncompressed encoding does not round trip")
+	}
+
+	for _, test := range []struct {
+		name    string
+		key     []byte
+		chain   []byte
+		wantErr bool
+	}{
+		{name: "compressed", key: key.Bytes()},
+		{name: "x-only", key: key.XOnlyBytes(), wantErr: true},
+		{name: "not on the curve", key: append([]byte{0x02}, make([]byte, 32)...), wantErr: true},
+		{name: "chain code", key: key.Bytes(), chain: make([]byte, 31), wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := chainkey.NewSecp256k1PublicKey(test.key, test.chain); (err != nil) != test.wantErr {
+				t.Errorf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSecp256k1PublicKey_Derive(t *testing.T) {
+	// The non-hardened steps of the test vectors 1 and 2 of BIP-32, the keys and chain
+	// codes of the extended public keys.
+	for _, test := range []struct {
+		name      string
+		key       string
+		chainCode string
+		path      []uint32
+		want      string
+		wantChain string
+	}{
+		{
+			name:      "vector 1: m/0H/1",
+			key:       "035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56",
+			chainCode: "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141",
+			path:      []uint32{1},
+			want:      "03501e454bf00751f24b1b489aa925215d66af2234e3891c3b21a52bedb3cd711c",
+			wantChain: "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19",
+		},
+		{
+			name:      "vector 1: m/0H/1/2H/2/1000000000",
+			key:       "0357bfe1e341d01c69fe5654309956cbea516822fba8a601743a012a7896ee8dc2",
+			chainCode: "04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f",
+			path:      []uint32{2, 1000000000},
+			want:      "022a471424da5e657499d1ff51cb43c47481a03b1e77f951fe64cec9f5a48f7011",
+			wantChain: "c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e",
+		},
+		{
+			name:      "vector 2: m/0",
+			key:       "03cbcaa9c98c877a26977d00825c956a238e8dddfbd322cce4f74b0b5bd6ace4a7",
+			chainCode: "60499f801b896d83179a4374aeb7822aaeaceaa0db1f85ee3e904c4defbd9689",
+			path:      []uint32{0},
+			want:      "02fc9e5af0ac8d9b3cecfe2a888e2117ba3d089d8585886c9c826b6b22a98d12ea",
+			wantChain: "f0909affaa7ee7abe5dd4e100598d4dc53cd709d5a5c2cac40e7412f232f7c9c",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			parent, err := chainkey.NewSecp256k1PublicKey(mustDecodeHex(test.key), mustDecodeHex(test.chainCode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var path chainkey.DerivationPath
+			for _, index := range test.path {
+				path = append(path, binary.BigEndian.AppendUint32(nil, index))
+			}
+			key := parent.Derive(path)
+			if got := hex.EncodeToString(key.Bytes()); got != test.want {
+				t.Errorf("expected public key %s, got %s", test.want, got)
+			}
+			if got := hex.EncodeToString(key.ChainCode()); got != test.wantChain {
+				t.Errorf("expected chain code %s, got %s", test.wantChain, got)
+			}
+		})
+	}
+}
+
+func TestSecp256k1PublicKey_Verify(t *testing.T) {
+	master, _ := chainkey.NewSecp256k1PublicKey(mustDecodeHex("03f973a0b87062c389d125d8199e803b832b6ac6bf7867a4f6cd87506060fc4c58"), nil)
+	other := master.Derive(testPath)
+	message := []byte("hello")
+	hash := sha256.Sum256(message)
+
+	ecdsaSig := mustDecodeHex("76d2fdf1302d1fa9556f4df94ec84cefba6d482e54f47c6c2a238c1baa560f0eca07bbac1ef6e6b6eab52821eab94b951d270585c4fccfd50517887491c77f02")
+	if !master.VerifyECDSA(hash[:], ecdsaSig) {
+		t.Error("valid ECDSA signature rejected")
+	}
+	if other.VerifyECDSA(hash[:], ecdsaSig) {
+		t.Error("ECDSA signature of another key accepted")
+	}
+
+	schnorrSig := mustDecodeHex("30cfa0c0a165a279b67eeade5b23948ce4f5bc7bc3f2ad4b99e6ee7ede20c9f1676e9a468d62f8206f05342ca8ffb0d99cff705895f40ae09f9b443b37ebae07")
+	if !master.VerifyBIP340(message, schnorrSig) {
+		t.Error("valid BIP-340 signature rejected")
+	}
+	if master.VerifyBIP340([]byte("world"), schnorrSig) {
+		t.Error("BIP-340 signature of another message accepted")
+	}
+
+	taprootSig := mustDecodeHex("d02520258ef780022be147ea8176121a82f7473119b2f273a433aebfe27023b31885371abf576bb389c58eb939deeba068a2f5dcfa4c625a6ca30112207a4186")
+	if !master.VerifyBIP341(message, taprootSig, nil) {
+		t.Error("valid BIP-341 signature rejected")
+	}
+	if master.VerifyBIP340(message, taprootSig) {
+		t.Error("BIP-341 signature accepted by the internal key")
+	}
+
+	// The first test vector of BIP-340.
+	key, _ := chainkey.NewSecp256k1PublicKey(mustDecodeHex("02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"), nil)
+	if !key.VerifyBIP340(make([]byte, 32), mustDecodeHex("e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0")) {
+		t.Error("BIP-340 test vector rejected")
+	}
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
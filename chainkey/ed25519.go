@@ -0,0 +1,88 @@
+package chainkey
+
+import (
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/sha512"
+	"fmt"
+	"slices"
+
+	"filippo.io/edwards25519"
+)
+
+// Ed25519PublicKey is an Ed25519 public key of chain-key Schnorr signatures, with
+// the chain code to derive its children.
+type Ed25519PublicKey struct {
+	point     *edwards25519.Point
+	chainCode [chainCodeLen]byte
+}
+
+// NewEd25519PublicKey creates a public key from its encoding, e.g. the public key
+// returned by schnorr_public_key, and its chain code. The chain code of a master key
+// is empty.
+func NewEd25519PublicKey(publicKey, chainCode []byte) (*Ed25519PublicKey, error) {
+	point, err := new(edwards25519.Point).SetBytes(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	cc, ok := chainCodeOf(chainCode)
+	if !ok {
+		return nil, fmt.Errorf("invalid chain code length: %d", len(chainCode))
+	}
+	return &Ed25519PublicKey{point: point, chainCode: cc}, nil
+}
+
+// Bytes returns the encoding of the public key, as returned by schnorr_public_key.
+func (k Ed25519PublicKey) Bytes() []byte {
+	return k.point.Bytes()
+}
+
+// ChainCode returns the chain code of the public key.
+func (k Ed25519PublicKey) ChainCode() []byte {
+	return k.chainCode[:]
+}
+
+// Derive returns the public key derived along the given derivation path, like the
+// IC derives the keys of canisters.
+func (k Ed25519PublicKey) Derive(path DerivationPath) *Ed25519PublicKey {
+	key := &k
+	for _, index := range path {
+		key = key.child(index)
+	}
+	return key
+}
+
+// PublicKey returns the public key as a standard library key.
+func (k Ed25519PublicKey) PublicKey() ed25519.PublicKey {
+	return k.Bytes()
+}
+
+// Verify verifies an Ed25519 signature of the message, as returned by
+// sign_with_schnorr with the ed25519 algorithm.
+func (k Ed25519PublicKey) Verify(message, sig []byte) bool {
+	return ed25519.Verify(k.PublicKey(), message, sig)
+}
+
+// child returns the child key with the given index. The offset and the chain code
+// are HKDF-SHA512 of the public key and the index, with the chain code as salt. The
+// HMAC of ckd is the extract step of HKDF, so only the expand step is left.
+func (k Ed25519PublicKey) child(index []byte) *Ed25519PublicKey {
+	prk := ckd(k.chainCode[:], k.Bytes(), index)
+	okm, err := hkdf.Expand(sha512.New, prk, "Ed25519", 96)
+	if err != nil {
+		// 96 bytes is a valid length for HKDF-SHA512.
+		panic(err)
+	}
+	// The offset is a big-endian number, edwards25519 expects little-endian.
+	wide := slices.Clone(okm[:64])
+	slices.Reverse(wide)
+	offset, err := edwards25519.NewScalar().SetUniformBytes(wide)
+	if err != nil {
+		panic(err)
+	}
+	next := Ed25519PublicKey{
+		point: new(edwards25519.Point).Add(k.point, new(edwards25519.Point).ScalarBaseMult(offset)),
+	}
+	copy(next.chainCode[:], okm[64:])
+	return &next
+}
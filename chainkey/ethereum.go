@@ -0,0 +1,30 @@
+package chainkey
+
+import (
+	"encoding/hex"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// EthereumAddress returns the Ethereum address of the public key, with the mixed
+// case checksum of EIP-55.
+func (k Secp256k1PublicKey) EthereumAddress() string {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(k.UncompressedBytes()[1:])
+	address := []byte(hex.EncodeToString(h.Sum(nil)[12:]))
+
+	h = sha3.NewLegacyKeccak256()
+	h.Write(address)
+	checksum := h.Sum(nil)
+	for i, c := range address {
+		// Letters are upper case if the corresponding nibble of the hash is at least 8.
+		nibble := checksum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = checksum[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			address[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(address)
+}
@@ -0,0 +1,256 @@
+package chainkey
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/secp256k1"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fp"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fr"
+)
+
+// Sizes of secp256k1 keys and signatures.
+const (
+	coordLen        = fp.Bytes
+	compressedLen   = 1 + coordLen
+	uncompressedLen = 1 + 2*coordLen
+	signatureLen    = 2 * fr.Bytes
+)
+
+var (
+	// secp256k1P is the order of the base field.
+	secp256k1P = fp.Modulus()
+	// secp256k1N is the order of the curve.
+	secp256k1N = fr.Modulus()
+	// secp256k1SqrtExp is (p+1)/4, y = c^((p+1)/4) is a square root of c = y^2.
+	secp256k1SqrtExp = new(big.Int).Rsh(new(big.Int).Add(fp.Modulus(), big.NewInt(1)), 2)
+)
+
+// liftX returns the point with the given x coordinate and a y coordinate of the
+// given parity.
+func liftX(x *big.Int, odd bool) (secp256k1.G1Affine, bool) {
+	var p secp256k1.G1Affine
+	if x.Cmp(secp256k1P) >= 0 {
+		return p, false
+	}
+	c := new(big.Int).Exp(x, big.NewInt(3), secp256k1P)
+	c.Add(c, big.NewInt(7)).Mod(c, secp256k1P)
+	y := new(big.Int).Exp(c, secp256k1SqrtExp, secp256k1P)
+	if new(big.Int).Exp(y, big.NewInt(2), secp256k1P).Cmp(c) != 0 {
+		return p, false
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(secp256k1P, y)
+	}
+	p.X.SetBigInt(x)
+	p.Y.SetBigInt(y)
+	return p, true
+}
+
+// parseSecp256k1Point parses a SEC1 encoded point, either compressed or
+// uncompressed.
+func parseSecp256k1Point(raw []byte) (secp256k1.G1Affine, error) {
+	switch {
+	case len(raw) == compressedLen && (raw[0] == 0x02 || raw[0] == 0x03):
+		p, ok := liftX(new(big.Int).SetBytes(raw[1:]), raw[0] == 0x03)
+		if !ok {
+			return p, fmt.Errorf("invalid public key: not on the curve")
+		}
+		return p, nil
+	case len(raw) == uncompressedLen && raw[0] == 0x04:
+		var p secp256k1.G1Affine
+		if _, err := p.SetBytes(raw[1:]); err != nil {
+			return p, err
+		}
+		return p, nil
+	default:
+		return secp256k1.G1Affine{}, fmt.Errorf("invalid public key: expected a SEC1 encoded point")
+	}
+}
+
+// taggedHash returns the tagged hash of BIP-340, sha256(sha256(tag) || sha256(tag) || data).
+func taggedHash(tag string, data ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// verifyBIP340 verifies a BIP-340 signature of the message by the x-only public key.
+func verifyBIP340(publicKey, message, sig []byte) bool {
+	if len(publicKey) != coordLen || len(sig) != signatureLen {
+		return false
+	}
+	p, ok := liftX(new(big.Int).SetBytes(publicKey), false)
+	if !ok {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:coordLen])
+	s := new(big.Int).SetBytes(sig[coordLen:])
+	if r.Cmp(secp256k1P) >= 0 || s.Cmp(secp256k1N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", sig[:coordLen], publicKey, message))
+	e.Mod(e, secp256k1N)
+	var sG, eP, R secp256k1.G1Affine
+	sG.ScalarMultiplicationBase(s)
+	eP.ScalarMultiplication(&p, e)
+	R.Sub(&sG, &eP)
+	if R.IsInfinity() || R.Y.Bytes()[coordLen-1]&1 == 1 {
+		return false
+	}
+	x := R.X.Bytes()
+	return new(big.Int).SetBytes(x[:]).Cmp(r) == 0
+}
+
+// Secp256k1PublicKey is a secp256k1 public key of chain-key ECDSA or BIP-340
+// Schnorr signatures, with the chain code to derive its children.
+type Secp256k1PublicKey struct {
+	point     secp256k1.G1Affine
+	chainCode [chainCodeLen]byte
+}
+
+// NewSecp256k1PublicKey creates a public key from a SEC1 encoded point, e.g. the
+// public key returned by ecdsa_public_key, and its chain code. The chain code of a
+// master key is empty.
+func NewSecp256k1PublicKey(publicKey, chainCode []byte) (*Secp256k1PublicKey, error) {
+	point, err := parseSecp256k1Point(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	cc, ok := chainCodeOf(chainCode)
+	if !ok {
+		return nil, fmt.Errorf("invalid chain code length: %d", len(chainCode))
+	}
+	return &Secp256k1PublicKey{point: point, chainCode: cc}, nil
+}
+
+// Bytes returns the SEC1 compressed encoding of the public key, as returned by
+// ecdsa_public_key.
+func (k Secp256k1PublicKey) Bytes() []byte {
+	x, y := k.point.X.Bytes(), k.point.Y.Bytes()
+	return append([]byte{0x02 | y[coordLen-1]&1}, x[:]...)
+}
+
+// ChainCode returns the chain code of the public key.
+func (k Secp256k1PublicKey) ChainCode() []byte {
+	return k.chainCode[:]
+}
+
+// Derive returns the public key derived along the given derivation path, like the
+// IC derives the keys of canisters.
+func (k Secp256k1PublicKey) Derive(path DerivationPath) *Secp256k1PublicKey {
+	key := &k
+	for _, index := range path {
+		key = key.child(index)
+	}
+	return key
+}
+
+// TaprootOutputKey returns the x-only output key of BIP-341 of the public key as
+// internal key, tweaked with the given merkle root of the script tree. The merkle
+// root is empty for outputs without a script path, as defined by BIP-86.
+func (k Secp256k1PublicKey) TaprootOutputKey(merkleRoot []byte) ([]byte, error) {
+	if len(merkleRoot) != 0 && len(merkleRoot) != sha256.Size {
+		return nil, fmt.Errorf("invalid merkle root length: %d", len(merkleRoot))
+	}
+	internalKey := k.XOnlyBytes()
+	p, _ := liftX(new(big.Int).SetBytes(internalKey), false)
+	t := new(big.Int).SetBytes(taggedHash("TapTweak", internalKey, merkleRoot))
+	if t.Cmp(secp256k1N) >= 0 {
+		return nil, fmt.Errorf("invalid tweak")
+	}
+	var tG, q secp256k1.G1Affine
+	tG.ScalarMultiplicationBase(t)
+	q.Add(&p, &tG)
+	if q.IsInfinity() {
+		return nil, fmt.Errorf("invalid tweak")
+	}
+	x := q.X.Bytes()
+	return x[:], nil
+}
+
+// UncompressedBytes returns the SEC1 uncompressed encoding of the public key.
+func (k Secp256k1PublicKey) UncompressedBytes() []byte {
+	xy := k.point.RawBytes()
+	return append([]byte{0x04}, xy[:]...)
+}
+
+// VerifyBIP340 verifies a BIP-340 Schnorr signature of the message, as returned by
+// sign_with_schnorr with the bip340secp256k1 algorithm.
+func (k Secp256k1PublicKey) VerifyBIP340(message, sig []byte) bool {
+	return verifyBIP340(k.XOnlyBytes(), message, sig)
+}
+
+// VerifyBIP341 verifies a BIP-340 Schnorr signature of the message by the taproot
+// output key of the given merkle root, as returned by sign_with_schnorr with the
+// bip340secp256k1 algorithm and the BIP-341 auxiliary data.
+func (k Secp256k1PublicKey) VerifyBIP341(message, sig, merkleRoot []byte) bool {
+	outputKey, err := k.TaprootOutputKey(merkleRoot)
+	if err != nil {
+		return false
+	}
+	return verifyBIP340(outputKey, message, sig)
+}
+
+// VerifyECDSA verifies an ECDSA signature (r || s) of the message hash, as returned
+// by sign_with_ecdsa. Signatures with a high s are accepted.
+func (k Secp256k1PublicKey) VerifyECDSA(messageHash, sig []byte) bool {
+	if len(messageHash) != sha256.Size || len(sig) != signatureLen {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:fr.Bytes])
+	s := new(big.Int).SetBytes(sig[fr.Bytes:])
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(secp256k1N) >= 0 || s.Cmp(secp256k1N) >= 0 {
+		return false
+	}
+	w := new(big.Int).ModInverse(s, secp256k1N)
+	u1 := new(big.Int).SetBytes(messageHash)
+	u1.Mul(u1, w).Mod(u1, secp256k1N)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, secp256k1N)
+	var p1, p2, R secp256k1.G1Affine
+	p1.ScalarMultiplicationBase(u1)
+	p2.ScalarMultiplication(&k.point, u2)
+	R.Add(&p1, &p2)
+	if R.IsInfinity() {
+		return false
+	}
+	x := R.X.Bytes()
+	v := new(big.Int).SetBytes(x[:])
+	return v.Mod(v, secp256k1N).Cmp(r) == 0
+}
+
+// XOnlyBytes returns the x-only encoding of BIP-340 of the public key, as returned by
+// schnorr_public_key without the leading byte of the SEC1 compressed encoding.
+func (k Secp256k1PublicKey) XOnlyBytes() []byte {
+	x := k.point.X.Bytes()
+	return x[:]
+}
+
+// child returns the child key with the given index.
+func (k Secp256k1PublicKey) child(index []byte) *Secp256k1PublicKey {
+	input := k.Bytes()
+	for {
+		sum := ckd(k.chainCode[:], input, index)
+		var next Secp256k1PublicKey
+		copy(next.chainCode[:], sum[chainCodeLen:])
+		if offset := new(big.Int).SetBytes(sum[:32]); offset.Cmp(secp256k1N) < 0 {
+			var t secp256k1.G1Affine
+			t.ScalarMultiplicationBase(offset)
+			next.point.Add(&k.point, &t)
+			if !next.point.IsInfinity() {
+				return &next
+			}
+		}
+		// If the offset is invalid, the derivation is retried with the same chain code
+		// and index, but 0x01 || the next chain code in place of the public key, as
+		// defined by SLIP-10.
+		input = append([]byte{0x01}, sum[chainCodeLen:]...)
+	}
+}
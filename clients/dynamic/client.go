@@ -0,0 +1,283 @@
+// Package dynamic provides a client for canisters whose interface is only known at
+// runtime, e.g. from the `candid:service` metadata of the canister.
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aviate-labs/agent-go"
+	"github.com/aviate-labs/agent-go/candid"
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// FetchDescription fetches the interface of the given canister. It uses the
+// `candid:service` metadata of the canister, and falls back to the deprecated
+// `__get_candid_interface_tmp_hack` query method of older Motoko canisters.
+func FetchDescription(a *agent.Agent, canisterID principal.Principal) (*did.Description, error) {
+	raw, err := a.GetCanisterMetadata(canisterID, "candid:service")
+	if err != nil {
+		var s string
+		if qErr := a.Query(canisterID, "__get_candid_interface_tmp_hack", nil, []any{&s}); qErr != nil {
+			return nil, err
+		}
+		raw = []byte(s)
+	}
+	return did.ParseDID([]rune(string(raw)))
+}
+
+// Client calls the methods of a canister based on its interface description.
+// Arguments are encoded against the declared argument types, and replies are
+// decoded into values with the field names of the declared result types.
+type Client struct {
+	a           *agent.Agent
+	canisterID  principal.Principal
+	description did.Description
+}
+
+// New creates a new client for the given canister and its interface description.
+func New(a *agent.Agent, canisterID principal.Principal, description did.Description) (*Client, error) {
+	if len(description.Services) == 0 {
+		return nil, fmt.Errorf("no service declared")
+	}
+	return &Client{
+		a:           a,
+		canisterID:  canisterID,
+		description: description,
+	}, nil
+}
+
+// NewFromMetadata creates a new client for the given canister, using the interface
+// description that is fetched from the canister, see FetchDescription.
+func NewFromMetadata(a *agent.Agent, canisterID principal.Principal) (*Client, error) {
+	description, err := FetchDescription(a, canisterID)
+	if err != nil {
+		return nil, err
+	}
+	return New(a, canisterID, *description)
+}
+
+// Call calls the given method with the encoded arguments. Query and composite query
+// methods are queried, oneway methods are sent without waiting for a reply, all other
+// methods are called as update. The reply is annotated with the declared result types.
+func (c Client) Call(method string, args []byte) ([]idl.Value, error) {
+	return c.CallWithContext(context.Background(), method, args)
+}
+
+// CallJSON is like Call, but takes the arguments as a JSON array, see EncodeJSON.
+func (c Client) CallJSON(method string, args []byte, opts ...candid.JSONOption) ([]idl.Value, error) {
+	raw, err := c.EncodeJSON(method, args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Call(method, raw)
+}
+
+// CallText is like Call, but takes the arguments in the candid textual format, see
+// EncodeText.
+func (c Client) CallText(method string, args string) ([]idl.Value, error) {
+	raw, err := c.EncodeText(method, args)
+	if err != nil {
+		return nil, err
+	}
+	return c.Call(method, raw)
+}
+
+// CallWithContext is like Call, but uses the given context for the request.
+func (c Client) CallWithContext(ctx context.Context, method string, args []byte) ([]idl.Value, error) {
+	reply, err := c.CallRawWithContext(ctx, method, args)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return c.DecodeResults(method, reply)
+}
+
+// CallRawWithContext is like CallWithContext, but returns the reply without decoding
+// it. The reply of a oneway method is nil.
+func (c Client) CallRawWithContext(ctx context.Context, method string, args []byte) ([]byte, error) {
+	m, err := c.Method(method)
+	if err != nil {
+		return nil, err
+	}
+	var reply []byte
+	switch {
+	case m.IsOneWay():
+		req, err := c.a.CreateRawAPIRequest(agent.RequestTypeCall, c.canisterID, method, args)
+		if err != nil {
+			return nil, err
+		}
+		return nil, req.SendWithContext(ctx)
+	case m.IsQuery():
+		req, err := c.a.CreateRawAPIRequest(agent.RequestTypeQuery, c.canisterID, method, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.QueryWithContext(ctx, &reply, false); err != nil {
+			return nil, err
+		}
+	default:
+		req, err := c.a.CreateRawAPIRequest(agent.RequestTypeCall, c.canisterID, method, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.CallAndWaitWithContext(ctx, &reply); err != nil {
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+// DecodeResults decodes the reply of the given method, and annotates it with the
+// declared result types.
+func (c Client) DecodeResults(method string, reply []byte) ([]idl.Value, error) {
+	vs, err := candid.DecodeValues(reply)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.description.AnnotateResults(method, vs); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// Description returns the interface description of the canister.
+func (c Client) Description() did.Description {
+	return c.description
+}
+
+// EncodeJSON encodes the JSON array of arguments against the declared argument types
+// of the given method, see candid.FromJSON for the mapping.
+func (c Client) EncodeJSON(method string, args []byte, opts ...candid.JSONOption) ([]byte, error) {
+	m, err := c.Method(method)
+	if err != nil {
+		return nil, err
+	}
+	return candid.FromJSON(m.Arguments, args, opts...)
+}
+
+// EncodeText encodes the arguments in the candid textual format, e.g.
+// `(record { owner = principal "aaaaa-aa" }, 10)`, against the declared argument
+// types of the given method. Numbers without type annotation are converted to the
+// declared number type.
+func (c Client) EncodeText(method string, args string) ([]byte, error) {
+	m, err := c.Method(method)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := candid.ParseValueString(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) != len(m.Arguments) {
+		return nil, fmt.Errorf("invalid number of arguments for %s: expected %d, got %d", method, len(m.Arguments), len(parsed))
+	}
+	vs := make([]idl.Value, len(parsed))
+	for i, v := range parsed {
+		// The types of textual values are inferred, e.g. `1` is an int.
+		if vs[i], err = v.Convert(m.Arguments[i]); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+	}
+	return candid.EncodeValues(vs)
+}
+
+// SubmitWithContext submits a call of the given update method with the encoded
+// arguments without waiting for the reply, and returns the ID of the request.
+func (c Client) SubmitWithContext(ctx context.Context, method string, args []byte) (agent.RequestID, error) {
+	m, err := c.Method(method)
+	if err != nil {
+		return agent.RequestID{}, err
+	}
+	if m.IsQuery() {
+		return agent.RequestID{}, fmt.Errorf("%s is a query method", method)
+	}
+	req, err := c.a.CreateRawAPIRequest(agent.RequestTypeCall, c.canisterID, method, args)
+	if err != nil {
+		return agent.RequestID{}, err
+	}
+	return req.RequestID(), req.SendWithContext(ctx)
+}
+
+// Method returns the method with the given name.
+func (c Client) Method(name string) (*Method, error) {
+	f, err := c.description.Method(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.method(name, f)
+}
+
+// Methods returns all methods of the canister, sorted by name.
+func (c Client) Methods() ([]Method, error) {
+	ms, err := c.description.Methods()
+	if err != nil {
+		return nil, err
+	}
+	methods := make([]Method, len(ms))
+	for i, m := range ms {
+		method, err := c.method(m.Name, *m.Func)
+		if err != nil {
+			return nil, err
+		}
+		methods[i] = *method
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+	return methods, nil
+}
+
+func (c Client) method(name string, f did.Func) (*Method, error) {
+	args, err := c.description.Types(f.ArgTypes)
+	if err != nil {
+		return nil, err
+	}
+	results, err := c.description.Types(f.ResTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &Method{
+		Name:      name,
+		Func:      f,
+		Arguments: args,
+		Results:   results,
+	}, nil
+}
+
+// Method is a method of a canister.
+type Method struct {
+	// Name is the name of the method.
+	Name string
+	// Func is the signature as declared in the interface description.
+	Func did.Func
+	// Arguments are the argument types of the method.
+	Arguments []idl.Type
+	// Results are the result types of the method.
+	Results []idl.Type
+}
+
+// IsOneWay reports whether the method does not return a response.
+func (m Method) IsOneWay() bool {
+	return m.Func.Annotation != nil && *m.Func.Annotation == did.AnnOneWay
+}
+
+// IsQuery reports whether the method is a query or composite query method.
+func (m Method) IsQuery() bool {
+	if m.Func.Annotation == nil {
+		return false
+	}
+	switch *m.Func.Annotation {
+	case did.AnnQuery, did.AnnCompositeQuery:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the signature of the method, e.g. `get : (nat) -> (text) query`.
+func (m Method) String() string {
+	return did.Method{Name: m.Name, Func: &m.Func}.String()
+}
@@ -0,0 +1,212 @@
+package dynamic_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aviate-labs/agent-go"
+	"github.com/aviate-labs/agent-go/candid"
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/clients/dynamic"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+var ledgerDID = `
+type Account = record { owner : principal; subaccount : opt blob };
+type Result = variant { Ok : nat; Err : text };
+type transfer = func (record { to : Account; amount : nat; memo : opt nat64 }) -> (Result);
+service : {
+	icrc1_balance_of : (Account) -> (nat) query;
+	icrc1_transfer : transfer;
+	icrc1_name : () -> (text) composite_query;
+}`
+
+func newClient(t testing.TB) *dynamic.Client {
+	desc, err := did.ParseDID([]rune(ledgerDID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := agent.New(agent.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := dynamic.New(a, principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai"), *desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func ExampleClient_Methods() {
+	c := newClient(nil)
+	methods, _ := c.Methods()
+	for _, m := range methods {
+		fmt.Println(m, m.IsQuery())
+	}
+	// Output:
+	// icrc1_balance_of : Account -> nat query true
+	// icrc1_name : () -> text composite_query true
+	// icrc1_transfer : (record {
+	//   to : Account;
+	//   amount : nat;
+	//   memo : opt nat64;
+	// }) -> Result false
+}
+
+func TestClient_Encode(t *testing.T) {
+	c := newClient(t)
+	text, err := c.EncodeText("icrc1_transfer", `(record { to = record { owner = principal "aaaaa-aa" }; amount = 100 })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json, err := c.EncodeJSON("icrc1_transfer", []byte(`[{"to":{"owner":"aaaaa-aa"},"amount":"100","memo":null}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(text, json) {
+		t.Errorf("%x != %x", text, json)
+	}
+
+	for _, args := range []string{
+		`(record { to = record { owner = principal "aaaaa-aa" }; amount = -1 })`, // nat can not be negative.
+		`(record { to = record { owner = principal "aaaaa-aa" } })`,              // Missing amount.
+		`(1, 2)`, // Too many arguments.
+	} {
+		if _, err := c.EncodeText("icrc1_transfer", args); err == nil {
+			t.Errorf("expected error for %s", args)
+		}
+	}
+	if _, err := c.EncodeText("unknown", "()"); err == nil {
+		t.Error("expected error for unknown method")
+	}
+}
+
+func TestClient_EncodeText_opt(t *testing.T) {
+	desc, err := did.ParseDID([]rune(`service : { f : (opt opt nat) -> () }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := dynamic.New(nil, principal.AnonymousID, *desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := make(map[string]string)
+	for _, args := range []string{`(null)`, `(opt null)`, `(opt opt 1)`} {
+		raw, err := c.EncodeText("f", args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other, ok := encoded[string(raw)]; ok {
+			t.Errorf("%s and %s have the same encoding", other, args)
+		}
+		encoded[string(raw)] = args
+	}
+}
+
+func TestClient_EncodeText_numbers(t *testing.T) {
+	desc, err := did.ParseDID([]rune(`service : { f : (nat64, nat, int8, float64) -> () }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := dynamic.New(nil, principal.AnonymousID, *desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := c.EncodeText("f", `(18446744073709551615, 340282366920938463463374607431768211456, -128, 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := candid.EncodeValueString(`(18446744073709551615 : nat64, 340282366920938463463374607431768211456 : nat, -128 : int8, 1.0 : float64)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("%x != %x", raw, expected)
+	}
+
+	for _, args := range []string{
+		`(18446744073709551616, 0, 0, 0)`, // nat64 overflow.
+		`(0, -1, 0, 0)`,                   // nat can not be negative.
+		`(0, 0, 128, 0)`,                  // int8 overflow.
+	} {
+		if _, err := c.EncodeText("f", args); err == nil {
+			t.Errorf("expected error for %s", args)
+		}
+	}
+}
+
+func TestClient_DecodeResults(t *testing.T) {
+	c := newClient(t)
+	reply, err := candid.EncodeValueString(`(variant { Err = "insufficient funds" })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, err := c.DecodeResults("icrc1_transfer", reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := vs[0].String(); s != `variant { Err = "insufficient funds" }` {
+		t.Error(s)
+	}
+}
+
+func TestClient_SubmitWithContext(t *testing.T) {
+	// /call returns 202 and nothing else is served: a submitted call must return
+	// without polling the request status.
+	var calls, other int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/call"):
+			calls++
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			other++
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	host, _ := url.Parse(srv.URL)
+
+	desc, err := did.ParseDID([]rune(ledgerDID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := agent.New(agent.Config{
+		ClientConfig: []agent.ClientOption{agent.WithHostURL(host)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := dynamic.New(a, principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai"), *desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := c.EncodeText("icrc1_transfer", `(record { to = record { owner = principal "aaaaa-aa" }; amount = 1 })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := c.SubmitWithContext(context.Background(), "icrc1_transfer", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == (agent.RequestID{}) {
+		t.Error("expected a request id")
+	}
+	if calls != 1 || other != 0 {
+		t.Errorf("expected a single call request, got %d calls and %d other requests", calls, other)
+	}
+
+	args, err = c.EncodeText("icrc1_balance_of", `(record { owner = principal "aaaaa-aa" })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SubmitWithContext(context.Background(), "icrc1_balance_of", args); err == nil {
+		t.Error("expected an error for a query method")
+	}
+}
@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/aviate-labs/agent-go/principal"
+	"github.com/aviate-labs/agent-go/principal/icrc"
+)
+
+// NewAccountIdentifier converts the account identifier into its protobuf message,
+// the 32 byte identifier including the checksum.
+func NewAccountIdentifier(id principal.AccountIdentifier) *AccountIdentifier {
+	return &AccountIdentifier{Hash: id.Bytes()}
+}
+
+// NewAccountIdentifierFromAccount converts the ICRC account into the protobuf message
+// of its account identifier, which the ledger uses for the account. The conversion is
+// one way, the identifier is a hash of the account.
+func NewAccountIdentifierFromAccount(account icrc.Account) *AccountIdentifier {
+	var subAccount [32]byte
+	if account.SubAccount != nil {
+		subAccount = *account.SubAccount
+	}
+	return NewAccountIdentifier(principal.NewAccountID(account.Owner, subAccount))
+}
+
+// NewPrincipalId converts the principal into its protobuf message.
+func NewPrincipalId(p principal.Principal) *PrincipalId {
+	return &PrincipalId{SerializedId: p.Raw}
+}
+
+// NewSubaccount converts the sub-account into its protobuf message.
+func NewSubaccount(subAccount principal.SubAccount) *Subaccount {
+	return &Subaccount{SubAccount: subAccount[:]}
+}
+
+// NewTokens converts the amount of e8s into its protobuf message.
+func NewTokens(e8s idl.Nat) (*Tokens, error) {
+	bi := e8s.BigInt()
+	if bi == nil {
+		return &Tokens{}, nil
+	}
+	if !bi.IsUint64() {
+		return nil, fmt.Errorf("invalid amount of tokens: %s", bi)
+	}
+	return &Tokens{E8S: bi.Uint64()}, nil
+}
+
+// NewTokensFromInt converts the amount of e8s into its protobuf message, the amount
+// must not be negative.
+func NewTokensFromInt(e8s idl.Int) (*Tokens, error) {
+	bi := e8s.BigInt()
+	if bi != nil && bi.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount of tokens: %s", bi)
+	}
+	return NewTokens(idl.NewBigNat(bi))
+}
+
+// AccountIdentifier converts the protobuf message into an account identifier, the
+// hash may or may not include the checksum.
+func (x *AccountIdentifier) AccountIdentifier() (principal.AccountIdentifier, error) {
+	var id principal.AccountIdentifier
+	if err := id.UnmarshalBinary(x.GetHash()); err != nil {
+		return principal.AccountIdentifier{}, err
+	}
+	return id, nil
+}
+
+// Int converts the protobuf message into an amount of e8s.
+func (x *Tokens) Int() idl.Int {
+	return idl.NewBigInt(new(big.Int).SetUint64(x.GetE8S()))
+}
+
+// Nat converts the protobuf message into an amount of e8s.
+func (x *Tokens) Nat() idl.Nat {
+	return idl.NewNat(x.GetE8S())
+}
+
+// Principal converts the protobuf message into a principal.
+func (x *PrincipalId) Principal() (principal.Principal, error) {
+	var p principal.Principal
+	if err := p.UnmarshalBinary(x.GetSerializedId()); err != nil {
+		return principal.Principal{}, err
+	}
+	return p, nil
+}
+
+// SubAccountID converts the protobuf message into a sub-account.
+func (x *Subaccount) SubAccountID() (principal.SubAccount, error) {
+	raw := x.GetSubAccount()
+	if len(raw) != 32 {
+		return principal.SubAccount{}, fmt.Errorf("invalid sub account length: %d", len(raw))
+	}
+	return principal.SubAccount(raw), nil
+}
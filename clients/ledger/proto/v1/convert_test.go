@@ -0,0 +1,102 @@
+package v1_test
+
+import (
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid/idl"
+	v1 "github.com/aviate-labs/agent-go/clients/ledger/proto/v1"
+	"github.com/aviate-labs/agent-go/principal"
+	"github.com/aviate-labs/agent-go/principal/icrc"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAccountIdentifier(t *testing.T) {
+	owner := principal.MustDecode("k2t6j-2nvnp-4zjm3-25dtz-6xhaa-c7boj-5gayf-oj3xs-i43lp-teztq-6ae")
+	id := principal.NewAccountID(owner, principal.SubAccount{31: 1})
+
+	var msg v1.AccountIdentifier
+	raw, err := proto.Marshal(v1.NewAccountIdentifier(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proto.Unmarshal(raw, &msg); err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []*v1.AccountIdentifier{
+		&msg,
+		v1.NewAccountIdentifierFromAccount(icrc.Account{Owner: owner, SubAccount: &[32]byte{31: 1}}),
+		{Hash: id[:]}, // without the checksum
+	} {
+		decoded, err := msg.AccountIdentifier()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != id {
+			t.Errorf("expected %s, got %s", id, decoded)
+		}
+	}
+
+	// The default sub-account is the same account as no sub-account.
+	a, _ := v1.NewAccountIdentifierFromAccount(icrc.Account{Owner: owner}).AccountIdentifier()
+	if a != principal.NewAccountID(owner, principal.DefaultSubAccount) {
+		t.Errorf("unexpected account identifier: %s", a)
+	}
+	if _, err := (&v1.AccountIdentifier{Hash: []byte{0x01}}).AccountIdentifier(); err == nil {
+		t.Error("invalid hash")
+	}
+}
+
+func TestPrincipalId(t *testing.T) {
+	for _, p := range []principal.Principal{
+		principal.MustDecode("aaaaa-aa"),
+		principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai"),
+	} {
+		decoded, err := v1.NewPrincipalId(p).Principal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(p) {
+			t.Errorf("expected %s, got %s", p, decoded)
+		}
+	}
+	if _, err := (&v1.PrincipalId{SerializedId: make([]byte, 30)}).Principal(); err == nil {
+		t.Error("invalid principal")
+	}
+}
+
+func TestSubaccount(t *testing.T) {
+	subAccount := principal.SubAccount{0: 1, 31: 2}
+	decoded, err := v1.NewSubaccount(subAccount).SubAccountID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != subAccount {
+		t.Errorf("expected %x, got %x", subAccount, decoded)
+	}
+	if _, err := (&v1.Subaccount{SubAccount: []byte{0x01}}).SubAccountID(); err == nil {
+		t.Error("invalid sub account")
+	}
+}
+
+func TestTokens(t *testing.T) {
+	nat, err := v1.NewTokens(idl.NewNat(uint64(100_000_000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := v1.NewTokensFromInt(idl.NewInt(100_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nat.GetE8S() != 100_000_000 || i.GetE8S() != 100_000_000 {
+		t.Errorf("unexpected amounts: %d, %d", nat.GetE8S(), i.GetE8S())
+	}
+	if nat.Nat().BigInt().Uint64() != 100_000_000 || i.Int().BigInt().Int64() != 100_000_000 {
+		t.Errorf("unexpected amounts: %s, %s", nat.Nat(), i.Int())
+	}
+	if _, err := v1.NewTokensFromInt(idl.NewInt(-1)); err == nil {
+		t.Error("negative amount")
+	}
+	if _, err := v1.NewTokens(idl.NewNatFromString("18446744073709551616")); err == nil {
+		t.Error("amount does not fit in 64 bits")
+	}
+}
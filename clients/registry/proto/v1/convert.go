@@ -0,0 +1,19 @@
+package v1
+
+import (
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// NewPrincipalId converts the principal into its protobuf message.
+func NewPrincipalId(p principal.Principal) *PrincipalId {
+	return &PrincipalId{Raw: p.Raw}
+}
+
+// Principal converts the protobuf message into a principal.
+func (x *PrincipalId) Principal() (principal.Principal, error) {
+	var p principal.Principal
+	if err := p.UnmarshalBinary(x.GetRaw()); err != nil {
+		return principal.Principal{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,35 @@
+package v1_test
+
+import (
+	"testing"
+
+	v1 "github.com/aviate-labs/agent-go/clients/registry/proto/v1"
+	"github.com/aviate-labs/agent-go/principal"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPrincipalId(t *testing.T) {
+	for _, p := range []principal.Principal{
+		principal.MustDecode("aaaaa-aa"),
+		principal.MustDecode("rwlgt-iiaaa-aaaaa-aaaaa-cai"),
+	} {
+		raw, err := proto.Marshal(v1.NewPrincipalId(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg v1.PrincipalId
+		if err := proto.Unmarshal(raw, &msg); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := msg.Principal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(p) {
+			t.Errorf("expected %s, got %s", p, decoded)
+		}
+	}
+	if _, err := (&v1.PrincipalId{Raw: make([]byte, 30)}).Principal(); err == nil {
+		t.Error("invalid principal")
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/aviate-labs/agent-go"
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/aviate-labs/agent-go/clients/dynamic"
+	"github.com/aviate-labs/agent-go/cmd/goic/internal/cmd"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// localReplica is the address of the replica started by `dfx start`.
+const localReplica = "http://127.0.0.1:4943"
+
+var callCommand = cmd.NewCommand(
+	"call",
+	"Call a method of a canister with arguments in the candid textual format, e.g. '(42, \"text\")'.",
+	[]string{"canisterID", "method", "args"},
+	[]cmd.CommandOption{
+		{
+			Name:        "did",
+			Description: "Read the DID from this file instead of fetching it from the canister.",
+			HasValue:    true,
+		},
+		{
+			Name:        "identity",
+			Description: "Sign the request with this identity or the identity of this PEM file (default: the default identity or anonymous).",
+			HasValue:    true,
+		},
+		{
+			Name:        "network",
+			Description: "Send the request to this network: ic, local or a URL (default: ic).",
+			HasValue:    true,
+		},
+		{
+			Name:        "fetch-root-key",
+			Description: "Trust the root key returned by the network, e.g. of a test network (default: only for local networks).",
+			HasValue:    false,
+		},
+		{
+			Name:        "output",
+			Description: "Print the reply as candid, json or raw (default: candid).",
+			HasValue:    true,
+		},
+		{
+			Name:        "async",
+			Description: "Submit an update call without waiting for the reply and print its request ID.",
+			HasValue:    false,
+		},
+	},
+	func(args []string, options map[string]string) error {
+		canisterID, err := principal.Decode(args[0])
+		if err != nil {
+			return err
+		}
+		method := args[1]
+		output := "candid"
+		if o, ok := options["output"]; ok {
+			output = o
+		}
+		switch output {
+		case "candid", "json", "raw":
+		default:
+			return fmt.Errorf("invalid output format: %s", output)
+		}
+
+		config, err := agentConfig(options)
+		if err != nil {
+			return err
+		}
+		a, err := agent.New(config)
+		if err != nil {
+			return err
+		}
+		var desc *did.Description
+		if p, ok := options["did"]; ok {
+			desc, err = did.ParseDIDFile(p)
+		} else {
+			desc, err = dynamic.FetchDescription(a, canisterID)
+		}
+		if err != nil {
+			return err
+		}
+		c, err := dynamic.New(a, canisterID, *desc)
+		if err != nil {
+			return err
+		}
+		raw, err := c.EncodeText(method, args[2])
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		if _, ok := options["async"]; ok {
+			requestID, err := c.SubmitWithContext(ctx, method, raw)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%x\n", requestID)
+			return nil
+		}
+		reply, err := c.CallRawWithContext(ctx, method, raw)
+		if err != nil || reply == nil {
+			return err
+		}
+		if output == "raw" {
+			fmt.Println(hex.EncodeToString(reply))
+			return nil
+		}
+		vs, err := c.DecodeResults(method, reply)
+		if err != nil {
+			return err
+		}
+		s, err := formatValues(vs, output)
+		if err != nil {
+			return err
+		}
+		fmt.Println(s)
+		return nil
+	},
+)
+
+// agentConfig returns the configuration of the agent for the "identity" and
+// "network" options, see loadIdentity. The root key is only fetched from local
+// networks, or with the "fetch-root-key" option, since certificates signed by a key
+// of the network itself prove nothing. Other networks are verified with the root key
+// of the IC.
+func agentConfig(options map[string]string) (agent.Config, error) {
+	var config agent.Config
+	id, err := loadIdentity(options)
+	if err != nil {
+		return config, err
+	}
+	if id != nil {
+		config.Identity = id
+	}
+	network := "ic"
+	if n, ok := options["network"]; ok {
+		network = n
+	}
+	switch network {
+	case "ic":
+		return config, nil
+	case "local":
+		network = localReplica
+	}
+	host, err := url.Parse(network)
+	if err != nil {
+		return config, err
+	}
+	if host.Scheme == "" || host.Host == "" {
+		return config, fmt.Errorf("invalid network: %s", network)
+	}
+	config.ClientConfig = []agent.ClientOption{agent.WithHostURL(host)}
+	_, fetchRootKey := options["fetch-root-key"]
+	config.FetchRootKey = fetchRootKey || isLoopback(host)
+	return config, nil
+}
+
+// isLoopback reports whether the host of the URL is a loopback address, e.g. of a
+// replica started by `dfx start`.
+func isLoopback(u *url.URL) bool {
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+// formatValues returns the values in the given output format, candid or json.
+func formatValues(vs []idl.Value, output string) (string, error) {
+	if output == "json" {
+		if vs == nil {
+			vs = []idl.Value{}
+		}
+		raw, err := json.Marshal(vs)
+		if err != nil {
+			return "", err
+		}
+		return string(raw), nil
+	}
+	ss := make([]string, len(vs))
+	for i, v := range vs {
+		ss[i] = v.String()
+	}
+	return fmt.Sprintf("(%s)", strings.Join(ss, ", ")), nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aviate-labs/agent-go/candid"
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/clients/dynamic"
+	"github.com/aviate-labs/agent-go/cmd/goic/internal/cmd"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+var candidCommand = cmd.NewCommandFork(
+	"candid",
+	"Encode, decode and check candid values and DID files.",
+	cmd.NewCommand(
+		"encode",
+		"Encode arguments in the candid textual format, e.g. '(42, \"text\")', and print them as hex.",
+		[]string{"args"},
+		[]cmd.CommandOption{
+			{
+				Name:        "did",
+				Description: "Encode the arguments against the argument types of --method declared in this DID file.",
+				HasValue:    true,
+			},
+			{
+				Name:        "method",
+				Description: "The method of which the argument types are used, requires --did.",
+				HasValue:    true,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			c, method, err := methodClient(options)
+			if err != nil {
+				return err
+			}
+			var raw []byte
+			if c != nil {
+				raw, err = c.EncodeText(method, args[0])
+			} else {
+				raw, err = candid.EncodeValueString(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println(hex.EncodeToString(raw))
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"decode",
+		"Decode candid encoded values, given as hex or as a file containing hex or binary.",
+		[]string{"input"},
+		[]cmd.CommandOption{
+			{
+				Name:        "did",
+				Description: "Name the fields with the result types of --method declared in this DID file.",
+				HasValue:    true,
+			},
+			{
+				Name:        "method",
+				Description: "The method of which the result types are used, requires --did.",
+				HasValue:    true,
+			},
+			{
+				Name:        "arguments",
+				Description: "Use the argument types of --method instead of the result types.",
+				HasValue:    false,
+			},
+			{
+				Name:        "output",
+				Description: "Print the values as candid or json (default: candid).",
+				HasValue:    true,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			output := "candid"
+			if o, ok := options["output"]; ok {
+				output = o
+			}
+			if output != "candid" && output != "json" {
+				return fmt.Errorf("invalid output format: %s", output)
+			}
+			raw, err := readEncoded(args[0])
+			if err != nil {
+				return err
+			}
+			c, method, err := methodClient(options)
+			if err != nil {
+				return err
+			}
+			vs, err := candid.DecodeValues(raw)
+			if err != nil {
+				return err
+			}
+			if c != nil {
+				desc := c.Description()
+				if _, ok := options["arguments"]; ok {
+					err = desc.AnnotateArguments(method, vs)
+				} else {
+					err = desc.AnnotateResults(method, vs)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			s, err := formatValues(vs, output)
+			if err != nil {
+				return err
+			}
+			fmt.Println(s)
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"check-compat",
+		"Check whether the service of the new DID can replace the service of the old DID, e.g. on an upgrade.",
+		[]string{"new", "old"},
+		[]cmd.CommandOption{},
+		func(args []string, options map[string]string) error {
+			desc, err := did.ParseDIDFile(args[0])
+			if err != nil {
+				return err
+			}
+			prev, err := did.ParseDIDFile(args[1])
+			if err != nil {
+				return err
+			}
+			if err := desc.CheckCompatibility(*prev); err != nil {
+				return fmt.Errorf("%s is not compatible with %s:\n%w", args[0], args[1], err)
+			}
+			fmt.Printf("%s is compatible with %s\n", args[0], args[1])
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"fmt",
+		"Print a DID file in its canonical format, without comments.",
+		[]string{"path"},
+		[]cmd.CommandOption{
+			{
+				Name:        "write",
+				Description: "Write the result to the file instead of stdout, refused for files with comments.",
+				HasValue:    false,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			raw, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			formatted, err := formatDID(string(raw))
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+			if _, ok := options["write"]; ok {
+				if hasComments(string(raw)) {
+					return fmt.Errorf("%s: comments are not kept by fmt, refusing to overwrite the file", args[0])
+				}
+				return os.WriteFile(args[0], []byte(formatted), outputPerm)
+			}
+			fmt.Print(formatted)
+			return nil
+		},
+	),
+)
+
+// formatDID returns the canonical format of the DID. The result is parsed again, so
+// that a file is never overwritten with a DID that can not be read back.
+func formatDID(raw string) (string, error) {
+	desc, err := did.ParseDID([]rune(raw))
+	if err != nil {
+		return "", err
+	}
+	formatted := desc.String() + "\n"
+	again, err := did.ParseDID([]rune(formatted))
+	if err != nil {
+		return "", fmt.Errorf("invalid formatted output: %w", err)
+	}
+	if again.String()+"\n" != formatted {
+		return "", fmt.Errorf("the formatted output does not round trip")
+	}
+	return formatted, nil
+}
+
+// hasComments reports whether the DID contains comments, ignoring comment markers
+// inside text, e.g. in quoted names or imports.
+func hasComments(raw string) bool {
+	var text bool
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case text && c == '\\':
+			i++ // Skip the escaped character.
+		case c == '"':
+			text = !text
+		case !text && c == '/' && i+1 < len(raw) && (raw[i+1] == '/' || raw[i+1] == '*'):
+			return true
+		}
+	}
+	return false
+}
+
+// methodClient returns a client for the DID of the "did" option, which is only used
+// to encode and decode values, and the method of the "method" option. Without a DID,
+// the client is nil.
+func methodClient(options map[string]string) (*dynamic.Client, string, error) {
+	path, hasDID := options["did"]
+	method, hasMethod := options["method"]
+	if hasDID != hasMethod {
+		return nil, "", fmt.Errorf("--did and --method have to be used together")
+	}
+	if !hasDID {
+		return nil, "", nil
+	}
+	desc, err := did.ParseDIDFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	c, err := dynamic.New(nil, principal.AnonymousID, *desc)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := c.Method(method); err != nil {
+		return nil, "", err
+	}
+	return c, method, nil
+}
+
+// readEncoded returns the candid encoded values of the input, which is either hex
+// or the path of a file containing hex or binary.
+func readEncoded(input string) ([]byte, error) {
+	if raw, err := hex.DecodeString(input); err == nil {
+		return raw, nil
+	}
+	raw, err := os.ReadFile(input)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(raw, []byte("DIDL")) {
+		return raw, nil
+	}
+	return hex.DecodeString(strings.TrimSpace(string(raw)))
+}
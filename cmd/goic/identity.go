@@ -0,0 +1,433 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aviate-labs/agent-go/cmd/goic/internal/cmd"
+	"github.com/aviate-labs/agent-go/identity"
+	"github.com/aviate-labs/agent-go/principal"
+	"github.com/aviate-labs/agent-go/principal/icrc"
+)
+
+// passwordEnv is the environment variable of the password of encrypted identities.
+const passwordEnv = "GOIC_IDENTITY_PASSWORD"
+
+var identityCommand = cmd.NewCommandFork(
+	"identity",
+	"Manage the identities of the local identity store.",
+	cmd.NewCommand(
+		"new",
+		"Create a new identity with a random key.",
+		[]string{"name"},
+		[]cmd.CommandOption{
+			{
+				Name:        "type",
+				Description: "Key type of the identity: secp256k1, ed25519 or prime256v1 (default: secp256k1).",
+				HasValue:    true,
+			},
+			{
+				Name:        "encrypt",
+				Description: "Encrypt the identity with the password of $GOIC_IDENTITY_PASSWORD, like dfx encrypts identities.",
+				HasValue:    false,
+			},
+			{
+				Name:        "force",
+				Description: "Overwrite an existing identity with the same name.",
+				HasValue:    false,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			typ := "secp256k1"
+			if t, ok := options["type"]; ok {
+				typ = t
+			}
+			var (
+				id  identity.Identity
+				err error
+			)
+			switch typ {
+			case "secp256k1":
+				id, err = identity.NewRandomSecp256k1Identity()
+			case "ed25519":
+				id, err = identity.NewRandomEd25519Identity()
+			case "prime256v1":
+				id, err = identity.NewRandomPrime256v1Identity()
+			default:
+				return fmt.Errorf("invalid key type: %s", typ)
+			}
+			if err != nil {
+				return err
+			}
+			if err := saveIdentity(args[0], id, options); err != nil {
+				return err
+			}
+			fmt.Println(id.Sender())
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"import",
+		"Import the identity of a PEM file.",
+		[]string{"name", "path"},
+		[]cmd.CommandOption{
+			{
+				Name:        "dfx",
+				Description: "Import the dfx identity with the name of path instead of a file.",
+				HasValue:    false,
+			},
+			{
+				Name:        "seed",
+				Description: "Derive the secp256k1 identity from the BIP-39 mnemonic in the file at path, like quill.",
+				HasValue:    false,
+			},
+			{
+				Name:        "index",
+				Description: "Derive the identity of this account of the mnemonic, m/44'/223'/0'/0/{index} (default: 0).",
+				HasValue:    true,
+			},
+			{
+				Name:        "encrypt",
+				Description: "Encrypt the identity with the password of $GOIC_IDENTITY_PASSWORD, like dfx encrypts identities.",
+				HasValue:    false,
+			},
+			{
+				Name:        "force",
+				Description: "Overwrite an existing identity with the same name.",
+				HasValue:    false,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			var (
+				id  identity.Identity
+				err error
+			)
+			if _, ok := options["dfx"]; ok {
+				var dfx *identity.Keystore
+				if dfx, err = identity.NewDfxKeystore(); err != nil {
+					return err
+				}
+				id, err = loadStoredIdentity(dfx, args[1])
+			} else if _, ok := options["seed"]; ok {
+				id, err = loadSeedFile(args[1], options)
+			} else {
+				id, err = loadIdentityFile(args[1])
+			}
+			if err != nil {
+				return err
+			}
+			if err := saveIdentity(args[0], id, options); err != nil {
+				return err
+			}
+			fmt.Println(id.Sender())
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"export",
+		"Print the PEM of an identity.",
+		[]string{"name"},
+		[]cmd.CommandOption{
+			{
+				Name:        "encrypt",
+				Description: "Encrypt the PEM (PKCS #8) with the password of $GOIC_IDENTITY_PASSWORD.",
+				HasValue:    false,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			id, err := storedIdentity(args[0])
+			if err != nil {
+				return err
+			}
+			var raw []byte
+			if _, ok := options["encrypt"]; ok {
+				password, err := requiredPassword()
+				if err != nil {
+					return err
+				}
+				raw, err = identity.EncryptPEM(id, password)
+			} else {
+				raw, err = id.ToPEM()
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(raw))
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"list",
+		"List the identities, the default identity is marked with a *.",
+		[]string{},
+		[]cmd.CommandOption{
+			{
+				Name:        "dfx",
+				Description: "List the dfx identities instead.",
+				HasValue:    false,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			k, err := identityStore()
+			if err != nil {
+				return err
+			}
+			if _, ok := options["dfx"]; ok {
+				if k, err = identity.NewDfxKeystore(); err != nil {
+					return err
+				}
+			}
+			names, err := k.Names()
+			if err != nil {
+				return err
+			}
+			def, err := k.Default()
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if name == def {
+					name += " *"
+				}
+				fmt.Println(name)
+			}
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"use",
+		"Set the default identity, used when no --identity is given.",
+		[]string{"name"},
+		[]cmd.CommandOption{},
+		func(args []string, options map[string]string) error {
+			k, err := identityStore()
+			if err != nil {
+				return err
+			}
+			return k.SetDefault(args[0])
+		},
+	),
+	cmd.NewCommand(
+		"principal",
+		"Print the principal of an identity.",
+		[]string{},
+		[]cmd.CommandOption{
+			{
+				Name:        "identity",
+				Description: "Name of the identity or path of a PEM file (default: the default identity).",
+				HasValue:    true,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			id, err := selectedIdentity(options)
+			if err != nil {
+				return err
+			}
+			fmt.Println(id.Sender())
+			return nil
+		},
+	),
+	cmd.NewCommand(
+		"account-id",
+		"Print the ICP account identifier and the ICRC-1 account of an identity.",
+		[]string{},
+		[]cmd.CommandOption{
+			{
+				Name:        "identity",
+				Description: "Name of the identity or path of a PEM file (default: the default identity).",
+				HasValue:    true,
+			},
+			{
+				Name:        "subaccount",
+				Description: "Hex encoded subaccount of at most 32 bytes (default: the default subaccount).",
+				HasValue:    true,
+			},
+		},
+		func(args []string, options map[string]string) error {
+			id, err := selectedIdentity(options)
+			if err != nil {
+				return err
+			}
+			account := icrc.Account{Owner: id.Sender()}
+			var subAccount [32]byte
+			if s, ok := options["subaccount"]; ok {
+				raw, err := hex.DecodeString(s)
+				if err != nil {
+					return err
+				}
+				if len(raw) > len(subAccount) {
+					return fmt.Errorf("invalid subaccount: expected at most 32 bytes, got %d", len(raw))
+				}
+				// Shorter subaccounts are big-endian numbers, left-padded with zeros.
+				copy(subAccount[len(subAccount)-len(raw):], raw)
+				if subAccount != principal.DefaultSubAccount {
+					account.SubAccount = &subAccount
+				}
+			}
+			fmt.Printf("account-id: %s\n", principal.NewAccountID(account.Owner, subAccount))
+			fmt.Printf("icrc1:      %s\n", account)
+			return nil
+		},
+	),
+)
+
+// configDir returns the directory of the goic configuration. It is $GOIC_CONFIG_DIR
+// or the goic directory in the user configuration directory.
+func configDir() (string, error) {
+	if dir := os.Getenv("GOIC_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goic"), nil
+}
+
+// identityStore returns the identity store. Like dfx, every identity is stored in
+// identity/<name> of the configuration directory, and the name of the default
+// identity is stored in identity.json.
+func identityStore() (*identity.Keystore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return identity.NewKeystore(filepath.Join(dir, "identity")), nil
+}
+
+// loadIdentity loads the identity for the "identity" option, which is the name of a
+// stored identity or else the path of a PEM file. Without the option, the default
+// identity is loaded, if any.
+func loadIdentity(options map[string]string) (identity.Identity, error) {
+	name, ok := options["identity"]
+	if !ok {
+		k, err := identityStore()
+		if err != nil {
+			return nil, err
+		}
+		if name, err = k.Default(); err != nil || name == "" {
+			return nil, err
+		}
+		return loadStoredIdentity(k, name)
+	}
+	if _, err := os.Stat(name); err == nil && !isStoredIdentity(name) {
+		return loadIdentityFile(name)
+	}
+	return storedIdentity(name)
+}
+
+// isStoredIdentity reports whether an identity with the given name is stored.
+func isStoredIdentity(name string) bool {
+	k, err := identityStore()
+	return err == nil && k.Exists(name)
+}
+
+// loadIdentityFile loads the Ed25519, secp256k1 or prime256v1 identity of the PEM
+// file at path, e.g. as exported by `dfx identity export`. Encrypted PEM files are
+// decrypted with the password of $GOIC_IDENTITY_PASSWORD.
+func loadIdentityFile(path string) (identity.Identity, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(raw); block != nil && block.Type == "ENCRYPTED PRIVATE KEY" {
+		password, err := requiredPassword()
+		if err != nil {
+			return nil, err
+		}
+		return identity.NewIdentityFromEncryptedPEM(raw, password)
+	}
+	id, err := identity.NewIdentityFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return id, nil
+}
+
+// loadSeedFile derives the secp256k1 identity of the account of the "index" option
+// from the BIP-39 mnemonic in the file at path.
+func loadSeedFile(path string, options map[string]string) (identity.Identity, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var index uint64
+	if i, ok := options["index"]; ok {
+		if index, err = strconv.ParseUint(i, 10, 31); err != nil {
+			return nil, fmt.Errorf("invalid index: %s", i)
+		}
+	}
+	return identity.NewSecp256k1IdentityFromMnemonic(string(raw), "", uint32(index))
+}
+
+// loadStoredIdentity loads the identity with the given name of the keystore.
+// Encrypted identities are decrypted with the password of $GOIC_IDENTITY_PASSWORD.
+func loadStoredIdentity(k *identity.Keystore, name string) (identity.Identity, error) {
+	var password []byte
+	if p, ok := os.LookupEnv(passwordEnv); ok {
+		password = []byte(p)
+	}
+	id, err := k.Load(name, password)
+	if errors.Is(err, identity.ErrPasswordRequired) {
+		return nil, fmt.Errorf("%w, see $%s", err, passwordEnv)
+	}
+	return id, err
+}
+
+// requiredPassword returns the password of $GOIC_IDENTITY_PASSWORD.
+func requiredPassword() ([]byte, error) {
+	p, ok := os.LookupEnv(passwordEnv)
+	if !ok {
+		return nil, fmt.Errorf("no password, see $%s", passwordEnv)
+	}
+	return []byte(p), nil
+}
+
+// saveIdentity stores the identity under the given name, encrypted if the "encrypt"
+// option is set. The first identity becomes the default identity.
+func saveIdentity(name string, id identity.Identity, options map[string]string) error {
+	k, err := identityStore()
+	if err != nil {
+		return err
+	}
+	if _, force := options["force"]; k.Exists(name) && !force {
+		return fmt.Errorf("identity %q already exists, use --force to overwrite it", name)
+	}
+	var password []byte
+	if _, ok := options["encrypt"]; ok {
+		if password, err = requiredPassword(); err != nil {
+			return err
+		}
+	}
+	if err := k.Save(name, id, password); err != nil {
+		return err
+	}
+	def, err := k.Default()
+	if err != nil || def != "" {
+		return err
+	}
+	return k.SetDefault(name)
+}
+
+// selectedIdentity is like loadIdentity, but fails if no identity is selected.
+func selectedIdentity(options map[string]string) (identity.Identity, error) {
+	id, err := loadIdentity(options)
+	if err == nil && id == nil {
+		return nil, fmt.Errorf("no default identity, see `goic identity use`")
+	}
+	return id, err
+}
+
+// storedIdentity loads the stored identity with the given name.
+func storedIdentity(name string) (identity.Identity, error) {
+	k, err := identityStore()
+	if err != nil {
+		return nil, err
+	}
+	return loadStoredIdentity(k, name)
+}
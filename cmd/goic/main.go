@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/aviate-labs/agent-go"
 	"github.com/aviate-labs/agent-go/cmd/goic/internal/cmd"
@@ -10,8 +15,12 @@ import (
 	"github.com/aviate-labs/agent-go/principal"
 )
 
-// rw-r--r-- : data files, not executable.
-const outputPerm os.FileMode = 0o644
+const (
+	// rw-r--r-- : data files, not executable.
+	outputPerm os.FileMode = 0o644
+	// rwxr-xr-x : directories of generated packages.
+	outputDirPerm os.FileMode = 0o755
+)
 
 var root = cmd.NewCommandFork(
 	"goic",
@@ -26,6 +35,9 @@ var root = cmd.NewCommandFork(
 			return nil
 		},
 	),
+	callCommand,
+	candidCommand,
+	identityCommand,
 	cmd.NewCommand(
 		"fetch",
 		"Fetch a DID from a canister ID.",
@@ -92,6 +104,21 @@ var root = cmd.NewCommandFork(
 					Description: "Generate indirect (boxed) call wrappers.",
 					HasValue:    false,
 				},
+				{
+					Name:        "generics",
+					Description: "Use idl.Opt and idl.Result instead of pointers for opt and Ok/Err variants.",
+					HasValue:    false,
+				},
+				{
+					Name:        "mock",
+					Description: "Also generate an interface of the service and an in-memory fake for tests.",
+					HasValue:    false,
+				},
+				{
+					Name:        "template",
+					Description: "Generate with the template files matching this pattern instead of the Agent template.",
+					HasValue:    true,
+				},
 			},
 			func(args []string, options map[string]string) error {
 				inputPath := args[0]
@@ -110,7 +137,7 @@ var root = cmd.NewCommandFork(
 				if err != nil {
 					return err
 				}
-				return writeGenerated(g, canisterID, o.output, o.indirect)
+				return writeGenerated(g, canisterID, o)
 			},
 		),
 		cmd.NewCommand(
@@ -138,6 +165,21 @@ var root = cmd.NewCommandFork(
 					Description: "Generate indirect (boxed) call wrappers.",
 					HasValue:    false,
 				},
+				{
+					Name:        "generics",
+					Description: "Use idl.Opt and idl.Result instead of pointers for opt and Ok/Err variants.",
+					HasValue:    false,
+				},
+				{
+					Name:        "mock",
+					Description: "Also generate an interface of the service and an in-memory fake for tests.",
+					HasValue:    false,
+				},
+				{
+					Name:        "template",
+					Description: "Generate with the template files matching this pattern instead of the Agent template.",
+					HasValue:    true,
+				},
 			},
 			func(args []string, options map[string]string) error {
 				id := args[0]
@@ -151,7 +193,96 @@ var root = cmd.NewCommandFork(
 				}
 
 				o := parseGenOptions(args[1], options)
-				return writeDID(o.agentName, o.canisterName, &canisterID, o.packageName, o.output, []rune(string(rawDID)), o.indirect)
+				return writeDID(&canisterID, []rune(string(rawDID)), o)
+			},
+		),
+		cmd.NewCommand(
+			"project",
+			"Generate an Agent for every canister of a dfx.json project.",
+			[]string{"manifest"},
+			[]cmd.CommandOption{
+				{
+					Name:        "output",
+					Description: "Write the packages to this directory (default: current directory).",
+					HasValue:    true,
+				},
+				{
+					Name:        "module",
+					Description: "Import path of the output directory (default: derived from go.mod).",
+					HasValue:    true,
+				},
+				{
+					Name:        "network",
+					Description: "Embed the canister IDs of this network from canister_ids.json (default: ic).",
+					HasValue:    true,
+				},
+				{
+					Name:        "sharedPackageName",
+					Description: "Go package name for the types shared by the canisters (default: types).",
+					HasValue:    true,
+				},
+				{
+					Name:        "indirect",
+					Description: "Generate indirect (boxed) call wrappers.",
+					HasValue:    false,
+				},
+				{
+					Name:        "generics",
+					Description: "Use idl.Opt and idl.Result instead of pointers for opt and Ok/Err variants.",
+					HasValue:    false,
+				},
+				{
+					Name:        "mock",
+					Description: "Also generate an interface of the service and an in-memory fake for tests.",
+					HasValue:    false,
+				},
+			},
+			func(args []string, options map[string]string) error {
+				network := "ic"
+				if n, ok := options["network"]; ok {
+					network = n
+				}
+				p, err := gen.LoadProject(args[0], network)
+				if err != nil {
+					return err
+				}
+				if n, ok := options["sharedPackageName"]; ok {
+					p.SharedPackageName = n
+				}
+				if _, ok := options["indirect"]; ok {
+					p.Indirect()
+				}
+				if _, ok := options["generics"]; ok {
+					p.Generics()
+				}
+				if _, ok := options["mock"]; ok {
+					p.Mock()
+				}
+
+				dir := "."
+				if d, ok := options["output"]; ok {
+					dir = d
+				}
+				if m, ok := options["module"]; ok {
+					p.ModulePath = m
+				} else if p.ModulePath, err = modulePath(dir); err != nil {
+					return err
+				}
+
+				files, err := p.Generate()
+				if err != nil {
+					return err
+				}
+				for name, raw := range files {
+					path := filepath.Join(dir, filepath.FromSlash(name))
+					if err := os.MkdirAll(filepath.Dir(path), outputDirPerm); err != nil {
+						return err
+					}
+					if err := os.WriteFile(path, raw, outputPerm); err != nil {
+						return err
+					}
+				}
+				return nil
 			},
 		),
 	),
@@ -179,28 +310,67 @@ func main() {
 	}
 }
 
-func writeDID(agentName, canisterName string, canisterID *principal.Principal, packageName, outputPath string, rawDID []rune, indirect bool) error {
-	g, err := gen.NewGenerator(agentName, canisterName, packageName, rawDID)
+// modulePath returns the import path of dir, derived from the module path in the
+// nearest go.mod, or an empty string if dir is not part of a module.
+func modulePath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		raw, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			for line := range strings.Lines(string(raw)) {
+				if m, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					rel, err := filepath.Rel(root, abs)
+					if err != nil {
+						return "", err
+					}
+					return path.Join(strings.Trim(strings.TrimSpace(m), `"`), filepath.ToSlash(rel)), nil
+				}
+			}
+			return "", fmt.Errorf("%s: no module declaration", filepath.Join(root, "go.mod"))
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if filepath.Dir(root) == root {
+			return "", nil
+		}
+	}
+}
+
+func writeDID(canisterID *principal.Principal, rawDID []rune, o genOptions) error {
+	g, err := gen.NewGenerator(o.agentName, o.canisterName, o.packageName, rawDID)
 	if err != nil {
 		return err
 	}
-	return writeGenerated(g, canisterID, outputPath, indirect)
+	return writeGenerated(g, canisterID, o)
 }
 
-func writeGenerated(g *gen.Generator, canisterID *principal.Principal, outputPath string, indirect bool) error {
-	if indirect {
+func writeGenerated(g *gen.Generator, canisterID *principal.Principal, o genOptions) error {
+	if o.indirect {
 		g.Indirect()
 	}
+	if o.generics {
+		g.Generics()
+	}
+	if o.mock {
+		g.Mock()
+	}
 	if canisterID != nil {
 		g.WithCanisterID(canisterID)
 	}
+	if o.template != "" {
+		g.WithTemplates(os.DirFS(filepath.Dir(o.template)), filepath.Base(o.template))
+	}
 	raw, err := g.Generate()
 	if err != nil {
 		return err
 	}
 
-	if outputPath != "" {
-		return os.WriteFile(outputPath, raw, outputPerm)
+	if o.output != "" {
+		return os.WriteFile(o.output, raw, outputPerm)
 	}
 	fmt.Println(string(raw))
 	return nil
@@ -211,7 +381,10 @@ type genOptions struct {
 	packageName  string
 	agentName    string
 	output       string
+	template     string
 	indirect     bool
+	generics     bool
+	mock         bool
 }
 
 // parseGenOptions reads the options shared by the generate subcommands.
@@ -222,6 +395,7 @@ func parseGenOptions(canisterName string, options map[string]string) genOptions
 		packageName:  canisterName,
 		agentName:    canisterName,
 		output:       options["output"],
+		template:     options["template"],
 	}
 	if p, ok := options["packageName"]; ok {
 		o.packageName = p
@@ -230,5 +404,7 @@ func parseGenOptions(canisterName string, options map[string]string) genOptions
 		o.agentName = a
 	}
 	_, o.indirect = options["indirect"]
+	_, o.generics = options["generics"]
+	_, o.mock = options["mock"]
 	return o
 }
@@ -1,8 +1,210 @@
 package agent
 
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aviate-labs/agent-go/certification"
+	"github.com/aviate-labs/agent-go/certification/ii"
+	"github.com/aviate-labs/agent-go/identity"
+	"github.com/aviate-labs/agent-go/principal"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// maxDelegations is the maximum length of a delegation chain that is accepted.
+const maxDelegations = 20
+
+// maxIngressExpiry is the maximum time an ingress expiry may lie in the future, five
+// minutes plus the permitted clock drift of the IC.
+const maxIngressExpiry = 6 * time.Minute
+
+// VerifyEnvelope verifies the CBOR encoded envelope of a request, e.g. of a "sign in
+// with IC" flow, and returns the verified request of the authenticated sender. The
+// root key is used to verify canister signatures, see Envelope.Verify.
+//
+// A valid envelope only proves that the sender signed the request, the caller has to
+// check that it is addressed to the expected canister and method, and that it is not
+// replayed, see EnvelopeMiddleware.
+func VerifyEnvelope(envelope []byte, rootKey []byte) (*Request, error) {
+	var e Envelope
+	if err := cbor.Unmarshal(envelope, &e); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if _, err := e.Verify(rootKey, time.Now()); err != nil {
+		return nil, err
+	}
+	return &e.Content, nil
+}
+
+// canisterSigOID is the algorithm identifier of canister signature public keys.
+var canisterSigOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 56387, 1, 2}
+
+// subjectPublicKeyInfo is a DER encoded public key.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// verifySignature verifies the signature of the message by the DER encoded public
+// key, which can be the key of an identity or of a canister signature.
+func verifySignature(publicKey, msg, sig, rootKey []byte) error {
+	var spki subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(publicKey, &spki); err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	} else if len(rest) != 0 {
+		return fmt.Errorf("invalid public key: %d trailing bytes", len(rest))
+	}
+	if spki.Algorithm.Algorithm.Equal(canisterSigOID) {
+		// The key is the length of the canister id, the canister id and the seed.
+		raw := spki.PublicKey.Bytes
+		if len(raw) == 0 || len(raw) < 1+int(raw[0]) {
+			return fmt.Errorf("invalid canister signature public key")
+		}
+		canisterSig := &ii.CanisterSigPublicKey{
+			CanisterID: principal.Principal{Raw: raw[1 : 1+raw[0]]},
+			Seed:       raw[1+raw[0]:],
+		}
+		return ii.VerifyCanisterSig(canisterSig, msg, sig, rootKey)
+	}
+	return identity.VerifySignature(publicKey, msg, sig)
+}
+
+// Delegation delegates the authority of a key to another key, optionally restricted
+// to the given canisters.
+// DOCS: https://internetcomputer.org/docs/references/ic-interface-spec#authentication
+type Delegation struct {
+	// PublicKey is the DER encoded key the authority is delegated to.
+	PublicKey []byte `cbor:"pubkey"`
+	// Expiration in nanoseconds since 1970-01-01.
+	Expiration uint64 `cbor:"expiration"`
+	// Targets are the canisters the delegation is restricted to, if any.
+	Targets []principal.Principal `cbor:"targets,omitempty"`
+}
+
+// SignatureMessage returns the message that is signed by the delegating key.
+func (d Delegation) SignatureMessage() ([]byte, error) {
+	kv := []certification.KeyValuePair{
+		{Key: "pubkey", Value: d.PublicKey},
+		{Key: "expiration", Value: d.Expiration},
+	}
+	if len(d.Targets) != 0 {
+		targets := make([]any, len(d.Targets))
+		for i, t := range d.Targets {
+			targets[i] = t.Raw
+		}
+		kv = append(kv, certification.KeyValuePair{Key: "targets", Value: targets})
+	}
+	hash, err := certification.RepresentationIndependentHash(kv)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("\x1aic-request-auth-delegation"), hash[:]...), nil
+}
+
+// allows reports whether the delegation allows requests to the given canister.
+func (d Delegation) allows(canisterID principal.Principal) bool {
+	if len(d.Targets) == 0 {
+		return true
+	}
+	for _, t := range d.Targets {
+		if t.Equal(canisterID) {
+			return true
+		}
+	}
+	return false
+}
+
 // Envelope is a wrapper for a Request that includes the sender's public key and signature.
 type Envelope struct {
-	Content      Request `cbor:"content,omitempty"`
-	SenderPubKey []byte  `cbor:"sender_pubkey,omitempty"`
-	SenderSig    []byte  `cbor:"sender_sig,omitempty"`
+	Content          Request            `cbor:"content,omitempty"`
+	SenderPubKey     []byte             `cbor:"sender_pubkey,omitempty"`
+	SenderSig        []byte             `cbor:"sender_sig,omitempty"`
+	SenderDelegation []SignedDelegation `cbor:"sender_delegation,omitempty"`
+}
+
+// Verify verifies the envelope at the given time, like the IC verifies requests:
+//   - the ingress expiry lies between now and six minutes from now,
+//   - the sender is the self-authenticating principal of the public key,
+//   - every delegation is signed by the previous key, is not expired and allows
+//     requests to the canister of the request,
+//   - the request id is signed by the last key.
+//
+// Anonymous requests are not signed, their sender is returned as is. The root key
+// is used to verify canister signatures, e.g. of Internet Identity delegations.
+func (e Envelope) Verify(rootKey []byte, now time.Time) (principal.Principal, error) {
+	sender := e.Content.Sender
+	if len(sender.Raw) == 0 {
+		return principal.Principal{}, errors.New("missing sender")
+	}
+	if e.Content.IngressExpiry == 0 {
+		return principal.Principal{}, errors.New("missing ingress expiry")
+	}
+	expiry := time.Unix(0, int64(e.Content.IngressExpiry))
+	if expiry.Before(now) {
+		return principal.Principal{}, fmt.Errorf("request expired at %s", expiry.UTC().Format(time.RFC3339))
+	}
+	if expiry.After(now.Add(maxIngressExpiry)) {
+		return principal.Principal{}, fmt.Errorf("ingress expiry is too far in the future: %s", expiry.UTC().Format(time.RFC3339))
+	}
+
+	if sender.IsAnonymous() {
+		if e.SenderPubKey != nil || e.SenderSig != nil || e.SenderDelegation != nil {
+			return principal.Principal{}, errors.New("anonymous requests must not be signed")
+		}
+		return sender, nil
+	}
+	if len(e.SenderPubKey) == 0 || len(e.SenderSig) == 0 {
+		return principal.Principal{}, errors.New("unsigned request")
+	}
+	if !principal.NewSelfAuthenticating(e.SenderPubKey).Equal(sender) {
+		return principal.Principal{}, errors.New("the sender does not match the public key")
+	}
+	if len(e.SenderDelegation) > maxDelegations {
+		return principal.Principal{}, fmt.Errorf("too many delegations: %d", len(e.SenderDelegation))
+	}
+
+	key := e.SenderPubKey
+	seen := map[string]bool{string(key): true}
+	for i, d := range e.SenderDelegation {
+		msg, err := d.Delegation.SignatureMessage()
+		if err != nil {
+			return principal.Principal{}, err
+		}
+		if err := verifySignature(key, msg, d.Signature, rootKey); err != nil {
+			return principal.Principal{}, fmt.Errorf("delegation %d: %w", i, err)
+		}
+		if time.Unix(0, int64(d.Delegation.Expiration)).Before(now) {
+			return principal.Principal{}, fmt.Errorf("delegation %d expired", i)
+		}
+		if len(d.Delegation.Targets) != 0 {
+			if e.Content.CanisterID.Raw == nil {
+				return principal.Principal{}, fmt.Errorf("delegation %d: targets can not be checked without a canister id", i)
+			}
+			if !d.Delegation.allows(e.Content.CanisterID) {
+				return principal.Principal{}, fmt.Errorf("delegation %d: canister %s is not a target", i, e.Content.CanisterID)
+			}
+		}
+		key = d.Delegation.PublicKey
+		if seen[string(key)] {
+			return principal.Principal{}, fmt.Errorf("delegation %d: cycle in the delegation chain", i)
+		}
+		seen[string(key)] = true
+	}
+
+	requestID := NewRequestID(e.Content)
+	msg := append([]byte("\x0Aic-request"), requestID[:]...)
+	if err := verifySignature(key, msg, e.SenderSig, rootKey); err != nil {
+		return principal.Principal{}, fmt.Errorf("sender signature: %w", err)
+	}
+	return sender, nil
+}
+
+// SignedDelegation is a delegation and the signature of the delegating key.
+type SignedDelegation struct {
+	Delegation Delegation `cbor:"delegation"`
+	Signature  []byte     `cbor:"signature"`
 }
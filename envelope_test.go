@@ -0,0 +1,356 @@
+package agent_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/aviate-labs/agent-go"
+	"github.com/aviate-labs/agent-go/certification"
+	"github.com/aviate-labs/agent-go/certification/bls"
+	"github.com/aviate-labs/agent-go/certification/hashtree"
+	"github.com/aviate-labs/agent-go/certification/ii"
+	"github.com/aviate-labs/agent-go/identity"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+func TestEnvelope_Verify(t *testing.T) {
+	now := time.Now()
+	canisterID := principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai")
+	user, _ := identity.NewRandomEd25519Identity()
+	session, _ := identity.NewRandomSecp256k1Identity()
+
+	newEnvelope := func(id identity.Identity, request agent.Request, delegations ...agent.SignedDelegation) agent.Envelope {
+		request.Sender = user.Sender()
+		requestID := agent.NewRequestID(request)
+		sig, err := requestID.Sign(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return agent.Envelope{
+			Content:          request,
+			SenderPubKey:     user.PublicKey(),
+			SenderSig:        sig,
+			SenderDelegation: delegations,
+		}
+	}
+	delegate := func(d agent.Delegation) agent.SignedDelegation {
+		msg, err := d.SignatureMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := user.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return agent.SignedDelegation{Delegation: d, Signature: sig}
+	}
+	request := agent.Request{
+		Type:          agent.RequestTypeCall,
+		CanisterID:    canisterID,
+		MethodName:    "whoami",
+		Arguments:     []byte("DIDL\x00\x00"),
+		IngressExpiry: uint64(now.Add(5 * time.Minute).UnixNano()),
+	}
+	expiration := uint64(now.Add(time.Hour).UnixNano())
+
+	for _, test := range []struct {
+		name     string
+		envelope agent.Envelope
+		err      bool
+	}{
+		{name: "signed", envelope: newEnvelope(user, request)},
+		{name: "delegation", envelope: newEnvelope(session, request, delegate(agent.Delegation{
+			PublicKey:  session.PublicKey(),
+			Expiration: expiration,
+			Targets:    []principal.Principal{canisterID},
+		}))},
+		{name: "not delegated", envelope: newEnvelope(session, request), err: true},
+		{name: "expired delegation", envelope: newEnvelope(session, request, delegate(agent.Delegation{
+			PublicKey:  session.PublicKey(),
+			Expiration: uint64(now.Add(-time.Minute).UnixNano()),
+		})), err: true},
+		{name: "other target", envelope: newEnvelope(session, request, delegate(agent.Delegation{
+			PublicKey:  session.PublicKey(),
+			Expiration: expiration,
+			Targets:    []principal.Principal{principal.MustDecode("rrkah-fqaaa-aaaaa-aaaaq-cai")},
+		})), err: true},
+		{name: "expired request", envelope: func() agent.Envelope {
+			request := request
+			request.IngressExpiry = uint64(now.Add(-time.Second).UnixNano())
+			return newEnvelope(user, request)
+		}(), err: true},
+		{name: "far expiry", envelope: func() agent.Envelope {
+			request := request
+			request.IngressExpiry = uint64(now.Add(time.Hour).UnixNano())
+			return newEnvelope(user, request)
+		}(), err: true},
+		{name: "tampered", envelope: func() agent.Envelope {
+			e := newEnvelope(user, request)
+			e.Content.MethodName = "transfer"
+			return e
+		}(), err: true},
+		{name: "other sender", envelope: func() agent.Envelope {
+			e := newEnvelope(user, request)
+			e.SenderPubKey = session.PublicKey()
+			return e
+		}(), err: true},
+		{name: "anonymous", envelope: agent.Envelope{Content: agent.Request{
+			Type:          agent.RequestTypeQuery,
+			Sender:        principal.AnonymousID,
+			CanisterID:    canisterID,
+			MethodName:    "whoami",
+			IngressExpiry: request.IngressExpiry,
+		}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := cbor.Marshal(test.envelope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := agent.VerifyEnvelope(raw, nil)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !req.Sender.Equal(test.envelope.Content.Sender) || req.MethodName != "whoami" {
+				t.Errorf("unexpected request: %v", req)
+			}
+		})
+	}
+}
+
+func TestEnvelopeMiddleware(t *testing.T) {
+	id, _ := identity.NewRandomPrime256v1Identity()
+	canisterID := principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai")
+	newEnvelope := func(methodName string, nonce []byte) []byte {
+		request := agent.Request{
+			Type:          agent.RequestTypeCall,
+			Sender:        id.Sender(),
+			CanisterID:    canisterID,
+			MethodName:    methodName,
+			Arguments:     []byte("DIDL\x00\x00"),
+			IngressExpiry: uint64(time.Now().Add(time.Minute).UnixNano()),
+			Nonce:         nonce,
+		}
+		requestID := agent.NewRequestID(request)
+		sig, _ := requestID.Sign(id)
+		body, _ := cbor.Marshal(agent.Envelope{
+			Content:      request,
+			SenderPubKey: id.PublicKey(),
+			SenderSig:    sig,
+		})
+		return body
+	}
+	post := func(url string, body []byte, challenge string) int {
+		r, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		r.Header.Set("X-Challenge", challenge)
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	body := newEnvelope("sign_in", nil)
+	handler, err := agent.EnvelopeMiddleware(agent.EnvelopeConfig{
+		CanisterID: canisterID,
+		MethodName: "sign_in",
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sender, ok := agent.SenderFromContext(r.Context())
+		if !ok || !sender.Equal(id.Sender()) {
+			t.Errorf("unexpected sender: %s", sender)
+		}
+		if request, ok := agent.RequestFromContext(r.Context()); !ok || request.MethodName != "sign_in" {
+			t.Errorf("unexpected request: %v", request)
+		}
+		if raw, _ := io.ReadAll(r.Body); !bytes.Equal(raw, body) {
+			t.Error("body not available")
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	if status := post(server.URL, body, ""); status != http.StatusOK {
+		t.Errorf("unexpected status: %d", status)
+	}
+	if status := post(server.URL, body, ""); status != http.StatusUnauthorized {
+		t.Errorf("replayed request: unexpected status: %d", status)
+	}
+	if status := post(server.URL, newEnvelope("transfer", nil), ""); status != http.StatusUnauthorized {
+		t.Errorf("other method: unexpected status: %d", status)
+	}
+	tampered := newEnvelope("sign_in", nil)
+	tampered[len(tampered)-1] ^= 0x01 // the signature is encoded last
+	if status := post(server.URL, tampered, ""); status != http.StatusUnauthorized {
+		t.Errorf("tampered request: unexpected status: %d", status)
+	}
+
+	handler, err = agent.EnvelopeMiddleware(agent.EnvelopeConfig{
+		CanisterID: canisterID,
+		MethodName: "sign_in",
+		Nonce: func(r *http.Request) []byte {
+			return []byte(r.Header.Get("X-Challenge"))
+		},
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonces := httptest.NewServer(handler)
+	defer nonces.Close()
+
+	if status := post(nonces.URL, newEnvelope("sign_in", []byte("challenge")), "challenge"); status != http.StatusOK {
+		t.Errorf("unexpected status: %d", status)
+	}
+	if status := post(nonces.URL, newEnvelope("sign_in", []byte("challenge")), "other"); status != http.StatusUnauthorized {
+		t.Errorf("other nonce: unexpected status: %d", status)
+	}
+
+	if _, err := agent.EnvelopeMiddleware(agent.EnvelopeConfig{CanisterID: canisterID}, handler); err == nil {
+		t.Error("expected an error for a config without method name")
+	}
+}
+
+func TestVerifyEnvelope_canisterSignature(t *testing.T) {
+	now := time.Now()
+	iiCanisterID := principal.MustDecode("rdmx6-jaaaa-aaaaa-aaadq-cai")
+	canisterID := principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai")
+	rootSecretKey := bls.NewSecretKeyByCSPRNG()
+	rootKey, err := certification.PublicBLSKeyToDER(rootSecretKey.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		user    ii.CanisterSigPublicKey
+		userDER []byte
+	)
+	session, _ := identity.NewRandomEd25519Identity()
+
+	// canisterSign returns the canister signature of the message, a certificate of the
+	// root key that certifies the hash tree that contains the message.
+	canisterSign := func(msg []byte, secretKey *bls.SecretKey) []byte {
+		seed := sha256.Sum256(user.Seed)
+		hash := sha256.Sum256(msg)
+		tree := hashtree.NewHashTree(hashtree.Labeled{
+			Label: hashtree.Label("sig"),
+			Tree: hashtree.Labeled{
+				Label: seed[:],
+				Tree:  hashtree.Labeled{Label: hash[:], Tree: hashtree.Leaf{}},
+			},
+		})
+		certifiedData := tree.Digest()
+		certificateTree := hashtree.NewHashTree(hashtree.Labeled{
+			Label: hashtree.Label("canister"),
+			Tree: hashtree.Labeled{
+				Label: iiCanisterID.Raw,
+				Tree: hashtree.Labeled{
+					Label: hashtree.Label("certified_data"),
+					Tree:  hashtree.Leaf(certifiedData[:]),
+				},
+			},
+		})
+		rootHash := certificateTree.Digest()
+		signature, err := secretKey.Sign(append(hashtree.DomainSeparator("ic-state-root"), rootHash[:]...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		certificate, err := cbor.Marshal(map[string]any{
+			"tree":      certificateTree,
+			"signature": signature.Bytes(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := cbor.Marshal(map[string]any{
+			"certificate": certificate,
+			"tree":        tree,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	newEnvelope := func(secretKey *bls.SecretKey) []byte {
+		delegation := agent.Delegation{
+			PublicKey:  session.PublicKey(),
+			Expiration: uint64(now.Add(time.Hour).UnixNano()),
+		}
+		msg, err := delegation.SignatureMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := agent.Request{
+			Type:          agent.RequestTypeCall,
+			Sender:        principal.NewSelfAuthenticating(userDER),
+			CanisterID:    canisterID,
+			MethodName:    "whoami",
+			Arguments:     []byte("DIDL\x00\x00"),
+			IngressExpiry: uint64(now.Add(5 * time.Minute).UnixNano()),
+		}
+		requestID := agent.NewRequestID(request)
+		sig, err := requestID.Sign(session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := cbor.Marshal(agent.Envelope{
+			Content:      request,
+			SenderPubKey: userDER,
+			SenderSig:    sig,
+			SenderDelegation: []agent.SignedDelegation{{
+				Delegation: delegation,
+				Signature:  canisterSign(msg, secretKey),
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	for _, seed := range [][]byte{
+		[]byte("anchor"),
+		bytes.Repeat([]byte{0x01}, 200), // the DER encoding has a long-form length
+	} {
+		user = ii.CanisterSigPublicKey{CanisterID: iiCanisterID, Seed: seed}
+		userDER, err = asn1.Marshal(struct {
+			Algorithm pkix.AlgorithmIdentifier
+			PublicKey asn1.BitString
+		}{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 56387, 1, 2}},
+			PublicKey: asn1.BitString{Bytes: user.Raw(), BitLength: 8 * len(user.Raw())},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := agent.VerifyEnvelope(newEnvelope(rootSecretKey), rootKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !req.Sender.Equal(principal.NewSelfAuthenticating(userDER)) {
+			t.Errorf("unexpected sender: %s", req.Sender)
+		}
+		if _, err := agent.VerifyEnvelope(newEnvelope(bls.NewSecretKeyByCSPRNG()), rootKey); err == nil {
+			t.Error("expected an error for a certificate of another root key")
+		}
+	}
+	if !bytes.Equal(userDER[:3], []byte{0x30, 0x81, 0xe5}) {
+		t.Errorf("expected a long-form length: %x", userDER[:3])
+	}
+}
@@ -0,0 +1,302 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+var (
+	idlPkgPath = reflect.TypeFor[idl.Nat]().PkgPath()
+	errorType  = reflect.TypeFor[error]()
+	ctxType    = reflect.TypeFor[context.Context]()
+)
+
+// DIDGenerator generates a service description (.did) from Go types, the reverse of
+// Generator. Named Go structs become type definitions, field labels follow the `ic`
+// tags, and the methods of a Go interface become the methods of the service.
+type DIDGenerator struct {
+	// MethodName converts the name of a Go method to the name of the candid method.
+	// Defaults to snake case, e.g. "BalanceOf" becomes "balance_of".
+	MethodName func(name string) string
+	// Annotations are the annotations of the methods, by Go method name.
+	Annotations map[string]did.FuncAnnotation
+
+	definitions []did.Definition
+	names       map[reflect.Type]string
+	ids         map[string]bool
+}
+
+// NewDIDGenerator creates a new generator for service descriptions.
+func NewDIDGenerator() *DIDGenerator {
+	return &DIDGenerator{
+		MethodName:  snakeCase,
+		Annotations: make(map[string]did.FuncAnnotation),
+		names:       make(map[reflect.Type]string),
+		ids:         make(map[string]bool),
+	}
+}
+
+// DataOf returns the data type of the given Go type. Named structs are added to the
+// type definitions of the generator and referenced by name.
+func (g *DIDGenerator) DataOf(t reflect.Type) (did.Data, error) {
+	switch t {
+	case reflect.TypeFor[idl.Nat]():
+		return did.Primitive("nat"), nil
+	case reflect.TypeFor[idl.Int]():
+		return did.Primitive("int"), nil
+	case reflect.TypeFor[idl.Null]():
+		return did.Primitive("null"), nil
+	case reflect.TypeFor[idl.Reserved]():
+		return did.Primitive("reserved"), nil
+	case reflect.TypeFor[idl.Empty]():
+		return did.Primitive("empty"), nil
+	case reflect.TypeFor[principal.Principal]():
+		return did.Principal{}, nil
+	case reflect.TypeFor[[]byte]():
+		return did.Blob{}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return did.Primitive("bool"), nil
+	case reflect.Uint8:
+		return did.Primitive("nat8"), nil
+	case reflect.Uint16:
+		return did.Primitive("nat16"), nil
+	case reflect.Uint32:
+		return did.Primitive("nat32"), nil
+	case reflect.Uint, reflect.Uint64:
+		return did.Primitive("nat64"), nil
+	case reflect.Int8:
+		return did.Primitive("int8"), nil
+	case reflect.Int16:
+		return did.Primitive("int16"), nil
+	case reflect.Int32:
+		return did.Primitive("int32"), nil
+	case reflect.Int, reflect.Int64:
+		return did.Primitive("int64"), nil
+	case reflect.Float32:
+		return did.Primitive("float32"), nil
+	case reflect.Float64:
+		return did.Primitive("float64"), nil
+	case reflect.String:
+		return did.Primitive("text"), nil
+	case reflect.Slice, reflect.Array:
+		elem, err := g.DataOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return did.Vector{Data: elem}, nil
+	case reflect.Pointer:
+		elem, err := g.DataOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return did.Optional{Data: elem}, nil
+	case reflect.Struct:
+		if t.PkgPath() == idlPkgPath {
+			return g.genericDataOf(t)
+		}
+		if t.Name() == "" {
+			return g.structData(t)
+		}
+		if name, ok := g.names[t]; ok {
+			return did.DataId(name), nil
+		}
+		name := g.typeName(t)
+		g.names[t] = name
+		// Reserve the position, so definitions are in the order they are found.
+		idx := len(g.definitions)
+		g.definitions = append(g.definitions, nil)
+		data, err := g.structData(t)
+		if err != nil {
+			return nil, err
+		}
+		g.definitions[idx] = did.Type{Id: name, Data: data}
+		return did.DataId(name), nil
+	default:
+		return nil, fmt.Errorf("unsupported go type: %s", t)
+	}
+}
+
+// Description returns the type definitions that were generated so far.
+func (g *DIDGenerator) Description() did.Description {
+	return did.Description{Definitions: g.definitions}
+}
+
+// Service generates a description with a service of which the methods are the
+// methods of the given Go interface. A leading context.Context argument and a
+// trailing error result are ignored.
+//
+// Example:
+//
+//	type Ledger interface {
+//		BalanceOf(ctx context.Context, account Account) (idl.Nat, error)
+//	}
+//
+//	desc, _ := gen.NewDIDGenerator().Service(reflect.TypeFor[Ledger]())
+func (g *DIDGenerator) Service(iface reflect.Type) (did.Description, error) {
+	if iface.Kind() != reflect.Interface {
+		return did.Description{}, fmt.Errorf("expected an interface, got %s", iface)
+	}
+	var service did.Service
+	for m := range iface.Methods() {
+		f, err := g.function(m.Type)
+		if err != nil {
+			return did.Description{}, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		if ann, ok := g.Annotations[m.Name]; ok {
+			f.Annotation = &ann
+		}
+		service.Methods = append(service.Methods, did.Method{
+			Name: g.MethodName(m.Name),
+			Func: &f,
+		})
+	}
+	desc := g.Description()
+	desc.Services = []did.Service{service}
+	return desc, nil
+}
+
+func (g *DIDGenerator) function(t reflect.Type) (did.Func, error) {
+	if t.IsVariadic() {
+		return did.Func{}, fmt.Errorf("variadic methods are not supported")
+	}
+	var f did.Func
+	for i := range t.NumIn() {
+		in := t.In(i)
+		if i == 0 && in == ctxType {
+			continue
+		}
+		data, err := g.DataOf(in)
+		if err != nil {
+			return did.Func{}, err
+		}
+		f.ArgTypes = append(f.ArgTypes, did.Argument{Data: data})
+	}
+	for i := range t.NumOut() {
+		out := t.Out(i)
+		if i == t.NumOut()-1 && out == errorType {
+			continue
+		}
+		data, err := g.DataOf(out)
+		if err != nil {
+			return did.Func{}, err
+		}
+		f.ResTypes = append(f.ResTypes, did.Argument{Data: data})
+	}
+	return f, nil
+}
+
+// genericDataOf returns the data type of idl.Opt and idl.Result.
+func (g *DIDGenerator) genericDataOf(t reflect.Type) (did.Data, error) {
+	switch name := t.Name(); {
+	case strings.HasPrefix(name, "Opt["):
+		m, _ := t.MethodByName("Ptr")
+		elem, err := g.DataOf(m.Type.Out(0).Elem())
+		if err != nil {
+			return nil, err
+		}
+		return did.Optional{Data: elem}, nil
+	case strings.HasPrefix(name, "Result["):
+		var variant did.Variant
+		for _, arm := range []string{"Ok", "Err"} {
+			m, _ := t.MethodByName(arm)
+			data, err := g.DataOf(m.Type.Out(0))
+			if err != nil {
+				return nil, err
+			}
+			variant = append(variant, field(arm, data))
+		}
+		return variant, nil
+	default:
+		return nil, fmt.Errorf("unsupported go type: %s", t)
+	}
+}
+
+func (g *DIDGenerator) structData(t reflect.Type) (did.Data, error) {
+	var variant, tuple bool
+	for f := range t.Fields() {
+		if !f.IsExported() {
+			continue
+		}
+		tag := idl.ParseTags(f)
+		variant = variant || tag.VariantType
+		tuple = tuple || tag.TupleType
+	}
+	var fields []did.Field
+	for f := range t.Fields() {
+		if !f.IsExported() {
+			continue
+		}
+		tag := idl.ParseTags(f)
+		ft := f.Type
+		if variant {
+			if ft.Kind() != reflect.Pointer {
+				return nil, fmt.Errorf("variant field %q must be a pointer", tag.Name)
+			}
+			ft = ft.Elem()
+		}
+		data, err := g.DataOf(ft)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case variant && data == did.Primitive("null"):
+			name := tag.Name
+			fields = append(fields, did.Field{NameData: &name})
+		case tuple:
+			fields = append(fields, did.Field{Data: &data})
+		default:
+			fields = append(fields, field(tag.Name, data))
+		}
+	}
+	if variant {
+		return did.Variant(fields), nil
+	}
+	return did.Record(fields), nil
+}
+
+// typeName returns a unique candid identifier for the named Go type.
+func (g *DIDGenerator) typeName(t reflect.Type) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, t.Name())
+	unique := name
+	for i := 2; g.ids[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.ids[unique] = true
+	return unique
+}
+
+func field(name string, data did.Data) did.Field {
+	return did.Field{Name: &name, Data: &data}
+}
+
+// snakeCase converts a Go name to snake case, e.g. "Icrc1BalanceOf" becomes
+// "icrc1_balance_of" and "GetHTTPRequest" becomes "get_http_request".
+func snakeCase(name string) string {
+	rs := []rune(name)
+	var s strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) && 0 < i {
+			prev := rs[i-1]
+			next := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				s.WriteRune('_')
+			}
+		}
+		s.WriteRune(unicode.ToLower(r))
+	}
+	return s.String()
+}
@@ -0,0 +1,108 @@
+package gen_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/aviate-labs/agent-go/gen"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+type Account struct {
+	Owner      principal.Principal `ic:"owner"`
+	Subaccount *[]byte             `ic:"subaccount"`
+}
+
+type TransferError struct {
+	InsufficientFunds *struct {
+		Balance idl.Nat `ic:"balance"`
+	} `ic:"InsufficientFunds,variant"`
+	TemporarilyUnavailable *idl.Null `ic:"TemporarilyUnavailable,variant"`
+}
+
+type List struct {
+	Head idl.Nat `ic:"head"`
+	Tail *List   `ic:"tail"`
+}
+
+type Ledger interface {
+	Icrc1BalanceOf(ctx context.Context, account Account) (idl.Nat, error)
+	Icrc1Transfer(to Account, amount idl.Nat) (idl.Result[idl.Nat, TransferError], error)
+	Pair(a struct {
+		A string `ic:"0,tuple"`
+		B uint64 `ic:"1,tuple"`
+	}) (List, error)
+	Notify(memo idl.Opt[uint64])
+}
+
+func ExampleDIDGenerator_Service() {
+	g := gen.NewDIDGenerator()
+	g.Annotations["Icrc1BalanceOf"] = did.AnnQuery
+	g.Annotations["Notify"] = did.AnnOneWay
+	desc, err := g.Service(reflect.TypeFor[Ledger]())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(desc)
+	// Output:
+	// type Account = record {
+	//   owner : principal;
+	//   subaccount : opt blob;
+	// };
+	// type TransferError = variant {
+	//   InsufficientFunds : record {
+	//   balance : nat;
+	// };
+	//   TemporarilyUnavailable;
+	// };
+	// type List = record {
+	//   head : nat;
+	//   tail : opt List;
+	// };
+	// service : {
+	//   icrc1_balance_of : Account -> nat query;
+	//   icrc1_transfer : (Account, nat) -> (variant {
+	//   Ok : nat;
+	//   Err : TransferError;
+	// });
+	//   notify : (opt nat64) -> () oneway;
+	//   pair : (record {
+	//   text;
+	//   nat64;
+	// }) -> List;
+	// }
+}
+
+func TestDIDGenerator_Service(t *testing.T) {
+	desc, err := gen.NewDIDGenerator().Service(reflect.TypeFor[Ledger]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := did.ParseDID([]rune(desc.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parsed.Method("icrc1_transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, err := parsed.Types(f.ResTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := idl.TypeFor[idl.Result[idl.Nat, TransferError]]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts[0].String() != expected.String() {
+		t.Errorf("%s != %s", ts[0], expected)
+	}
+
+	if _, err := gen.NewDIDGenerator().Service(reflect.TypeFor[Account]()); err == nil {
+		t.Error("expected error for non-interface type")
+	}
+}
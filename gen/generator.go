@@ -55,7 +55,7 @@ func init() {
 			continue
 		}
 
-		pt, err := template.ParseFS(files, templatesDir+"/"+tmpl.Name())
+		pt, err := template.ParseFS(files, templatesDir+"/"+tmpl.Name(), templatesDir+"/common/*.gotmpl")
 		if err != nil {
 			panic(err)
 		}
@@ -80,8 +80,21 @@ type Generator struct {
 	PackageName        string
 	ServiceDescription did.Description
 	usedIDL            bool
+	usedReflect        bool
+	usedPrincipal      bool
+
+	// shared are the type definitions that are generated in another package.
+	shared     *sharedTypes
+	usedShared bool
 
 	indirect bool
+	generics bool
+	mock     bool
+
+	naming           Naming
+	funcs            template.FuncMap
+	templateFS       fs.FS
+	templatePatterns []string
 }
 
 // NewGenerator creates a new generator for the given service description.
@@ -120,52 +133,57 @@ func (g *Generator) Generate() ([]byte, error) {
 	for _, definition := range g.ServiceDescription.Definitions {
 		switch definition := definition.(type) {
 		case did.Type:
-			typ := g.dataToString("", definition.Data)
-			definitions = append(definitions, agentArgsDefinition{
-				Name: funcName("", definition.Id),
-				Type: typ,
-				Eq:   !strings.HasPrefix(typ, "struct"),
-			})
-		}
-	}
-
-	var methods []agentArgsMethod
-	for _, service := range g.ServiceDescription.Services {
-		for _, method := range service.Methods {
-			name := rawName(method.Name)
-			f := method.Func
-
-			var argumentTypes []agentArgsMethodArgument
-			for i, t := range f.ArgTypes {
-				var n string
-				if (t.Name != nil) && (*t.Name != "") {
-					n = *t.Name
-				} else {
-					n = fmt.Sprintf("arg%d", i)
+			if g.shared.contains(definition.Id) {
+				continue
+			}
+			name := g.typeName(definition.Id)
+			switch data := definition.Data.(type) {
+			case did.Func:
+				f, err := g.method(name, name, definition.Id, data)
+				if err != nil {
+					return nil, err
 				}
-				argumentTypes = append(argumentTypes, agentArgsMethodArgument{
-					Name: n,
-					Type: g.dataToString("", t.Data),
+				f.Name, f.SkipQueryVerification = name, "false"
+				g.usedIDL, g.usedReflect = true, true
+				definitions = append(definitions, agentArgsDefinition{
+					Name: name,
+					Type: "idl.Function",
+					Func: f,
+				})
+			case did.Service:
+				methods, err := g.methods(name, name, data)
+				if err != nil {
+					return nil, err
+				}
+				g.usedIDL, g.usedReflect = true, true
+				definitions = append(definitions, agentArgsDefinition{
+					Name:    name,
+					Type:    "principal.Principal",
+					Service: &agentArgsService{Methods: methods},
+				})
+			default:
+				typ := g.dataToString("", definition.Data)
+				definitions = append(definitions, agentArgsDefinition{
+					Name: name,
+					Type: typ,
+					Eq:   !strings.HasPrefix(typ, "struct"),
 				})
 			}
+		}
+	}
 
-			var returnTypes []string
-			for _, t := range f.ResTypes {
-				returnTypes = append(returnTypes, g.dataToString("", t.Data))
-			}
-
-			typ := "Call"
-			if f.Annotation != nil && *f.Annotation == did.AnnQuery {
-				typ = "Query"
-			}
-
-			methods = append(methods, agentArgsMethod{
-				RawName:       name,
-				Name:          funcName("", name),
-				Type:          typ,
-				ArgumentTypes: argumentTypes,
-				ReturnTypes:   returnTypes,
-			})
+	var (
+		methods  []agentArgsMethod
+		initArgs []agentArgsMethodArgument
+	)
+	for _, service := range g.ServiceDescription.Services {
+		ms, err := g.methods(g.AgentName, g.CanisterName, service)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, ms...)
+		if initArgs == nil {
+			initArgs = g.arguments(service.InitArgs)
 		}
 	}
 	tmplName := "agent"
@@ -176,42 +194,159 @@ func (g *Generator) Generate() ([]byte, error) {
 	if !ok {
 		return nil, fmt.Errorf("template not found")
 	}
-	var tmpl bytes.Buffer
-	if err := t.Execute(&tmpl, agentArgs{
+	custom := g.templateFS != nil
+	if custom {
+		var err error
+		if t, err = g.customTemplate(); err != nil {
+			return nil, err
+		}
+	}
+	args := agentArgs{
 		AgentName:      g.AgentName,
 		AgentNameUpper: strings.ToUpper(g.AgentName),
 		CanisterName:   g.CanisterName,
 		CanisterID:     g.CanisterID,
 		PackageName:    g.PackageName,
 		UsedIDL:        g.usedIDL,
+		UsedReflect:    g.usedReflect,
+		Mock:           g.mock,
 		Definitions:    definitions,
 		Methods:        methods,
-	}); err != nil {
+		InitArgs:       initArgs,
+
+		ServiceDescription: g.ServiceDescription,
+	}
+	if g.usedShared {
+		args.SharedImport = g.shared.importPath
+	}
+	var tmpl bytes.Buffer
+	if err := t.Execute(&tmpl, args); err != nil {
 		return nil, err
 	}
+	if g.mock && !custom {
+		if err := templates["mock"].Execute(&tmpl, args); err != nil {
+			return nil, err
+		}
+	}
 	return io.ReadAll(&tmpl)
 }
 
+// Generics sets the generator to emit idl.Opt[T] for optional values and
+// idl.Result[T, E] for `variant { Ok : T; Err : E }` instead of pointers and
+// structs of pointers.
+func (g *Generator) Generics() *Generator {
+	g.generics = true
+	return g
+}
+
 // Indirect sets the generator to generate indirect calls.
 func (g *Generator) Indirect() *Generator {
 	g.indirect = true
 	return g
 }
 
+// Mock sets the generator to also emit an interface of the service, implemented by
+// the agent, and an in-memory fake of that interface for tests.
+func (g *Generator) Mock() *Generator {
+	g.mock = true
+	return g
+}
+
 func (g *Generator) WithCanisterID(canisterID *principal.Principal) *Generator {
 	g.CanisterID = canisterID
 	return g
 }
 
+// method returns the template arguments of the method with the given signature of
+// the agent with the given name.
+func (g *Generator) method(agentName, canisterName, name string, f did.Func) (*agentArgsMethod, error) {
+	argumentTypes := g.arguments(f.ArgTypes)
+
+	var returnTypes []string
+	for _, t := range f.ResTypes {
+		returnTypes = append(returnTypes, g.dataToString("", t.Data))
+	}
+
+	typ, requestType := "Call", "Call"
+	var annotation string
+	if f.Annotation != nil {
+		annotation = string(*f.Annotation)
+		switch *f.Annotation {
+		case did.AnnQuery:
+			typ, requestType = "Query", "Query"
+		case did.AnnCompositeQuery:
+			typ, requestType = "CompositeQuery", "Query"
+		case did.AnnOneWay:
+			if len(returnTypes) != 0 {
+				return nil, fmt.Errorf("oneway method %q can not have results", name)
+			}
+			typ = "CallOneway"
+		}
+	}
+
+	return &agentArgsMethod{
+		AgentName:             agentName,
+		CanisterName:          canisterName,
+		RawName:               name,
+		Name:                  g.methodName(name),
+		Type:                  typ,
+		RequestType:           requestType,
+		Annotation:            annotation,
+		ArgumentTypes:         argumentTypes,
+		SkipQueryVerification: "a.skipQueryVerification",
+		ReturnTypes:           returnTypes,
+	}, nil
+}
+
+// arguments returns the template arguments of the given parameters.
+func (g *Generator) arguments(tuple did.Tuple) []agentArgsMethodArgument {
+	var arguments []agentArgsMethodArgument
+	for i, t := range tuple {
+		var n string
+		if (t.Name != nil) && (*t.Name != "") {
+			n = *t.Name
+		} else {
+			n = fmt.Sprintf("arg%d", i)
+		}
+		arguments = append(arguments, agentArgsMethodArgument{
+			Name: n,
+			Type: g.dataToString("", t.Data),
+		})
+	}
+	return arguments
+}
+
+// methods returns the template arguments of the methods of the given service.
+func (g *Generator) methods(agentName, canisterName string, service did.Service) ([]agentArgsMethod, error) {
+	ms, err := g.ServiceDescription.ServiceMethods(service)
+	if err != nil {
+		return nil, err
+	}
+	var methods []agentArgsMethod
+	for _, m := range ms {
+		method, err := g.method(agentName, canisterName, rawName(m.Name), *m.Func)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, *method)
+	}
+	return methods, nil
+}
+
 func (g *Generator) dataToString(prefix string, data did.Data) string {
 	switch t := data.(type) {
 	case did.Blob:
 		return "[]byte"
 	case did.DataId:
-		return funcName(prefix, string(t))
+		return g.typeRef(prefix, string(t))
 	case did.Func:
+		g.usedIDL = true
 		return "idl.Function"
 	case did.Optional:
+		if g.generics {
+			g.usedIDL = true
+			return fmt.Sprintf("idl.Opt[%s]", g.dataToString(prefix, t.Data))
+		}
 		return fmt.Sprintf("*%s", g.dataToString(prefix, t.Data))
 	case did.Primitive:
 		switch t {
@@ -234,6 +369,10 @@ func (g *Generator) dataToString(prefix string, data did.Data) string {
 			panic(fmt.Sprintf("unknown primitive: %s", t))
 		}
 	case did.Principal:
+		g.usedPrincipal = true
+		return "principal.Principal"
+	case did.Service:
+		g.usedPrincipal = true
 		return "principal.Principal"
 	case did.Record:
 		var sizeName int
@@ -249,7 +388,7 @@ func (g *Generator) dataToString(prefix string, data did.Data) string {
 			name := originalName
 			if n := field.Name; n != nil {
 				originalName = *n
-				name = funcName("", *n)
+				name = g.fieldName(*n)
 				// A field labelled with its own positional index (candid
 				// `record { 0 : t; 1 : u }`, the explicit form of a tuple) is
 				// still a tuple field: keep tuple=true and generate Field<i>.
@@ -267,7 +406,7 @@ func (g *Generator) dataToString(prefix string, data did.Data) string {
 			if field.Data != nil {
 				typ = g.dataToString(prefix, *field.Data)
 			} else {
-				typ = funcName(prefix, *field.NameData)
+				typ = g.typeRef(prefix, *field.NameData)
 			}
 			for typ := range strings.SplitSeq(typ, "\n") {
 				if l := len(typ); l > sizeType {
@@ -299,6 +438,12 @@ func (g *Generator) dataToString(prefix string, data did.Data) string {
 		}
 		return fmt.Sprintf("struct {\n%s}", record.String())
 	case did.Variant:
+		if g.generics {
+			if ok, err, isResult := g.resultTypes(prefix, t); isResult {
+				g.usedIDL = true
+				return fmt.Sprintf("idl.Result[%s, %s]", ok, err)
+			}
+		}
 		var sizeName int
 		var sizeType int
 		var records []struct {
@@ -320,9 +465,9 @@ func (g *Generator) dataToString(prefix string, data did.Data) string {
 					originalName string
 					name         string
 					typ          string
-				}{originalName: name, name: funcName("", name), typ: "idl.Null"})
+				}{originalName: name, name: g.fieldName(name), typ: "idl.Null"})
 			} else {
-				name := funcName("", *field.Name)
+				name := g.fieldName(*field.Name)
 				if l := len(name); l > sizeName {
 					sizeName = l
 				}
@@ -330,7 +475,7 @@ func (g *Generator) dataToString(prefix string, data did.Data) string {
 				if field.Data != nil {
 					typ = g.dataToString(prefix, *field.Data)
 				} else {
-					typ = funcName(prefix, *field.NameData)
+					typ = g.typeRef(prefix, *field.NameData)
 				}
 				for typ := range strings.SplitSeq(typ, "\n") {
 					if l := len(typ); l > sizeType {
@@ -360,6 +505,54 @@ func (g *Generator) dataToString(prefix string, data did.Data) string {
 	}
 }
 
+// typeRef returns the Go type that refers to the type definition with the given id.
+func (g *Generator) typeRef(prefix, id string) string {
+	if g.shared.contains(id) {
+		g.usedShared = true
+		return fmt.Sprintf("%s.%s", g.shared.packageName, g.typeName(id))
+	}
+	if prefix != "" {
+		return fmt.Sprintf("%s.%s", prefix, g.typeName(id))
+	}
+	return g.typeName(id)
+}
+
+// resultTypes returns the Go types of the arms if the variant has the shape
+// `variant { Ok : T; Err : E }`. An arm without data is of type idl.Null.
+func (g *Generator) resultTypes(prefix string, v did.Variant) (string, string, bool) {
+	armName := func(field did.Field) string {
+		if field.Name != nil {
+			return *field.Name
+		}
+		if field.NameData != nil {
+			return *field.NameData
+		}
+		return ""
+	}
+	if len(v) != 2 || armName(v[0]) == armName(v[1]) {
+		return "", "", false
+	}
+	for _, field := range v {
+		if name := armName(field); name != "Ok" && name != "Err" {
+			return "", "", false
+		}
+	}
+	types := make(map[string]string)
+	for _, field := range v {
+		name := armName(field)
+		switch {
+		case field.Data != nil:
+			types[name] = g.dataToString(prefix, *field.Data)
+		case field.Name != nil && field.NameData != nil:
+			types[name] = g.typeRef(prefix, *field.NameData)
+		default:
+			g.usedIDL = true
+			types[name] = "idl.Null"
+		}
+	}
+	return types["Ok"], types["Err"], true
+}
+
 type agentArgs struct {
 	AgentName      string
 	AgentNameUpper string
@@ -367,23 +560,48 @@ type agentArgs struct {
 	CanisterID     *principal.Principal
 	PackageName    string
 	UsedIDL        bool
+	UsedReflect    bool
+	Mock           bool
+	UsedPrincipal  bool
+	SharedImport   string
 	Definitions    []agentArgsDefinition
 	Methods        []agentArgsMethod
+	// InitArgs are the arguments of the service class.
+	InitArgs []agentArgsMethodArgument
+
+	// ServiceDescription is the description the agent is generated for, used by
+	// custom templates.
+	ServiceDescription did.Description
 }
 
 type agentArgsDefinition struct {
 	Name string
 	Type string
 	Eq   bool
+	// Func is the signature of a function reference type.
+	Func *agentArgsMethod
+	// Service are the methods of a service reference type.
+	Service *agentArgsService
 }
 
 type agentArgsMethod struct {
-	RawName             string
-	Name                string
-	Type                string
-	ArgumentTypes       []agentArgsMethodArgument
-	FilledArgumentTypes []agentArgsMethodArgument
-	ReturnTypes         []string
+	AgentName    string
+	CanisterName string
+	RawName      string
+	Name         string
+	Type         string
+	RequestType  string
+	Annotation   string
+	// SkipQueryVerification is the expression that disables the verification of
+	// query responses.
+	SkipQueryVerification string
+	ArgumentTypes         []agentArgsMethodArgument
+	FilledArgumentTypes   []agentArgsMethodArgument
+	ReturnTypes           []string
+}
+
+type agentArgsService struct {
+	Methods []agentArgsMethod
 }
 
 type agentArgsMethodArgument struct {
@@ -0,0 +1,49 @@
+package gen_test
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/gen"
+)
+
+func TestGenerator_initArgs(t *testing.T) {
+	g, err := gen.NewGenerator("ledger", "ledger", "ledger", []rune(`type InitArgs = record { owner : principal; fee : opt nat };
+service : (InitArgs) -> {
+	get : () -> (nat) query;
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := typeCheck(t, raw)
+	encode, ok := pkg.Scope().Lookup("EncodeInitArgs").(*types.Func)
+	if !ok {
+		t.Fatalf("EncodeInitArgs is not generated:\n%s", raw)
+	}
+	params := encode.Signature().Params()
+	if params.Len() != 1 || params.At(0).Type() != pkg.Scope().Lookup("InitArgs").Type() {
+		t.Errorf("unexpected signature of EncodeInitArgs: %s", encode.Signature())
+	}
+
+	// Without init args, no helper and no candid import.
+	g, err = gen.NewGenerator("ledger", "ledger", "ledger", []rune(`service : { get : () -> (nat) query }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, err = g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	pkg = typeCheck(t, raw)
+	if pkg.Scope().Lookup("EncodeInitArgs") != nil {
+		t.Error("unexpected EncodeInitArgs")
+	}
+	for _, p := range pkg.Imports() {
+		if p.Path() == "github.com/aviate-labs/agent-go/candid" {
+			t.Error("unexpected candid import")
+		}
+	}
+}
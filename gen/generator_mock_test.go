@@ -0,0 +1,53 @@
+package gen_test
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/gen"
+)
+
+func TestGenerator_Mock(t *testing.T) {
+	for _, indirect := range []bool{false, true} {
+		g, err := gen.NewGenerator("test", "test", "test", []rune(`service : {
+	get : (nat) -> (text) query;
+	set : (nat, text) -> ();
+	notify : () -> () oneway;
+}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if indirect {
+			g.Indirect()
+		}
+		raw, err := g.Mock().Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg := typeCheck(t, raw)
+
+		api := pkg.Scope().Lookup("TestAPI")
+		if api == nil {
+			t.Fatal("missing TestAPI interface")
+		}
+		iface, ok := api.Type().Underlying().(*types.Interface)
+		if !ok {
+			t.Fatal("TestAPI is not an interface")
+		}
+		if n := iface.NumMethods(); n != 6 {
+			t.Errorf("expected 6 interface methods, got %d", n)
+		}
+		fake := pkg.Scope().Lookup("FakeTestAgent")
+		if fake == nil {
+			t.Fatal("missing FakeTestAgent")
+		}
+		for _, typ := range []types.Type{fake.Type(), pkg.Scope().Lookup("TestAgent").Type()} {
+			if !types.Implements(types.NewPointer(typ), iface) {
+				t.Errorf("%s does not implement TestAPI", typ)
+			}
+		}
+		if obj, _, _ := types.LookupFieldOrMethod(fake.Type(), true, pkg, "Calls"); obj == nil {
+			t.Error("FakeTestAgent is missing method Calls")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package gen_test
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/gen"
+)
+
+func TestGenerator_references(t *testing.T) {
+	g, err := gen.NewGenerator("test", "test", "test", []rune(`
+type Callback = func (text) -> (nat) query;
+type Counter = service {
+	inc : () -> (nat);
+	get : () -> (nat) query;
+	callback : Callback;
+};
+service : {
+	subscribe : (Callback) -> ();
+	counter : () -> (Counter) query;
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := typeCheck(t, raw)
+
+	imports := make(map[string]*types.Package)
+	for _, p := range pkg.Imports() {
+		imports[p.Path()] = p
+	}
+	for name, typ := range map[string]struct{ path, name string }{
+		"Callback": {"github.com/aviate-labs/agent-go/candid/idl", "Function"},
+		"Counter":  {"github.com/aviate-labs/agent-go/principal", "Principal"},
+	} {
+		obj, ref := pkg.Scope().Lookup(name), imports[typ.path].Scope().Lookup(typ.name)
+		if obj == nil {
+			t.Errorf("missing %s", name)
+			continue
+		}
+		if !types.Identical(obj.Type().Underlying(), ref.Type().Underlying()) {
+			t.Errorf("expected %s to be a %s.%s, got %s", name, typ.path, typ.name, obj.Type().Underlying())
+		}
+	}
+	if pkg.Scope().Lookup("CounterAgent") == nil {
+		t.Error("missing CounterAgent")
+	}
+	for _, m := range []struct{ typ, method string }{
+		{"Callback", "FunctionType"}, {"Callback", "Invoke"}, {"Callback", "InvokeWithContext"},
+		{"Counter", "ServiceType"}, {"Counter", "Agent"},
+		{"CounterAgent", "Inc"}, {"CounterAgent", "GetWithContext"}, {"CounterAgent", "CallbackRequest"},
+		{"TestAgent", "Subscribe"}, {"TestAgent", "Counter"},
+	} {
+		obj := pkg.Scope().Lookup(m.typ)
+		if obj == nil {
+			t.Errorf("missing %s", m.typ)
+			continue
+		}
+		if f, _, _ := types.LookupFieldOrMethod(obj.Type(), true, pkg, m.method); f == nil {
+			t.Errorf("missing method %s.%s", m.typ, m.method)
+		}
+	}
+}
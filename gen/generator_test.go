@@ -2,6 +2,16 @@ package gen_test
 
 import (
 	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
 
 	"github.com/aviate-labs/agent-go/gen"
 )
@@ -22,6 +32,8 @@ func ExampleNewGenerator() {
 	// package test
 	//
 	// import (
+	//     "context"
+	//
 	//     "github.com/aviate-labs/agent-go"
 	//     "github.com/aviate-labs/agent-go/candid/idl"
 	//     "github.com/aviate-labs/agent-go/principal"
@@ -31,33 +43,63 @@ func ExampleNewGenerator() {
 	// type TestAgent struct {
 	//     *agent.Agent
 	//     CanisterId principal.Principal
+	//
+	//     skipQueryVerification bool
+	// }
+	//
+	// // TestAgentOption configures a TestAgent.
+	// type TestAgentOption func(a *TestAgent)
+	//
+	// // WithTestAgentSkipQueryVerification disables the verification of the signatures of query responses.
+	// func WithTestAgentSkipQueryVerification() TestAgentOption {
+	//     return func(a *TestAgent) {
+	//         a.skipQueryVerification = true
+	//     }
 	// }
 	//
 	// // NewTestAgent creates a new agent for the "test" canister.
-	// func NewTestAgent(canisterId principal.Principal, config agent.Config) (*TestAgent, error) {
+	// func NewTestAgent(canisterId principal.Principal, config agent.Config, options ...TestAgentOption) (*TestAgent, error) {
 	//     a, err := agent.New(config)
 	//     if err != nil {
 	//         return nil, err
 	//     }
-	//     return &TestAgent{
+	//     ca := &TestAgent{
 	//         Agent:      a,
 	//         CanisterId: canisterId,
-	//     }, nil
+	//     }
+	//     for _, o := range options {
+	//         o(ca)
+	//     }
+	//     return ca, nil
 	// }
 	//
 	// // Inc calls the "inc" method on the "test" canister.
 	// func (a TestAgent) Inc() (*idl.Nat, error) {
+	//     return a.IncWithContext(context.Background())
+	// }
+	//
+	// // IncWithContext is like Inc but uses the given context for the request.
+	// func (a TestAgent) IncWithContext(ctx context.Context) (*idl.Nat, error) {
+	//     req, err := a.IncRequest()
+	//     if err != nil {
+	//         return nil, err
+	//     }
 	//     var r0 idl.Nat
-	//     if err := a.Call(
-	//         a.CanisterId,
-	//         "inc",
-	//         []any{},
-	//         []any{&r0},
-	//     ); err != nil {
+	//     if err := req.CallAndWaitWithContext(ctx, []any{&r0}); err != nil {
 	//         return nil, err
 	//     }
 	//     return &r0, nil
 	// }
+	//
+	// // IncRequest creates a request for the "inc" method on the "test" canister,
+	// // e.g. to submit it later or with a different effective canister ID.
+	// func (a TestAgent) IncRequest() (*agent.CandidAPIRequest, error) {
+	//     return a.CreateCandidAPIRequest(
+	//         agent.RequestTypeCall,
+	//         a.CanisterId,
+	//         "inc",
+	//     )
+	// }
 }
 
 func ExampleNewGenerator_indirect() {
@@ -76,6 +118,8 @@ func ExampleNewGenerator_indirect() {
 	// package test
 	//
 	// import (
+	//     "context"
+	//
 	//     "github.com/aviate-labs/agent-go"
 	//     "github.com/aviate-labs/agent-go/candid/idl"
 	//     "github.com/aviate-labs/agent-go/principal"
@@ -85,42 +129,128 @@ func ExampleNewGenerator_indirect() {
 	// type TestAgent struct {
 	//     *agent.Agent
 	//     CanisterId principal.Principal
+	//
+	//     skipQueryVerification bool
+	// }
+	//
+	// // TestAgentOption configures a TestAgent.
+	// type TestAgentOption func(a *TestAgent)
+	//
+	// // WithTestAgentSkipQueryVerification disables the verification of the signatures of query responses.
+	// func WithTestAgentSkipQueryVerification() TestAgentOption {
+	//     return func(a *TestAgent) {
+	//         a.skipQueryVerification = true
+	//     }
 	// }
 	//
 	// // NewTestAgent creates a new agent for the "test" canister.
-	// func NewTestAgent(canisterId principal.Principal, config agent.Config) (*TestAgent, error) {
+	// func NewTestAgent(canisterId principal.Principal, config agent.Config, options ...TestAgentOption) (*TestAgent, error) {
 	//     a, err := agent.New(config)
 	//     if err != nil {
 	//         return nil, err
 	//     }
-	//     return &TestAgent{
+	//     ca := &TestAgent{
 	//         Agent:      a,
 	//         CanisterId: canisterId,
-	//     }, nil
+	//     }
+	//     for _, o := range options {
+	//         o(ca)
+	//     }
+	//     return ca, nil
 	// }
 	//
 	// // Inc calls the "inc" method on the "test" canister.
 	// func (a TestAgent) Inc() (*idl.Nat, error) {
+	//     return a.IncWithContext(context.Background())
+	// }
+	//
+	// // IncWithContext is like Inc but uses the given context for the request.
+	// func (a TestAgent) IncWithContext(ctx context.Context) (*idl.Nat, error) {
+	//     req, err := a.IncRequest()
+	//     if err != nil {
+	//         return nil, err
+	//     }
 	//     var r0 idl.Nat
-	//     if err := a.Call(
-	//         a.CanisterId,
-	//         "inc",
-	//         []any{},
-	//         []any{&r0},
-	//     ); err != nil {
+	//     if err := req.CallAndWaitWithContext(ctx, []any{&r0}); err != nil {
 	//         return nil, err
 	//     }
 	//     return &r0, nil
 	// }
 	//
-	// // IncCall creates an indirect representation of the "inc" method on the "test" canister.
-	// func (a TestAgent) IncCall() (*agent.CandidAPIRequest, error) {
+	// // IncRequest creates a request for the "inc" method on the "test" canister,
+	// // e.g. to submit it later or with a different effective canister ID.
+	// func (a TestAgent) IncRequest() (*agent.CandidAPIRequest, error) {
 	//     return a.CreateCandidAPIRequest(
 	//         agent.RequestTypeCall,
 	//         a.CanisterId,
 	//         "inc",
 	//     )
 	// }
+	//
+	// // IncCall creates an indirect representation of the "inc" method on the "test" canister.
+	// func (a TestAgent) IncCall() (*agent.CandidAPIRequest, error) {
+	//     return a.IncRequest()
+	// }
+}
+
+func ExampleNewGenerator_empty() {
+	g, err := gen.NewGenerator("test", "test", "test", []rune("type t = record { a : nat }; service : {}"))
+	if err != nil {
+		panic(err)
+	}
+	raw, err := g.Generate()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(raw))
+	// Output:
+	// // Package test provides a client for the "test" canister.
+	// // Do NOT edit this file. It was automatically generated by https://github.com/aviate-labs/agent-go.
+	// package test
+	//
+	// import (
+	//     "github.com/aviate-labs/agent-go"
+	//     "github.com/aviate-labs/agent-go/candid/idl"
+	//     "github.com/aviate-labs/agent-go/principal"
+	// )
+	//
+	// type T struct {
+	// 	A idl.Nat `ic:"a" json:"a"`
+	// }
+	//
+	// // TestAgent is a client for the "test" canister.
+	// type TestAgent struct {
+	//     *agent.Agent
+	//     CanisterId principal.Principal
+	//
+	//     skipQueryVerification bool
+	// }
+	//
+	// // TestAgentOption configures a TestAgent.
+	// type TestAgentOption func(a *TestAgent)
+	//
+	// // WithTestAgentSkipQueryVerification disables the verification of the signatures of query responses.
+	// func WithTestAgentSkipQueryVerification() TestAgentOption {
+	//     return func(a *TestAgent) {
+	//         a.skipQueryVerification = true
+	//     }
+	// }
+	//
+	// // NewTestAgent creates a new agent for the "test" canister.
+	// func NewTestAgent(canisterId principal.Principal, config agent.Config, options ...TestAgentOption) (*TestAgent, error) {
+	//     a, err := agent.New(config)
+	//     if err != nil {
+	//         return nil, err
+	//     }
+	//     ca := &TestAgent{
+	//         Agent:      a,
+	//         CanisterId: canisterId,
+	//     }
+	//     for _, o := range options {
+	//         o(ca)
+	//     }
+	//     return ca, nil
+	// }
 }
 
 func ExampleNewGenerator_tags() {
@@ -139,6 +269,8 @@ func ExampleNewGenerator_tags() {
 	// package test
 	//
 	// import (
+	//     "context"
+	//
 	//     "github.com/aviate-labs/agent-go"
 	//     "github.com/aviate-labs/agent-go/candid/idl"
 	//     "github.com/aviate-labs/agent-go/principal"
@@ -156,31 +288,292 @@ func ExampleNewGenerator_tags() {
 	// type TestAgent struct {
 	//     *agent.Agent
 	//     CanisterId principal.Principal
+	//
+	//     skipQueryVerification bool
+	// }
+	//
+	// // TestAgentOption configures a TestAgent.
+	// type TestAgentOption func(a *TestAgent)
+	//
+	// // WithTestAgentSkipQueryVerification disables the verification of the signatures of query responses.
+	// func WithTestAgentSkipQueryVerification() TestAgentOption {
+	//     return func(a *TestAgent) {
+	//         a.skipQueryVerification = true
+	//     }
 	// }
 	//
 	// // NewTestAgent creates a new agent for the "test" canister.
-	// func NewTestAgent(canisterId principal.Principal, config agent.Config) (*TestAgent, error) {
+	// func NewTestAgent(canisterId principal.Principal, config agent.Config, options ...TestAgentOption) (*TestAgent, error) {
 	//     a, err := agent.New(config)
 	//     if err != nil {
 	//         return nil, err
 	//     }
-	//     return &TestAgent{
+	//     ca := &TestAgent{
 	//         Agent:      a,
 	//         CanisterId: canisterId,
-	//     }, nil
+	//     }
+	//     for _, o := range options {
+	//         o(ca)
+	//     }
+	//     return ca, nil
 	// }
 	//
 	// // Test calls the "test" method on the "test" canister.
 	// func (a TestAgent) Test() (*[]Resp, error) {
+	//     return a.TestWithContext(context.Background())
+	// }
+	//
+	// // TestWithContext is like Test but uses the given context for the request.
+	// func (a TestAgent) TestWithContext(ctx context.Context) (*[]Resp, error) {
+	//     req, err := a.TestRequest()
+	//     if err != nil {
+	//         return nil, err
+	//     }
 	//     var r0 []Resp
-	//     if err := a.Call(
+	//     if err := req.CallAndWaitWithContext(ctx, []any{&r0}); err != nil {
+	//         return nil, err
+	//     }
+	//     return &r0, nil
+	// }
+	//
+	// // TestRequest creates a request for the "test" method on the "test" canister,
+	// // e.g. to submit it later or with a different effective canister ID.
+	// func (a TestAgent) TestRequest() (*agent.CandidAPIRequest, error) {
+	//     return a.CreateCandidAPIRequest(
+	//         agent.RequestTypeCall,
 	//         a.CanisterId,
 	//         "test",
-	//         []any{},
-	//         []any{&r0},
-	//     ); err != nil {
+	//     )
+	// }
+}
+
+func ExampleGenerator_Generics() {
+	g, err := gen.NewGenerator("test", "test", "test", []rune("type result = variant { Ok : nat; Err : text }; service : { transfer : (opt nat64) -> (result) }"))
+	if err != nil {
+		panic(err)
+	}
+	raw, err := g.Generics().Generate()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(raw))
+	// Output:
+	// // Package test provides a client for the "test" canister.
+	// // Do NOT edit this file. It was automatically generated by https://github.com/aviate-labs/agent-go.
+	// package test
+	//
+	// import (
+	//     "context"
+	//
+	//     "github.com/aviate-labs/agent-go"
+	//     "github.com/aviate-labs/agent-go/candid/idl"
+	//     "github.com/aviate-labs/agent-go/principal"
+	// )
+	//
+	// type Result = idl.Result[idl.Nat, string]
+	//
+	// // TestAgent is a client for the "test" canister.
+	// type TestAgent struct {
+	//     *agent.Agent
+	//     CanisterId principal.Principal
+	//
+	//     skipQueryVerification bool
+	// }
+	//
+	// // TestAgentOption configures a TestAgent.
+	// type TestAgentOption func(a *TestAgent)
+	//
+	// // WithTestAgentSkipQueryVerification disables the verification of the signatures of query responses.
+	// func WithTestAgentSkipQueryVerification() TestAgentOption {
+	//     return func(a *TestAgent) {
+	//         a.skipQueryVerification = true
+	//     }
+	// }
+	//
+	// // NewTestAgent creates a new agent for the "test" canister.
+	// func NewTestAgent(canisterId principal.Principal, config agent.Config, options ...TestAgentOption) (*TestAgent, error) {
+	//     a, err := agent.New(config)
+	//     if err != nil {
+	//         return nil, err
+	//     }
+	//     ca := &TestAgent{
+	//         Agent:      a,
+	//         CanisterId: canisterId,
+	//     }
+	//     for _, o := range options {
+	//         o(ca)
+	//     }
+	//     return ca, nil
+	// }
+	//
+	// // Transfer calls the "transfer" method on the "test" canister.
+	// func (a TestAgent) Transfer(arg0 idl.Opt[uint64]) (*Result, error) {
+	//     return a.TransferWithContext(context.Background(), arg0)
+	// }
+	//
+	// // TransferWithContext is like Transfer but uses the given context for the request.
+	// func (a TestAgent) TransferWithContext(ctx context.Context, arg0 idl.Opt[uint64]) (*Result, error) {
+	//     req, err := a.TransferRequest(arg0)
+	//     if err != nil {
+	//         return nil, err
+	//     }
+	//     var r0 Result
+	//     if err := req.CallAndWaitWithContext(ctx, []any{&r0}); err != nil {
 	//         return nil, err
 	//     }
 	//     return &r0, nil
 	// }
+	//
+	// // TransferRequest creates a request for the "transfer" method on the "test" canister,
+	// // e.g. to submit it later or with a different effective canister ID.
+	// func (a TestAgent) TransferRequest(arg0 idl.Opt[uint64]) (*agent.CandidAPIRequest, error) {
+	//     return a.CreateCandidAPIRequest(
+	//         agent.RequestTypeCall,
+	//         a.CanisterId,
+	//         "transfer",
+	//         arg0,
+	//     )
+	// }
+}
+
+func ExampleNewGenerator_annotations() {
+	g, err := gen.NewGenerator("test", "test", "test", []rune("service : { name: () -> (text) composite_query; notify: (text) -> () oneway }"))
+	if err != nil {
+		panic(err)
+	}
+	raw, err := g.Generate()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(raw))
+	// Output:
+	// // Package test provides a client for the "test" canister.
+	// // Do NOT edit this file. It was automatically generated by https://github.com/aviate-labs/agent-go.
+	// package test
+	//
+	// import (
+	//     "context"
+	//
+	//     "github.com/aviate-labs/agent-go"
+	//     "github.com/aviate-labs/agent-go/principal"
+	// )
+	//
+	// // TestAgent is a client for the "test" canister.
+	// type TestAgent struct {
+	//     *agent.Agent
+	//     CanisterId principal.Principal
+	//
+	//     skipQueryVerification bool
+	// }
+	//
+	// // TestAgentOption configures a TestAgent.
+	// type TestAgentOption func(a *TestAgent)
+	//
+	// // WithTestAgentSkipQueryVerification disables the verification of the signatures of query responses.
+	// func WithTestAgentSkipQueryVerification() TestAgentOption {
+	//     return func(a *TestAgent) {
+	//         a.skipQueryVerification = true
+	//     }
+	// }
+	//
+	// // NewTestAgent creates a new agent for the "test" canister.
+	// func NewTestAgent(canisterId principal.Principal, config agent.Config, options ...TestAgentOption) (*TestAgent, error) {
+	//     a, err := agent.New(config)
+	//     if err != nil {
+	//         return nil, err
+	//     }
+	//     ca := &TestAgent{
+	//         Agent:      a,
+	//         CanisterId: canisterId,
+	//     }
+	//     for _, o := range options {
+	//         o(ca)
+	//     }
+	//     return ca, nil
+	// }
+	//
+	// // Name calls the "name" method on the "test" canister.
+	// func (a TestAgent) Name() (*string, error) {
+	//     return a.NameWithContext(context.Background())
+	// }
+	//
+	// // NameWithContext is like Name but uses the given context for the request.
+	// func (a TestAgent) NameWithContext(ctx context.Context) (*string, error) {
+	//     req, err := a.NameRequest()
+	//     if err != nil {
+	//         return nil, err
+	//     }
+	//     var r0 string
+	//     if err := req.QueryWithContext(ctx, []any{&r0}, a.skipQueryVerification); err != nil {
+	//         return nil, err
+	//     }
+	//     return &r0, nil
+	// }
+	//
+	// // NameRequest creates a request for the "name" method on the "test" canister,
+	// // e.g. to submit it later or with a different effective canister ID.
+	// func (a TestAgent) NameRequest() (*agent.CandidAPIRequest, error) {
+	//     return a.CreateCandidAPIRequest(
+	//         agent.RequestTypeQuery,
+	//         a.CanisterId,
+	//         "name",
+	//     )
+	// }
+	//
+	// // Notify calls the "notify" method on the "test" canister.
+	// func (a TestAgent) Notify(arg0 string) error {
+	//     return a.NotifyWithContext(context.Background(), arg0)
+	// }
+	//
+	// // NotifyWithContext is like Notify but uses the given context for the request.
+	// func (a TestAgent) NotifyWithContext(ctx context.Context, arg0 string) error {
+	//     req, err := a.NotifyRequest(arg0)
+	//     if err != nil {
+	//         return err
+	//     }
+	//     return req.SendWithContext(ctx)
+	// }
+	//
+	// // NotifyRequest creates a request for the "notify" method on the "test" canister,
+	// // e.g. to submit it later or with a different effective canister ID.
+	// func (a TestAgent) NotifyRequest(arg0 string) (*agent.CandidAPIRequest, error) {
+	//     return a.CreateCandidAPIRequest(
+	//         agent.RequestTypeCall,
+	//         a.CanisterId,
+	//         "notify",
+	//         arg0,
+	//     )
+	// }
+}
+
+// exportImporter loads the packages imported by the generated code from their export data,
+// as built by `go list -export`.
+var exportImporter = struct {
+	fset *token.FileSet
+	types.Importer
+}{fset: token.NewFileSet()}
+
+func init() {
+	exportImporter.Importer = importer.ForCompiler(exportImporter.fset, "gc", func(path string) (io.ReadCloser, error) {
+		out, err := exec.Command("go", "list", "-export", "-f", "{{.Export}}", path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("go list %s: %w", path, err)
+		}
+		return os.Open(strings.TrimSpace(string(out)))
+	})
+}
+
+// typeCheck type-checks the generated source.
+func typeCheck(t *testing.T, raw []byte) *types.Package {
+	t.Helper()
+	f, err := parser.ParseFile(exportImporter.fset, "generated.go", raw, 0)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, raw)
+	}
+	conf := types.Config{Importer: exportImporter}
+	pkg, err := conf.Check(f.Name.Name, exportImporter.fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, raw)
+	}
+	return pkg
 }
@@ -0,0 +1,328 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aviate-labs/agent-go/candid/did"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// Project is a dfx.json project of which every canister with a candid interface is
+// generated into its own package. Type definitions that are shared by several
+// canisters, e.g. the types of a commonly imported .did file, are generated once
+// into a shared package instead of being duplicated in every canister package.
+type Project struct {
+	// Canisters are the canisters of the project, ordered by name.
+	Canisters []ProjectCanister
+	// ModulePath is the import path of the directory the packages are generated in,
+	// used to import the shared package.
+	ModulePath string
+	// SharedPackageName is the name of the package of the shared types.
+	SharedPackageName string
+
+	indirect bool
+	generics bool
+	mock     bool
+}
+
+// LoadProject loads the dfx.json manifest at path. The ids of the canisters on the
+// given network are read from the canister_ids.json next to the manifest or, for
+// local networks, from .dfx/<network>/canister_ids.json. Canisters without a
+// candid interface, e.g. asset canisters, are skipped. Other manifests, e.g. the
+// icp.yaml of icp-cli projects, are not supported.
+func LoadProject(path, network string) (*Project, error) {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return nil, fmt.Errorf("%s: only dfx.json manifests are supported, icp.yaml projects are not", path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Canisters map[string]struct {
+			Candid string `json:"candid"`
+		} `json:"canisters"`
+	}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	ids := make(map[string]string)
+	for _, p := range []string{
+		filepath.Join(dir, "canister_ids.json"),
+		filepath.Join(dir, ".dfx", network, "canister_ids.json"),
+	} {
+		raw, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var networks map[string]map[string]string
+		if err := json.Unmarshal(raw, &networks); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		for name, n := range networks {
+			if id, ok := n[network]; ok {
+				ids[name] = id
+			}
+		}
+	}
+
+	p := &Project{SharedPackageName: "types"}
+	for name, c := range manifest.Canisters {
+		if c.Candid == "" {
+			continue
+		}
+		canister := ProjectCanister{
+			Name:   name,
+			Candid: c.Candid,
+		}
+		if !filepath.IsAbs(canister.Candid) {
+			canister.Candid = filepath.Join(dir, canister.Candid)
+		}
+		if id, ok := ids[name]; ok {
+			canisterID, err := principal.Decode(id)
+			if err != nil {
+				return nil, fmt.Errorf("canister %q: %w", name, err)
+			}
+			canister.ID = &canisterID
+		}
+		p.Canisters = append(p.Canisters, canister)
+	}
+	slices.SortFunc(p.Canisters, func(a, b ProjectCanister) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return p, nil
+}
+
+// Generate generates the packages of the project. It returns the generated files by
+// their slash-separated path, relative to the directory of ModulePath.
+func (p *Project) Generate() (map[string][]byte, error) {
+	var generators []*Generator
+	for _, c := range p.Canisters {
+		agentName := funcName("", strings.ReplaceAll(c.Name, "-", "_"))
+		g, err := NewGeneratorFromFile(agentName, c.Name, strings.ToLower(agentName), c.Candid)
+		if err != nil {
+			return nil, fmt.Errorf("canister %q: %w", c.Name, err)
+		}
+		g.CanisterID = c.ID
+		g.indirect, g.generics, g.mock = p.indirect, p.generics, p.mock
+		generators = append(generators, g)
+	}
+
+	shared := p.sharedDefinitions(generators)
+	files := make(map[string][]byte)
+	if len(shared) != 0 {
+		if p.ModulePath == "" {
+			return nil, fmt.Errorf("a module path is required to import the shared types")
+		}
+		var definitions []did.Definition
+		ids := make(map[string]bool)
+		for _, t := range shared {
+			definitions = append(definitions, t)
+			ids[t.Id] = true
+		}
+		for _, g := range generators {
+			g.shared = &sharedTypes{
+				packageName: p.SharedPackageName,
+				importPath:  path.Join(p.ModulePath, p.SharedPackageName),
+				ids:         ids,
+			}
+		}
+
+		g := &Generator{
+			PackageName:        p.SharedPackageName,
+			ServiceDescription: did.Description{Definitions: definitions},
+			generics:           p.generics,
+		}
+		raw, err := g.generateTypes()
+		if err != nil {
+			return nil, err
+		}
+		files[path.Join(p.SharedPackageName, p.SharedPackageName+".go")] = raw
+	}
+	for _, g := range generators {
+		raw, err := g.Generate()
+		if err != nil {
+			return nil, fmt.Errorf("canister %q: %w", g.CanisterName, err)
+		}
+		files[path.Join(g.PackageName, "agent.go")] = raw
+	}
+	return files, nil
+}
+
+// Generics sets the generator of every canister to use generics, see Generator.Generics.
+func (p *Project) Generics() *Project {
+	p.generics = true
+	return p
+}
+
+// Indirect sets the generator of every canister to generate indirect calls.
+func (p *Project) Indirect() *Project {
+	p.indirect = true
+	return p
+}
+
+// Mock sets the generator of every canister to also generate a fake, see Generator.Mock.
+func (p *Project) Mock() *Project {
+	p.mock = true
+	return p
+}
+
+// sharedDefinitions returns the type definitions that are defined identically by at
+// least two canisters and by every canister that defines them. Function and service
+// references are not shared, since their methods need the agent of the canister.
+func (p *Project) sharedDefinitions(generators []*Generator) []did.Type {
+	var (
+		order   []string
+		defs    = make(map[string]did.Type)
+		count   = make(map[string]int)
+		invalid = make(map[string]bool)
+	)
+	for _, g := range generators {
+		for _, def := range g.ServiceDescription.Definitions {
+			t, ok := def.(did.Type)
+			if !ok {
+				continue
+			}
+			switch t.Data.(type) {
+			case did.Func, did.Service:
+				invalid[t.Id] = true
+			}
+			if e, ok := defs[t.Id]; !ok {
+				defs[t.Id] = t
+				order = append(order, t.Id)
+			} else if e.Data.String() != t.Data.String() {
+				invalid[t.Id] = true
+			}
+			count[t.Id]++
+		}
+	}
+	shared := make(map[string]bool)
+	for _, id := range order {
+		shared[id] = count[id] > 1 && !invalid[id]
+	}
+	// A shared type can only refer to other shared types.
+	for changed := true; changed; {
+		changed = false
+		for _, id := range order {
+			if !shared[id] {
+				continue
+			}
+			for ref := range dataIds(defs[id].Data) {
+				if !shared[ref] {
+					shared[id], changed = false, true
+					break
+				}
+			}
+		}
+	}
+	var types []did.Type
+	for _, id := range order {
+		if shared[id] {
+			types = append(types, defs[id])
+		}
+	}
+	return types
+}
+
+// ProjectCanister is a canister of a Project.
+type ProjectCanister struct {
+	// Name is the name of the canister in the manifest.
+	Name string
+	// Candid is the path of the .did file of the canister.
+	Candid string
+	// ID is the id of the canister on the network, if known.
+	ID *principal.Principal
+}
+
+// generateTypes generates a package with only the type definitions of the service
+// description.
+func (g *Generator) generateTypes() ([]byte, error) {
+	var definitions []agentArgsDefinition
+	for _, definition := range g.ServiceDescription.Definitions {
+		if t, ok := definition.(did.Type); ok {
+			typ := g.dataToString("", t.Data)
+			definitions = append(definitions, agentArgsDefinition{
+				Name: g.typeName(t.Id),
+				Type: typ,
+				Eq:   !strings.HasPrefix(typ, "struct"),
+			})
+		}
+	}
+	var tmpl bytes.Buffer
+	if err := templates["types"].Execute(&tmpl, agentArgs{
+		PackageName:   g.PackageName,
+		UsedIDL:       g.usedIDL,
+		UsedPrincipal: g.usedPrincipal,
+		Definitions:   definitions,
+	}); err != nil {
+		return nil, err
+	}
+	return tmpl.Bytes(), nil
+}
+
+// dataIds returns the ids of the type definitions the Go type of data refers to.
+// Function and service references are opaque, so their signatures are not visited.
+func dataIds(data did.Data) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		var walk func(data did.Data) bool
+		walk = func(data did.Data) bool {
+			switch t := data.(type) {
+			case did.DataId:
+				return yield(string(t))
+			case did.Optional:
+				return walk(t.Data)
+			case did.Vector:
+				return walk(t.Data)
+			case did.Record:
+				for _, f := range t {
+					if f.Data != nil {
+						if !walk(*f.Data) {
+							return false
+						}
+					} else if f.NameData != nil && !yield(*f.NameData) {
+						return false
+					}
+				}
+			case did.Variant:
+				for _, f := range t {
+					if f.Data != nil {
+						if !walk(*f.Data) {
+							return false
+						}
+					} else if f.Name != nil && f.NameData != nil && !yield(*f.NameData) {
+						return false
+					}
+				}
+			}
+			return true
+		}
+		walk(data)
+	}
+}
+
+// sharedTypes are type definitions that are generated in another package.
+type sharedTypes struct {
+	packageName string
+	importPath  string
+	ids         map[string]bool
+}
+
+func (s *sharedTypes) contains(id string) bool {
+	return s != nil && s.ids[id]
+}
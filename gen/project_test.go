@@ -0,0 +1,146 @@
+package gen_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/gen"
+)
+
+func TestProject_Generate(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"dfx.json": `{
+	"canisters": {
+		"ledger": { "type": "custom", "candid": "src/ledger.did" },
+		"my-index": { "type": "custom", "candid": "src/index.did" },
+		"frontend": { "type": "assets" }
+	}
+}`,
+		"canister_ids.json": `{
+	"ledger": { "ic": "ryjl3-tyaaa-aaaaa-aaaba-cai" }
+}`,
+		".dfx/local/canister_ids.json": `{
+	"ledger": { "local": "bkyz2-fmaaa-aaaaa-qaaaq-cai" }
+}`,
+		"src/common.did": `type Account = record { owner : principal; subaccount : opt Subaccount };
+type Subaccount = blob;
+type Tokens = nat;`,
+		"src/ledger.did": `import "common.did";
+type Status = variant { Active; Frozen };
+service : {
+	balance : (Account) -> (Tokens) query;
+	status : () -> (Status) query;
+}`,
+		"src/index.did": `import "common.did";
+type Status = record { height : nat64 };
+service : {
+	accounts : () -> (vec Account) query;
+	status : () -> (Status) query;
+}`,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := gen.LoadProject(filepath.Join(dir, "dfx.json"), "ic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Canisters) != 2 || p.Canisters[0].Name != "ledger" || p.Canisters[1].Name != "my-index" {
+		t.Fatalf("unexpected canisters: %v", p.Canisters)
+	}
+	if id := p.Canisters[0].ID; id == nil || id.String() != "ryjl3-tyaaa-aaaaa-aaaba-cai" {
+		t.Errorf("unexpected canister id: %v", id)
+	}
+	if p.Canisters[1].ID != nil {
+		t.Errorf("unexpected canister id: %v", p.Canisters[1].ID)
+	}
+
+	if _, err := p.Generate(); err == nil {
+		t.Error("expected an error without a module path")
+	}
+	p.ModulePath = "example.com/app/agents"
+	files, err := p.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"ledger/agent.go", "myindex/agent.go", "types/types.go"}) {
+		t.Fatalf("unexpected files: %v", names)
+	}
+
+	parse := func(name string) (*ast.File, map[string]bool) {
+		f, err := parser.ParseFile(token.NewFileSet(), name, files[name], 0)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, files[name])
+		}
+		types := make(map[string]bool)
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok {
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok {
+						types[spec.Name.Name] = true
+					}
+				}
+			}
+		}
+		return f, types
+	}
+
+	if _, types := parse("types/types.go"); len(types) != 3 || !types["Account"] || !types["Subaccount"] || !types["Tokens"] {
+		t.Errorf("unexpected shared types: %v", types)
+	}
+	for _, name := range []string{"ledger/agent.go", "myindex/agent.go"} {
+		f, types := parse(name)
+		if types["Account"] || !types["Status"] {
+			t.Errorf("%s: unexpected types: %v", name, types)
+		}
+		var imported bool
+		for _, spec := range f.Imports {
+			imported = imported || spec.Path.Value == `"example.com/app/agents/types"`
+		}
+		if !imported {
+			t.Errorf("%s: shared types are not imported", name)
+		}
+		if !strings.Contains(string(files[name]), "types.Account") {
+			t.Errorf("%s: shared types are not used", name)
+		}
+	}
+	if !strings.Contains(string(files["ledger/agent.go"]), `principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai")`) {
+		t.Error("canister id is not embedded")
+	}
+
+	local, err := gen.LoadProject(filepath.Join(dir, "dfx.json"), "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := local.Canisters[0].ID; id == nil || id.String() != "bkyz2-fmaaa-aaaaa-qaaaq-cai" {
+		t.Errorf("unexpected canister id: %v", id)
+	}
+}
+
+func TestLoadProject_icp(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "icp.yaml")
+	if err := os.WriteFile(p, []byte("canisters:\n  - name: backend\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.LoadProject(p, "local"); err == nil || !strings.Contains(err.Error(), "icp.yaml projects are not") {
+		t.Errorf("expected an unsupported manifest error, got %v", err)
+	}
+}
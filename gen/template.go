@@ -0,0 +1,99 @@
+package gen
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"text/template"
+)
+
+// Naming converts candid names to Go identifiers. The names are passed without
+// quotes. A nil function keeps the default, which converts snake case to pascal
+// case, e.g. "get_balance" becomes "GetBalance".
+type Naming struct {
+	// Type converts the name of a type definition.
+	Type func(name string) string
+	// Method converts the name of a method.
+	Method func(name string) string
+	// Field converts the label of a record field or a variant arm.
+	Field func(name string) string
+}
+
+// WithFuncs adds functions to the custom templates, see WithTemplates.
+func (g *Generator) WithFuncs(funcs template.FuncMap) *Generator {
+	if g.funcs == nil {
+		g.funcs = make(template.FuncMap)
+	}
+	for name, f := range funcs {
+		g.funcs[name] = f
+	}
+	return g
+}
+
+// WithNaming sets the naming policy of the types, methods and fields.
+func (g *Generator) WithNaming(naming Naming) *Generator {
+	g.naming = naming
+	return g
+}
+
+// WithTemplates sets the generator to execute the template of the first file that
+// matches the patterns instead of the embedded agent template. The files are
+// parsed together with the embedded common templates, so they can use their
+// definitions, e.g. {{ template "method" . }}.
+//
+// Besides the functions of WithFuncs, the templates can use "typeName",
+// "methodName" and "fieldName", which apply the naming policy of the generator.
+// The service description the templates are executed with is available as
+// .ServiceDescription, next to the definitions and methods of the agent.
+func (g *Generator) WithTemplates(fsys fs.FS, patterns ...string) *Generator {
+	g.templateFS = fsys
+	g.templatePatterns = patterns
+	return g
+}
+
+// customTemplate parses the custom templates, see WithTemplates.
+func (g *Generator) customTemplate() (*template.Template, error) {
+	if len(g.templatePatterns) == 0 {
+		return nil, fmt.Errorf("no template patterns")
+	}
+	matches, err := fs.Glob(g.templateFS, g.templatePatterns[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern matches no files: %#q", g.templatePatterns[0])
+	}
+	t, err := template.New(path.Base(matches[0])).
+		Funcs(template.FuncMap{
+			"typeName":   g.typeName,
+			"methodName": g.methodName,
+			"fieldName":  g.fieldName,
+		}).
+		Funcs(g.funcs).
+		ParseFS(files, templatesDir+"/common/*.gotmpl")
+	if err != nil {
+		return nil, err
+	}
+	return t.ParseFS(g.templateFS, g.templatePatterns...)
+}
+
+func (g *Generator) fieldName(name string) string {
+	if g.naming.Field != nil {
+		return g.naming.Field(rawName(name))
+	}
+	return funcName("", name)
+}
+
+func (g *Generator) methodName(name string) string {
+	if g.naming.Method != nil {
+		return g.naming.Method(rawName(name))
+	}
+	return funcName("", name)
+}
+
+func (g *Generator) typeName(name string) string {
+	if g.naming.Type != nil {
+		return g.naming.Type(rawName(name))
+	}
+	return funcName("", name)
+}
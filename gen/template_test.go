@@ -0,0 +1,76 @@
+package gen_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/aviate-labs/agent-go/gen"
+)
+
+func ExampleGenerator_WithTemplates() {
+	g, err := gen.NewGenerator("ledger", "ledger", "ledger", []rune(`type Account = record { owner : principal };
+service : {
+	get_balance : (Account) -> (nat) query;
+	transfer : (Account, nat) -> ();
+}`))
+	if err != nil {
+		panic(err)
+	}
+	raw, err := g.
+		WithFuncs(template.FuncMap{"upper": strings.ToUpper}).
+		WithTemplates(fstest.MapFS{
+			"routes.gotmpl": {Data: []byte(`{{- range .Methods }}
+{{ if eq .RequestType "Query" }}GET{{ else }}POST{{ end }} /{{ upper .RawName }} -> {{ .Name }}
+{{- end }}
+{{- range .ServiceDescription.Definitions }}
+type {{ typeName .Id }}
+{{- end }}
+`)},
+		}, "*.gotmpl").
+		Generate()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(raw))
+	// Output:
+	// GET /GET_BALANCE -> GetBalance
+	// POST /TRANSFER -> Transfer
+	// type Account
+}
+
+func TestGenerator_WithNaming(t *testing.T) {
+	g, err := gen.NewGenerator("ledger", "ledger", "ledger", []rune(`type account = record { owner : principal; sub_account : opt blob };
+type status = variant { active; frozen };
+service : {
+	get_balance : (account) -> (status) query;
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := g.WithNaming(gen.Naming{
+		Type:   func(name string) string { return "T" + strings.ToUpper(name[:1]) + name[1:] },
+		Method: func(name string) string { return "Do_" + name },
+		Field:  func(name string) string { return strings.ToUpper(name) },
+	}).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeCheck(t, raw)
+	for _, s := range []string{
+		"type TAccount struct",
+		"SUB_ACCOUNT *[]byte",
+		"ACTIVE *idl.Null",
+		"func (a LedgerAgent) Do_get_balance(arg0 TAccount) (*TStatus, error)",
+	} {
+		if !strings.Contains(string(raw), s) {
+			t.Errorf("missing %q in:\n%s", s, raw)
+		}
+	}
+
+	if _, err := g.WithTemplates(fstest.MapFS{}, "*.gotmpl").Generate(); err == nil {
+		t.Error("expected an error for a pattern without matches")
+	}
+}
@@ -3,9 +3,11 @@ module github.com/aviate-labs/agent-go
 go 1.26.4
 
 require (
+	filippo.io/edwards25519 v1.1.0
 	github.com/0x51-dev/upeg v0.1.5
 	github.com/consensys/gnark-crypto v0.15.0
 	github.com/fxamacker/cbor/v2 v2.7.0
+	golang.org/x/crypto v0.32.0
 	google.golang.org/protobuf v1.36.3
 )
 
@@ -0,0 +1,163 @@
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/secp256k1"
+)
+
+// HardenedOffset is added to the index of hardened children, written as 44' or 44h.
+const HardenedOffset uint32 = 0x80000000
+
+// ICDerivationPath is the BIP-44 derivation path of the accounts of the Internet
+// Computer (coin type 223), as used by quill and the NNS dapp. The identity of the
+// i-th account is derived at m/44'/223'/0'/0/i.
+const ICDerivationPath = "m/44'/223'/0'/0"
+
+// NewSecp256k1IdentityFromMnemonic creates the identity of the i-th account of a
+// BIP-39 mnemonic, derived at ICDerivationPath/i. The passphrase is optional.
+func NewSecp256k1IdentityFromMnemonic(mnemonic, passphrase string, i uint32) (*Secp256k1Identity, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	master, err := NewHDKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	key, err := master.Derive(fmt.Sprintf("%s/%d", ICDerivationPath, i))
+	if err != nil {
+		return nil, err
+	}
+	return key.Identity()
+}
+
+// HDKey is an extended secp256k1 private key of a BIP-32 hierarchy of keys.
+//
+//	master, _ := identity.NewHDKey(seed)
+//	accounts, _ := master.Derive(identity.ICDerivationPath)
+//	for i := range uint32(10) {
+//		key, _ := accounts.Child(i)
+//		id, _ := key.Identity()
+//	}
+type HDKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+// NewHDKey returns the master key of the given seed, e.g. of a BIP-39 mnemonic.
+func NewHDKey(seed []byte) (*HDKey, error) {
+	if len(seed) < 16 || 64 < len(seed) {
+		return nil, fmt.Errorf("invalid seed length: %d bytes", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(secp256k1Order) >= 0 {
+		return nil, fmt.Errorf("invalid master key")
+	}
+	return &HDKey{key: key, chainCode: sum[32:]}, nil
+}
+
+// ChainCode returns the chain code of the key.
+func (k HDKey) ChainCode() []byte {
+	return k.chainCode
+}
+
+// Child returns the child key with the given index, indexes from HardenedOffset
+// on are hardened. As defined by BIP-32, an error is returned for the (extremely
+// unlikely) indexes that do not result in a valid key, the next index should be
+// used instead.
+func (k HDKey) Child(index uint32) (*HDKey, error) {
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x00}, k.PrivateKey()...)
+	} else {
+		data = k.compressedPublicKey()
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(secp256k1Order) >= 0 {
+		return nil, fmt.Errorf("invalid child key: %d", index)
+	}
+	key := tweak.Add(tweak, k.key)
+	key.Mod(key, secp256k1Order)
+	if key.Sign() == 0 {
+		return nil, fmt.Errorf("invalid child key: %d", index)
+	}
+	return &HDKey{key: key, chainCode: sum[32:]}, nil
+}
+
+// Derive returns the key of the given derivation path, e.g. m/44'/223'/0'/0/0.
+func (k HDKey) Derive(path string) (*HDKey, error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := &k
+	for _, i := range indexes {
+		if key, err = key.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Identity returns the secp256k1 identity of the key.
+func (k HDKey) Identity() (*Secp256k1Identity, error) {
+	privateKey, err := newSecp256k1PrivateKeyFromASN1(ecPrivateKey{
+		PrivateKey: k.PrivateKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewSecp256k1Identity(privateKey)
+}
+
+// PrivateKey returns the 32 byte secp256k1 private key.
+func (k HDKey) PrivateKey() []byte {
+	return k.key.FillBytes(make([]byte, scalarLen))
+}
+
+// compressedPublicKey returns the SEC1 compressed public key, 0x02 or 0x03 || X.
+func (k HDKey) compressedPublicKey() []byte {
+	var p secp256k1.G1Affine
+	p.ScalarMultiplicationBase(k.key)
+	xy := p.RawBytes() // X || Y
+	prefix := byte(0x02) | xy[2*coordLen-1]&1
+	return append([]byte{prefix}, xy[:coordLen]...)
+}
+
+// parseDerivationPath parses a derivation path, e.g. m/44'/223'/0'/0/0, into the
+// indexes of its children.
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path: %q", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		var offset uint32
+		if s, ok := strings.CutSuffix(p, "'"); ok {
+			p, offset = s, HardenedOffset
+		} else if s, ok := strings.CutSuffix(p, "h"); ok {
+			p, offset = s, HardenedOffset
+		}
+		i, err := strconv.ParseUint(p, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path: %q", path)
+		}
+		indexes = append(indexes, uint32(i)+offset)
+	}
+	return indexes, nil
+}
@@ -0,0 +1,133 @@
+package identity
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// Test vector 1 of BIP-32.
+func TestHDKey_Derive(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewHDKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		path       string
+		privateKey string
+		chainCode  string
+	}{
+		{
+			path:       "m",
+			privateKey: "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+			chainCode:  "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508",
+		},
+		{
+			path:       "m/0'",
+			privateKey: "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+			chainCode:  "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141",
+		},
+		{
+			path:       "m/0'/1",
+			privateKey: "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+			chainCode:  "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19",
+		},
+		{
+			path:       "m/0h/1/2h",
+			privateKey: "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+			chainCode:  "04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f",
+		},
+	} {
+		key, err := master.Derive(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key.PrivateKey()) != test.privateKey {
+			t.Errorf("%s: unexpected private key: %x", test.path, key.PrivateKey())
+		}
+		if hex.EncodeToString(key.ChainCode()) != test.chainCode {
+			t.Errorf("%s: unexpected chain code: %x", test.path, key.ChainCode())
+		}
+	}
+	for _, path := range []string{"", "0/1", "m/-1", "m/2147483648", "m/1''"} {
+		if _, err := master.Derive(path); err == nil {
+			t.Errorf("expected an error for %q", path)
+		}
+	}
+}
+
+// The first receiving keys of the test vectors of BIP-84 and BIP-86, of the mnemonic
+// "abandon abandon ... about" without passphrase. The BIP-86 key is the x-only internal
+// key.
+func TestHDKey_Derive_mnemonic(t *testing.T) {
+	seed, err := MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := NewHDKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		path      string
+		publicKey string
+	}{
+		{
+			path:      "m/84'/0'/0'/0/0",
+			publicKey: "0330d54fd0dd420a6e5f8d3624f5f3482cae350f79d5f0753bf5beef9c2d91af3c",
+		},
+		{
+			path:      "m/86'/0'/0'/0/0",
+			publicKey: "cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115",
+		},
+	} {
+		key, err := master.Derive(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey := key.compressedPublicKey()
+		if len(test.publicKey) == 2*coordLen {
+			publicKey = publicKey[1:]
+		}
+		if hex.EncodeToString(publicKey) != test.publicKey {
+			t.Errorf("%s: unexpected public key: %x", test.path, publicKey)
+		}
+	}
+}
+
+func TestNewSecp256k1IdentityFromMnemonic(t *testing.T) {
+	// The derivation itself is covered by the vectors above, the identity of the i-th
+	// account is the key at ICDerivationPath/i.
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	seed, _ := MnemonicToSeed(mnemonic, "")
+	master, _ := NewHDKey(seed)
+	for _, i := range []uint32{0, 1} {
+		id, err := NewSecp256k1IdentityFromMnemonic(mnemonic, "", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := master.Derive(fmt.Sprintf("m/44'/223'/0'/0/%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := key.Identity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !id.Sender().Equal(expected.Sender()) {
+			t.Errorf("%d: unexpected sender: %s", i, id.Sender())
+		}
+		msg := []byte("hello")
+		sig, err := id.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !id.Verify(msg, sig) {
+			t.Error("invalid signature")
+		}
+	}
+	if _, err := NewSecp256k1IdentityFromMnemonic("abandon", "", 0); err == nil {
+		t.Error("expected an error for an invalid mnemonic")
+	}
+}
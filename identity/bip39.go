@@ -0,0 +1,96 @@
+package identity
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	//go:embed bip39_english.txt
+	bip39English string
+	// bip39Words is the English word list of BIP-39.
+	bip39Words = strings.Fields(bip39English)
+	// bip39Index maps the words of the word list to their index.
+	bip39Index = func() map[string]int {
+		index := make(map[string]int, len(bip39Words))
+		for i, w := range bip39Words {
+			index[w] = i
+		}
+		return index
+	}()
+)
+
+// MnemonicFromEntropy returns the BIP-39 mnemonic of the given entropy, which has to
+// be 16, 20, 24, 28 or 32 bytes long.
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	if len(entropy) < 16 || 32 < len(entropy) || len(entropy)%4 != 0 {
+		return "", fmt.Errorf("invalid entropy length: %d bytes", len(entropy))
+	}
+	checksumBits := len(entropy) / 4
+	hash := sha256.Sum256(entropy)
+	// The entropy is followed by the first bits of its hash, each word encodes 11 bits.
+	n := new(big.Int).SetBytes(entropy)
+	n.Lsh(n, uint(checksumBits))
+	n.Or(n, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+	words := make([]string, (len(entropy)*8+checksumBits)/11)
+	mask := big.NewInt(0x7FF)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = bip39Words[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToSeed validates the mnemonic and returns its BIP-39 seed. The passphrase
+// is optional, and is expected to be NFKD normalized.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key(sha512.New, mnemonic, []byte("mnemonic"+passphrase), 2048, 64)
+}
+
+// NewMnemonic returns a new random BIP-39 mnemonic of 12, 15, 18, 21 or 24 words.
+func NewMnemonic(words int) (string, error) {
+	if words < 12 || 24 < words || words%3 != 0 {
+		return "", fmt.Errorf("invalid number of words: %d", words)
+	}
+	entropy := make([]byte, words/3*4)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return MnemonicFromEntropy(entropy)
+}
+
+// ValidateMnemonic checks whether the mnemonic consists of 12, 15, 18, 21 or 24 words
+// of the English BIP-39 word list, and whether its checksum is valid.
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || 24 < len(words) || len(words)%3 != 0 {
+		return fmt.Errorf("invalid number of words: %d", len(words))
+	}
+	n := new(big.Int)
+	for _, w := range words {
+		i, ok := bip39Index[w]
+		if !ok {
+			return fmt.Errorf("invalid word: %q", w)
+		}
+		n.Lsh(n, 11)
+		n.Or(n, big.NewInt(int64(i)))
+	}
+	checksumBits := len(words) / 3
+	checksum := new(big.Int).And(n, big.NewInt(1<<checksumBits-1)).Int64()
+	entropy := n.Rsh(n, uint(checksumBits)).FillBytes(make([]byte, checksumBits*4))
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return fmt.Errorf("invalid mnemonic checksum")
+	}
+	return nil
+}
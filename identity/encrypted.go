@@ -0,0 +1,241 @@
+package identity
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Parameters of scrypt used to encrypt PEM files, the defaults of OpenSSL.
+const (
+	scryptN = 1 << 14
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	pbes2OID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	pbkdf2OID         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	scryptOID         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	hmacWithSHA1OID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	hmacWithSHA256OID = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	aes128CBCOID      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	aes256CBCOID      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// ErrInvalidPassword is returned if an encrypted identity can not be decrypted with
+// the given password.
+var ErrInvalidPassword = errors.New("invalid password")
+
+// EncryptPEM returns the private key of the identity as an encrypted PKCS #8 PEM
+// file ("ENCRYPTED PRIVATE KEY"). The key is encrypted with AES-256-CBC and a key
+// derived from the password with scrypt, like `openssl pkcs8 -topk8 -scrypt`.
+func EncryptPEM(id Identity, password []byte) ([]byte, error) {
+	der, err := marshalPKCS8(id)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(password, salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	data := append(der, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	kdf, err := asn1.Marshal(scryptParams{
+		Salt:                     salt,
+		CostParameter:            scryptN,
+		BlockSize:                scryptR,
+		ParallelizationParameter: scryptP,
+		KeyLength:                32,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rawIV, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  scryptOID,
+			Parameters: asn1.RawValue{FullBytes: kdf},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  aes256CBCOID,
+			Parameters: asn1.RawValue{FullBytes: rawIV},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algo: pkix.AlgorithmIdentifier{
+			Algorithm:  pbes2OID,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedData: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "ENCRYPTED PRIVATE KEY",
+		Bytes: raw,
+	}), nil
+}
+
+// NewIdentityFromEncryptedPEM creates a new identity from an encrypted PKCS #8 PEM
+// file, as returned by EncryptPEM or `openssl pkcs8 -topk8`. The key has to be
+// encrypted with PBES2, using PBKDF2 or scrypt and AES-128-CBC or AES-256-CBC.
+func NewIdentityFromEncryptedPEM(data, password []byte) (Identity, error) {
+	block, remainder := pem.Decode(data)
+	if block == nil || block.Type != "ENCRYPTED PRIVATE KEY" || len(remainder) != 0 {
+		return nil, fmt.Errorf("invalid pem file")
+	}
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algo.Algorithm.Equal(pbes2OID) {
+		return nil, fmt.Errorf("unsupported encryption algorithm: %v", info.Algo.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	var keyLength int
+	switch alg := params.EncryptionScheme.Algorithm; {
+	case alg.Equal(aes128CBCOID):
+		keyLength = 16
+	case alg.Equal(aes256CBCOID):
+		keyLength = 32
+	default:
+		return nil, fmt.Errorf("unsupported encryption scheme: %v", alg)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid initialization vector")
+	}
+	key, err := deriveKey(params.KeyDerivationFunc, password, keyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := info.EncryptedData
+	if len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid encrypted data")
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	der := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(der, encrypted)
+	padding := int(der[len(der)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(der[len(der)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrInvalidPassword
+	}
+	id, err := identityFromPKCS8(der[:len(der)-padding])
+	if err != nil {
+		// The padding can be valid by chance.
+		return nil, errors.Join(ErrInvalidPassword, err)
+	}
+	return id, nil
+}
+
+// deriveKey derives a key of the given length from the password with the PBKDF2 or
+// scrypt key derivation function.
+func deriveKey(kdf pkix.AlgorithmIdentifier, password []byte, keyLength int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(pbkdf2OID):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLength {
+			return nil, fmt.Errorf("invalid key length: %d", params.KeyLength)
+		}
+		var h func() hash.Hash
+		switch prf := params.PRF.Algorithm; {
+		case len(prf) == 0, prf.Equal(hmacWithSHA1OID):
+			h = sha1.New
+		case prf.Equal(hmacWithSHA256OID):
+			h = sha256.New
+		default:
+			return nil, fmt.Errorf("unsupported pseudorandom function: %v", prf)
+		}
+		return pbkdf2.Key(h, string(password), params.Salt, params.IterationCount, keyLength)
+	case kdf.Algorithm.Equal(scryptOID):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLength {
+			return nil, fmt.Errorf("invalid key length: %d", params.KeyLength)
+		}
+		return scrypt.Key(password, params.Salt, params.CostParameter, params.BlockSize, params.ParallelizationParameter, keyLength)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function: %v", kdf.Algorithm)
+	}
+}
+
+// encryptedPrivateKeyInfo is an encrypted PKCS #8 private key, see RFC 5208.
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the parameters of the PBES2 encryption scheme, see RFC 8018.
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the parameters of the PBKDF2 key derivation function, see RFC
+// 8018.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// scryptParams are the parameters of the scrypt key derivation function, see RFC
+// 7914.
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
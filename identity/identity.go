@@ -1,9 +1,19 @@
 package identity
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/aviate-labs/agent-go/principal"
 )
 
+// ContextSigner is implemented by identities of which the signature can take a
+// while to create, e.g. because the key is held by an external signing service.
+type ContextSigner interface {
+	// SignWithContext signs the given message.
+	SignWithContext(ctx context.Context, msg []byte) ([]byte, error)
+}
+
 // Identity is an identity that can sign messages.
 type Identity interface {
 	// Sender returns the principal of the identity.
@@ -17,3 +27,25 @@ type Identity interface {
 	// ToPEM returns the PEM representation of the identity.
 	ToPEM() ([]byte, error)
 }
+
+// SignWithContext signs the given message with the identity. The context is passed
+// on if the identity is a ContextSigner.
+func SignWithContext(ctx context.Context, id Identity, msg []byte) ([]byte, error) {
+	if s, ok := id.(ContextSigner); ok {
+		return s.SignWithContext(ctx, msg)
+	}
+	return id.Sign(msg)
+}
+
+// VerifySignature verifies the signature of the given message by the DER encoded
+// public key of an Ed25519, secp256k1, P-256 or WebAuthn identity.
+func VerifySignature(publicKey, msg, sig []byte) error {
+	id, err := publicIdentity(publicKey)
+	if err != nil {
+		return err
+	}
+	if !id.Verify(msg, sig) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
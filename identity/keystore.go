@@ -0,0 +1,332 @@
+package identity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Parameters of Argon2id used by dfx to derive the key of encrypted identities.
+const (
+	argon2Memory  = 64000 // KiB
+	argon2Time    = 3
+	argon2Threads = 1
+)
+
+const (
+	// rw------- : private keys, only readable by the owner.
+	keystorePerm os.FileMode = 0o600
+	// rwx------ : directories of private keys.
+	keystoreDirPerm os.FileMode = 0o700
+	// rw-r--r-- : the name of the default identity.
+	keystoreConfigPerm os.FileMode = 0o644
+)
+
+// ErrPasswordRequired is returned when an encrypted identity is loaded without a
+// password.
+var ErrPasswordRequired = errors.New("the identity is encrypted, a password is required")
+
+var keystoreName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// decryptDfx decrypts the content of an encrypted dfx identity.
+func decryptDfx(encrypted []byte, config dfxEncryption, password []byte) ([]byte, error) {
+	gcm, err := config.cipher(password)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, len(config.FileNonce))
+	for i, b := range config.FileNonce {
+		nonce[i] = byte(b)
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	plain, err := gcm.Open(nil, nonce, encrypted, nil)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+	return plain, nil
+}
+
+// encryptDfx encrypts the content like dfx encrypts its identities, with
+// AES-256-GCM and a key derived from the password with Argon2id.
+func encryptDfx(plain, password []byte) ([]byte, *dfxEncryption, error) {
+	salt := make([]byte, 16)
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	config := dfxEncryption{
+		PasswordSalt: base64.RawStdEncoding.EncodeToString(salt),
+	}
+	for _, b := range nonce {
+		config.FileNonce = append(config.FileNonce, int(b))
+	}
+	gcm, err := config.cipher(password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gcm.Seal(nil, nonce, plain, nil), &config, nil
+}
+
+// validName reports whether the name can be used as the name of an identity, and
+// thus as the name of a directory.
+func validName(name string) bool {
+	return keystoreName.MatchString(name) && name != "." && name != ".."
+}
+
+// Keystore is a directory of identities with the layout of the identities of dfx,
+// e.g. ~/.config/dfx/identity:
+//
+//	{name}/identity.pem            a plaintext identity
+//	{name}/identity.pem.encrypted  an identity encrypted with a password
+//	{name}/identity.json           the parameters of the encryption
+//
+// The name of the default identity is stored in identity.json next to the
+// directory.
+type Keystore struct {
+	dir string
+}
+
+// NewDfxKeystore returns the keystore of dfx, which is in
+// $DFX_CONFIG_ROOT/.config/dfx/identity or ~/.config/dfx/identity.
+func NewDfxKeystore() (*Keystore, error) {
+	root := os.Getenv("DFX_CONFIG_ROOT")
+	if root == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		root = home
+	}
+	return NewKeystore(filepath.Join(root, ".config", "dfx", "identity")), nil
+}
+
+// NewKeystore returns the keystore of the given directory.
+func NewKeystore(dir string) *Keystore {
+	return &Keystore{dir: dir}
+}
+
+// Default returns the name of the default identity, or an empty string if there
+// is none.
+func (k Keystore) Default() (string, error) {
+	raw, err := os.ReadFile(filepath.Join(filepath.Dir(k.dir), "identity.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var config struct {
+		Default string `json:"default"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return "", err
+	}
+	return config.Default, nil
+}
+
+// Dir returns the directory of the keystore.
+func (k Keystore) Dir() string {
+	return k.dir
+}
+
+// Encrypted reports whether the identity with the given name is encrypted.
+func (k Keystore) Encrypted(name string) (bool, error) {
+	config, err := k.config(name)
+	if err != nil {
+		return false, err
+	}
+	return config.Encryption != nil, nil
+}
+
+// Exists reports whether an identity with the given name exists.
+func (k Keystore) Exists(name string) bool {
+	if !validName(name) {
+		return false
+	}
+	for _, f := range []string{"identity.pem", "identity.pem.encrypted"} {
+		if _, err := os.Stat(filepath.Join(k.dir, name, f)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Load loads the identity with the given name. The password is only used if the
+// identity is encrypted, ErrPasswordRequired is returned if it is nil.
+func (k Keystore) Load(name string, password []byte) (Identity, error) {
+	config, err := k.config(name)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(k.dir, name)
+	if config.Encryption == nil {
+		raw, err := os.ReadFile(filepath.Join(dir, "identity.pem"))
+		if errors.Is(err, fs.ErrNotExist) {
+			if config.KeyringIdentitySuffix != nil {
+				return nil, fmt.Errorf("identity %q is stored in the keyring of the operating system", name)
+			}
+			return nil, fmt.Errorf("identity %q does not exist", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return NewIdentityFromPEM(raw)
+	}
+	if password == nil {
+		return nil, ErrPasswordRequired
+	}
+	encrypted, err := os.ReadFile(filepath.Join(dir, "identity.pem.encrypted"))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := decryptDfx(encrypted, *config.Encryption, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewIdentityFromPEM(raw)
+}
+
+// Names returns the sorted names of the identities.
+func (k Keystore) Names() ([]string, error) {
+	entries, err := os.ReadDir(k.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && k.Exists(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Save stores the identity under the given name, replacing an existing identity
+// with the same name. If the password is not nil, the identity is encrypted like
+// dfx encrypts password protected identities, so that dfx can use it.
+func (k Keystore) Save(name string, id Identity, password []byte) error {
+	if !validName(name) {
+		return fmt.Errorf("invalid identity name: %q", name)
+	}
+	raw, err := id.ToPEM()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(k.dir, name)
+	if err := os.MkdirAll(dir, keystoreDirPerm); err != nil {
+		return err
+	}
+	if password == nil {
+		for _, f := range []string{"identity.pem.encrypted", "identity.json"} {
+			if err := os.Remove(filepath.Join(dir, f)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		return os.WriteFile(filepath.Join(dir, "identity.pem"), raw, keystorePerm)
+	}
+	encrypted, encryption, err := encryptDfx(raw, password)
+	if err != nil {
+		return err
+	}
+	config, err := json.MarshalIndent(dfxIdentityConfig{Encryption: encryption}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "identity.pem.encrypted"), encrypted, keystorePerm); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "identity.json"), config, keystorePerm); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, "identity.pem")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SetDefault sets the name of the default identity.
+func (k Keystore) SetDefault(name string) error {
+	if !k.Exists(name) {
+		return fmt.Errorf("identity %q does not exist", name)
+	}
+	raw, err := json.MarshalIndent(struct {
+		Default string `json:"default"`
+	}{Default: name}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(k.dir)
+	if err := os.MkdirAll(dir, keystoreDirPerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "identity.json"), raw, keystoreConfigPerm)
+}
+
+// config returns the configuration of the identity with the given name, which is
+// empty for plaintext identities.
+func (k Keystore) config(name string) (*dfxIdentityConfig, error) {
+	if !validName(name) {
+		return nil, fmt.Errorf("invalid identity name: %q", name)
+	}
+	var config dfxIdentityConfig
+	raw, err := os.ReadFile(filepath.Join(k.dir, name, "identity.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// dfxEncryption are the parameters of an encrypted dfx identity.
+type dfxEncryption struct {
+	// PasswordSalt is the B64 encoded (base64 without padding) salt of the key
+	// derivation.
+	PasswordSalt string `json:"pw_salt"`
+	// FileNonce is the nonce of AES-256-GCM, serialized as an array of numbers.
+	FileNonce []int `json:"file_nonce"`
+}
+
+// cipher derives the key from the password with Argon2id.
+func (e dfxEncryption) cipher(password []byte) (cipher.AEAD, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(e.PasswordSalt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	key := argon2.IDKey(password, salt, argon2Time, argon2Memory, argon2Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// dfxIdentityConfig is the identity.json of a dfx identity.
+type dfxIdentityConfig struct {
+	Encryption            *dfxEncryption `json:"encryption,omitempty"`
+	KeyringIdentitySuffix *string        `json:"keyring_identity_suffix,omitempty"`
+}
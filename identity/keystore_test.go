@@ -0,0 +1,86 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestKeystore(t *testing.T) {
+	k := NewKeystore(filepath.Join(t.TempDir(), "identity"))
+	alice, _ := NewRandomEd25519Identity()
+	bob, _ := NewRandomSecp256k1Identity()
+	if err := k.Save("alice", alice, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Save("bob", bob, []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Save("../eve", bob, nil); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+
+	// The encrypted identity has the layout of dfx.
+	if _, err := os.Stat(filepath.Join(k.Dir(), "bob", "identity.pem")); err == nil {
+		t.Error("unexpected plaintext identity")
+	}
+	raw, err := os.ReadFile(filepath.Join(k.Dir(), "bob", "identity.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Encryption struct {
+			PasswordSalt string `json:"pw_salt"`
+			FileNonce    []int  `json:"file_nonce"`
+		} `json:"encryption"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Encryption.PasswordSalt == "" || len(config.Encryption.FileNonce) != 12 {
+		t.Errorf("unexpected configuration: %s", raw)
+	}
+
+	if names, err := k.Names(); err != nil || !slices.Equal(names, []string{"alice", "bob"}) {
+		t.Errorf("unexpected names: %v, %v", names, err)
+	}
+	id, err := k.Load("alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(id.PublicKey(), alice.PublicKey()) {
+		t.Error("unexpected public key")
+	}
+	if encrypted, _ := k.Encrypted("bob"); !encrypted {
+		t.Error("expected an encrypted identity")
+	}
+	if _, err := k.Load("bob", nil); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("expected a required password, got %v", err)
+	}
+	if _, err := k.Load("bob", []byte("wrong")); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("expected an invalid password, got %v", err)
+	}
+	if id, err = k.Load("bob", []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(id.PublicKey(), bob.PublicKey()) {
+		t.Error("unexpected public key")
+	}
+	if _, err := k.Load("carol", nil); err == nil {
+		t.Error("expected an error for an unknown identity")
+	}
+
+	if def, err := k.Default(); err != nil || def != "" {
+		t.Errorf("unexpected default identity: %q, %v", def, err)
+	}
+	if err := k.SetDefault("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if def, err := k.Default(); err != nil || def != "bob" {
+		t.Errorf("unexpected default identity: %q, %v", def, err)
+	}
+}
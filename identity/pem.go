@@ -0,0 +1,120 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+)
+
+// identityFromPKCS8 creates an identity from a PKCS #8 encoded private key.
+func identityFromPKCS8(der []byte) (Identity, error) {
+	var key pkcs8
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+	if key.Algo.Algorithm.Equal(ecPublicKeyOID) {
+		var curve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(key.Algo.Parameters.FullBytes, &curve); err != nil {
+			return nil, fmt.Errorf("invalid curve: %w", err)
+		}
+		if isSecp256k1(curve) {
+			// The standard library does not support secp256k1.
+			var raw ecPrivateKey
+			if _, err := asn1.Unmarshal(key.PrivateKey, &raw); err != nil {
+				return nil, err
+			}
+			priv, err := newSecp256k1PrivateKeyFromASN1(raw)
+			if err != nil {
+				return nil, err
+			}
+			return NewSecp256k1Identity(priv)
+		}
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch k := privateKey.(type) {
+	case ed25519.PrivateKey:
+		return NewEd25519Identity(k.Public().(ed25519.PublicKey), k)
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported curve: %s", k.Curve.Params().Name)
+		}
+		return NewPrime256v1Identity(k), nil
+	default:
+		return nil, fmt.Errorf("unknown key type")
+	}
+}
+
+// marshalPKCS8 returns the PKCS #8 encoding of the private key of the identity.
+func marshalPKCS8(id Identity) ([]byte, error) {
+	raw, err := id.ToPEM()
+	if err != nil {
+		return nil, err
+	}
+	for block, rest := pem.Decode(raw); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "PRIVATE KEY":
+			return block.Bytes, nil
+		case "EC PRIVATE KEY":
+			var key ecPrivateKey
+			if _, err := asn1.Unmarshal(block.Bytes, &key); err != nil {
+				return nil, err
+			}
+			curve, err := asn1.Marshal(key.NamedCurveOID)
+			if err != nil {
+				return nil, err
+			}
+			return asn1.Marshal(pkcs8{
+				Algo: pkix.AlgorithmIdentifier{
+					Algorithm:  ecPublicKeyOID,
+					Parameters: asn1.RawValue{FullBytes: curve},
+				},
+				PrivateKey: block.Bytes,
+			})
+		}
+	}
+	return nil, fmt.Errorf("no private key")
+}
+
+// NewIdentityFromPEM creates a new Ed25519, secp256k1 or P-256 identity from the given
+// PEM file, e.g. as returned by ToPEM or exported by `dfx identity export`. Encrypted
+// PEM files are read by NewIdentityFromEncryptedPEM.
+func NewIdentityFromPEM(data []byte) (Identity, error) {
+	block, remainder := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid pem file")
+	}
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		return nil, fmt.Errorf("encrypted pem file, a password is required")
+	case "PRIVATE KEY":
+		if len(remainder) != 0 {
+			return nil, fmt.Errorf("invalid pem file")
+		}
+		return identityFromPKCS8(block.Bytes)
+	}
+	if id, err := NewSecp256k1IdentityFromPEM(data); err == nil {
+		return id, nil
+	}
+	if id, err := NewSecp256k1IdentityFromPEMWithoutParameters(data); err == nil {
+		return id, nil
+	}
+	if id, err := NewPrime256v1IdentityFromPEM(data); err == nil {
+		return id, nil
+	}
+	return nil, fmt.Errorf("unsupported pem file")
+}
+
+// pkcs8 is a PKCS #8 private key, see RFC 5208.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
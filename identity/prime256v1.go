@@ -118,6 +118,9 @@ func (id Prime256v1Identity) ToPEM() ([]byte, error) {
 
 // Verify verifies the signature of the given message.
 func (id Prime256v1Identity) Verify(msg, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
 	r := new(big.Int).SetBytes(sig[:32])
 	s := new(big.Int).SetBytes(sig[32:])
 	hashData := sha256.Sum256(msg)
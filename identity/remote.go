@@ -0,0 +1,243 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	"github.com/aviate-labs/agent-go/principal"
+	secp256k1 "github.com/consensys/gnark-crypto/ecc/secp256k1/ecdsa"
+)
+
+var ed25519OID = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// publicIdentity returns an identity of which only the public key is known, so it
+// can only be used to verify signatures. The public key is DER encoded, and is
+// either an Ed25519, secp256k1 or P-256 key.
+func publicIdentity(der []byte) (Identity, error) {
+	var key ecPublicKey
+	if rest, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("invalid public key: trailing data")
+	}
+	raw := key.PublicKey.Bytes
+	switch {
+	case len(key.Metadata) == 1 && key.Metadata[0].Equal(ed25519OID):
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+		return &Ed25519Identity{publicKey: raw}, nil
+	case len(key.Metadata) == 2 && key.Metadata[0].Equal(ecPublicKeyOID) && isSecp256k1(key.Metadata[1]):
+		if len(raw) != uncompressedPointLen || raw[0] != 0x04 {
+			return nil, fmt.Errorf("expected uncompressed public key (%d bytes, leading 0x04)", uncompressedPointLen)
+		}
+		var publicKey secp256k1.PublicKey
+		if _, err := publicKey.SetBytes(raw[1:]); err != nil {
+			return nil, err
+		}
+		return &Secp256k1Identity{publicKey: &publicKey}, nil
+	case len(key.Metadata) == 2 && key.Metadata[0].Equal(ecPublicKeyOID) && key.Metadata[1].Equal(prime256v1OID):
+		publicKey, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), raw)
+		if err != nil {
+			return nil, err
+		}
+		return &Prime256v1Identity{publicKey: publicKey}, nil
+	case len(key.Metadata) == 1 && key.Metadata[0].Equal(webAuthnOID):
+		publicKey, err := parseCOSEKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &WebAuthnIdentity{publicKey: publicKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key algorithm: %v", key.Metadata)
+	}
+}
+
+// ExecSigner is a signer that runs a command for every request, e.g. a plugin that
+// forwards the request to a hardware wallet. The command reads a JSON encoded
+// SignerRequest from stdin and writes a JSON encoded SignerResponse to stdout, see
+// ServeSigner.
+type ExecSigner struct {
+	// Command is the name or path of the command.
+	Command string
+	// Args are the arguments of the command.
+	Args []string
+}
+
+// PublicKey returns the DER encoded public key of the signer.
+func (s ExecSigner) PublicKey(ctx context.Context) ([]byte, error) {
+	resp, err := s.do(ctx, SignerRequest{Method: SignerMethodPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	return resp.PublicKey, nil
+}
+
+// Sign signs the given message.
+func (s ExecSigner) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	resp, err := s.do(ctx, SignerRequest{Method: SignerMethodSign, Message: msg})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (s ExecSigner) do(ctx context.Context, req SignerRequest) (*SignerResponse, error) {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Command, s.Args...)
+	cmd.Stdin = bytes.NewReader(raw)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() != 0 {
+			return nil, fmt.Errorf("signer %s: %w: %s", s.Command, err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("signer %s: %w", s.Command, err)
+	}
+	return decodeSignerResponse(out)
+}
+
+// HTTPSigner is a signer that posts JSON encoded SignerRequests to a signing
+// service, which replies with a JSON encoded SignerResponse, see NewSignerHandler.
+type HTTPSigner struct {
+	// URL is the endpoint of the signing service.
+	URL string
+	// Client is the client used to send the requests, http.DefaultClient if nil.
+	// Authentication can be added with a custom http.RoundTripper.
+	Client *http.Client
+}
+
+// PublicKey returns the DER encoded public key of the signer.
+func (s HTTPSigner) PublicKey(ctx context.Context) ([]byte, error) {
+	resp, err := s.do(ctx, SignerRequest{Method: SignerMethodPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	return resp.PublicKey, nil
+}
+
+// Sign signs the given message.
+func (s HTTPSigner) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	resp, err := s.do(ctx, SignerRequest{Method: SignerMethodSign, Message: msg})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (s HTTPSigner) do(ctx context.Context, req SignerRequest) (*SignerResponse, error) {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	var resp SignerResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("signer %s: %s", s.URL, httpResp.Status)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("signer %s: %s", s.URL, resp.Error)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signer %s: %s", s.URL, httpResp.Status)
+	}
+	return &resp, nil
+}
+
+// RemoteIdentity is an identity of which the private key is held by a Signer, e.g.
+// a signing service or a hardware wallet. The signer can use Ed25519, secp256k1 or
+// P-256 keys.
+type RemoteIdentity struct {
+	signer Signer
+	// public is used to verify signatures.
+	public Identity
+}
+
+// NewRemoteIdentity creates a new identity that delegates signing to the given
+// signer. The public key is requested once.
+func NewRemoteIdentity(ctx context.Context, signer Signer) (*RemoteIdentity, error) {
+	publicKey, err := signer.PublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	public, err := publicIdentity(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteIdentity{
+		signer: signer,
+		public: public,
+	}, nil
+}
+
+// PublicKey returns the public key of the identity.
+func (id RemoteIdentity) PublicKey() []byte {
+	return id.public.PublicKey()
+}
+
+// Sender returns the principal of the identity.
+func (id RemoteIdentity) Sender() principal.Principal {
+	return principal.NewSelfAuthenticating(id.PublicKey())
+}
+
+// Sign signs the given message, see SignWithContext.
+func (id RemoteIdentity) Sign(msg []byte) ([]byte, error) {
+	return id.SignWithContext(context.Background(), msg)
+}
+
+// SignWithContext signs the given message with the signer. The signature is verified
+// with the public key, so a misbehaving signer is detected before the signature is sent.
+func (id RemoteIdentity) SignWithContext(ctx context.Context, msg []byte) ([]byte, error) {
+	sig, err := id.signer.Sign(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if !id.public.Verify(msg, sig) {
+		return nil, fmt.Errorf("invalid signature of the signer")
+	}
+	return sig, nil
+}
+
+// ToPEM returns an error, the private key is not available.
+func (id RemoteIdentity) ToPEM() ([]byte, error) {
+	return nil, fmt.Errorf("the private key of a remote identity is not available")
+}
+
+// Verify verifies the signature of the given message.
+func (id RemoteIdentity) Verify(msg, sig []byte) bool {
+	return id.public.Verify(msg, sig)
+}
+
+// Signer creates signatures for a RemoteIdentity.
+type Signer interface {
+	// PublicKey returns the DER encoded public key of the signer.
+	PublicKey(ctx context.Context) ([]byte, error)
+	// Sign signs the given message, like the Sign method of the identity with the
+	// same key.
+	Sign(ctx context.Context, msg []byte) ([]byte, error)
+}
@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestMain runs the test binary as the command of an ExecSigner if requested, see
+// TestRemoteIdentity_exec.
+func TestMain(m *testing.M) {
+	if pem := os.Getenv("TEST_SIGNER_PEM"); pem != "" {
+		id, err := NewEd25519IdentityFromPEM([]byte(pem))
+		if err != nil {
+			panic(err)
+		}
+		if err := ServeSigner(id, os.Stdin, os.Stdout); err != nil {
+			panic(err)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRemoteIdentity(t *testing.T) {
+	ed25519ID, _ := NewRandomEd25519Identity()
+	secp256k1ID, _ := NewRandomSecp256k1Identity()
+	prime256v1ID, _ := NewRandomPrime256v1Identity()
+	for _, id := range []Identity{ed25519ID, secp256k1ID, prime256v1ID} {
+		server := httptest.NewServer(NewSignerHandler(id))
+		remote, err := NewRemoteIdentity(context.Background(), HTTPSigner{URL: server.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(remote.PublicKey(), id.PublicKey()) || !remote.Sender().Equal(id.Sender()) {
+			t.Errorf("%T: unexpected public key", id)
+		}
+		msg := []byte("hello")
+		sig, err := SignWithContext(context.Background(), remote, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !remote.Verify(msg, sig) || !id.Verify(msg, sig) {
+			t.Errorf("%T: invalid signature", id)
+		}
+		if remote.Verify([]byte("world"), sig) {
+			t.Errorf("%T: unexpected valid signature", id)
+		}
+		server.Close()
+	}
+}
+
+func TestRemoteIdentity_exec(t *testing.T) {
+	id, _ := NewRandomEd25519Identity()
+	pem, err := id.ToPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SIGNER_PEM", string(pem))
+	remote, err := NewRemoteIdentity(context.Background(), ExecSigner{Command: os.Args[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !remote.Sender().Equal(id.Sender()) {
+		t.Error("unexpected sender")
+	}
+	sig, err := remote.Sign([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.Verify([]byte("hello"), sig) {
+		t.Error("invalid signature")
+	}
+	if _, err := remote.ToPEM(); err == nil {
+		t.Error("expected an error, the private key is not available")
+	}
+}
+
+func TestRemoteIdentity_invalidSignature(t *testing.T) {
+	id, _ := NewRandomEd25519Identity()
+	other, _ := NewRandomEd25519Identity()
+	remote, err := NewRemoteIdentity(context.Background(), testSigner{public: id, private: other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Sign([]byte("hello")); err == nil {
+		t.Error("expected an error for a signature of another key")
+	}
+}
+
+// testSigner returns the public key of public, but signs with private.
+type testSigner struct {
+	public, private Identity
+}
+
+func (s testSigner) PublicKey(context.Context) ([]byte, error) {
+	return s.public.PublicKey(), nil
+}
+
+func (s testSigner) Sign(_ context.Context, msg []byte) ([]byte, error) {
+	return s.private.Sign(msg)
+}
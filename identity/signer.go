@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// SignerMethodPublicKey requests the DER encoded public key of the signer.
+	SignerMethodPublicKey = "public_key"
+	// SignerMethodSign requests the signature of a message.
+	SignerMethodSign = "sign"
+)
+
+// NewSignerHandler returns a reference implementation of a signing service, which
+// signs the requests of an HTTPSigner with the given identity. It should only be
+// exposed locally, e.g. in tests, as it does not authenticate its clients.
+func NewSignerHandler(id Identity) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_ = json.NewEncoder(w).Encode(SignerResponse{Error: "method not allowed"})
+			return
+		}
+		var req SignerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(SignerResponse{Error: err.Error()})
+			return
+		}
+		resp := handleSignerRequest(id, req)
+		if resp.Error != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// ServeSigner is a reference implementation of a command for an ExecSigner. It
+// reads a request from r, signs it with the given identity and writes the response
+// to w. Errors of the request are written to the response.
+func ServeSigner(id Identity, r io.Reader, w io.Writer) error {
+	var req SignerRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(handleSignerRequest(id, req))
+}
+
+// decodeSignerResponse decodes the response of a signer, and returns its error.
+func decodeSignerResponse(raw []byte) (*SignerResponse, error) {
+	var resp SignerResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("invalid signer response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("signer: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+func handleSignerRequest(id Identity, req SignerRequest) SignerResponse {
+	switch req.Method {
+	case SignerMethodPublicKey:
+		return SignerResponse{PublicKey: id.PublicKey()}
+	case SignerMethodSign:
+		sig, err := id.Sign(req.Message)
+		if err != nil {
+			return SignerResponse{Error: err.Error()}
+		}
+		return SignerResponse{Signature: sig}
+	default:
+		return SignerResponse{Error: fmt.Sprintf("unknown method: %q", req.Method)}
+	}
+}
+
+// SignerRequest is a request of the signing protocol used by HTTPSigner and
+// ExecSigner. Byte slices are base64 encoded.
+//
+//	{"method": "public_key"}
+//	{"method": "sign", "message": "CmljLXJlcXVlc3Q..."}
+type SignerRequest struct {
+	// Method is either SignerMethodPublicKey or SignerMethodSign.
+	Method string `json:"method"`
+	// Message is the message to sign.
+	Message []byte `json:"message,omitempty"`
+}
+
+// SignerResponse is a response of the signing protocol, see SignerRequest.
+//
+//	{"public_key": "MCowBQYDK2VwAyEA..."}
+//	{"signature": "3q2+7w..."}
+//	{"error": "unknown method: \"foo\""}
+type SignerResponse struct {
+	// PublicKey is the DER encoded public key, as returned by Identity.PublicKey.
+	PublicKey []byte `json:"public_key,omitempty"`
+	// Signature is the signature of the message, as returned by Identity.Sign.
+	Signature []byte `json:"signature,omitempty"`
+	// Error describes why the request failed.
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,286 @@
+package identity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+
+	"github.com/aviate-labs/agent-go/principal"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE parameters of ECDSA P-256 keys with SHA-256 (ES256), see RFC 9053.
+const (
+	coseKeyTypeEC2 = 2
+	coseAlgES256   = -7
+	coseCurveP256  = 1
+)
+
+// webAuthnOID identifies DER encoded COSE keys of WebAuthn identities.
+var webAuthnOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 56387, 1, 1}
+
+// webAuthnFlags are the flags of the authenticator data: user present and user
+// verified.
+const webAuthnFlags = 0x01 | 0x04
+
+// cborSelfDescribeTag marks the signature as CBOR.
+const cborSelfDescribeTag = 55799
+
+var ctap2 = func() cbor.EncMode {
+	em, err := cbor.CTAP2EncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return em
+}()
+
+func derEncodeWebAuthnPublicKey(key *ecdsa.PublicKey) ([]byte, error) {
+	pub, err := key.Bytes() // 0x04 || X || Y
+	if err != nil {
+		return nil, err
+	}
+	cose, err := ctap2.Marshal(coseKey{
+		KeyType:   coseKeyTypeEC2,
+		Algorithm: coseAlgES256,
+		Curve:     coseCurveP256,
+		X:         pub[1:33],
+		Y:         pub[33:],
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm: webAuthnOID,
+		},
+		PublicKey: asn1.BitString{
+			BitLength: len(cose) * 8,
+			Bytes:     cose,
+		},
+	})
+}
+
+// parseCOSEKey parses a COSE encoded ES256 public key.
+func parseCOSEKey(raw []byte) (*ecdsa.PublicKey, error) {
+	var key coseKey
+	if err := cbor.Unmarshal(raw, &key); err != nil {
+		return nil, err
+	}
+	if key.KeyType != coseKeyTypeEC2 || key.Algorithm != coseAlgES256 || key.Curve != coseCurveP256 {
+		return nil, fmt.Errorf("unsupported COSE key: only ES256 (P-256) is supported")
+	}
+	if len(key.X) != 32 || len(key.Y) != 32 {
+		return nil, fmt.Errorf("invalid COSE key")
+	}
+	return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{0x04}, key.X...), key.Y...))
+}
+
+// VerifyWebAuthnSignature verifies a WebAuthn signature of the given message, e.g.
+// of the sender of a request envelope. The public key is the DER encoded COSE key,
+// and the challenge of the client data has to be the message.
+func VerifyWebAuthnSignature(publicKey, msg, sig []byte) error {
+	var key ecPublicKey
+	if _, err := asn1.Unmarshal(publicKey, &key); err != nil {
+		return err
+	}
+	if len(key.Metadata) != 1 || !key.Metadata[0].Equal(webAuthnOID) {
+		return fmt.Errorf("not a WebAuthn public key")
+	}
+	pub, err := parseCOSEKey(key.PublicKey.Bytes)
+	if err != nil {
+		return err
+	}
+	return verifyWebAuthn(pub, msg, sig)
+}
+
+func verifyWebAuthn(publicKey *ecdsa.PublicKey, msg, raw []byte) error {
+	var sig webAuthnSignature
+	if err := cbor.Unmarshal(raw, &sig); err != nil {
+		return fmt.Errorf("invalid WebAuthn signature: %w", err)
+	}
+	var clientData webAuthnClientData
+	if err := json.Unmarshal([]byte(sig.ClientDataJSON), &clientData); err != nil {
+		return fmt.Errorf("invalid client data: %w", err)
+	}
+	if clientData.Type != "webauthn.get" {
+		return fmt.Errorf("invalid client data type: %q", clientData.Type)
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(clientData.Challenge)
+	if err != nil {
+		// Some clients pad the challenge.
+		if challenge, err = base64.URLEncoding.DecodeString(clientData.Challenge); err != nil {
+			return fmt.Errorf("invalid challenge: %w", err)
+		}
+	}
+	if !bytes.Equal(challenge, msg) {
+		return fmt.Errorf("the challenge does not match the message")
+	}
+	hash := webAuthnHash(sig.AuthenticatorData, sig.ClientDataJSON)
+	if !ecdsa.VerifyASN1(publicKey, hash[:], sig.Signature) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// webAuthnHash returns the hash that is signed by the authenticator, the hash of the
+// authenticator data and the hash of the client data.
+func webAuthnHash(authenticatorData []byte, clientDataJSON string) [32]byte {
+	clientDataHash := sha256.Sum256([]byte(clientDataJSON))
+	return sha256.Sum256(append(bytes.Clone(authenticatorData), clientDataHash[:]...))
+}
+
+// WebAuthnIdentity is an identity that signs like a WebAuthn authenticator, e.g. a
+// security key or passkey of an Internet Identity user. The authenticator is
+// implemented in software, with a P-256 key.
+type WebAuthnIdentity struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  *ecdsa.PublicKey
+	origin     string
+	rpID       string
+}
+
+// NewRandomWebAuthnIdentity creates a new identity with a random P-256 key, see
+// NewWebAuthnIdentity.
+func NewRandomWebAuthnIdentity(origin string) (*WebAuthnIdentity, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewWebAuthnIdentity(privateKey, origin)
+}
+
+// NewWebAuthnIdentity creates a new identity based on the given P-256 key. The
+// origin is the origin of the client data, e.g. https://identity.ic0.app, of which
+// the host is the relying party ID.
+func NewWebAuthnIdentity(privateKey *ecdsa.PrivateKey, origin string) (*WebAuthnIdentity, error) {
+	if privateKey.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported curve: %s", privateKey.Curve.Params().Name)
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return nil, err
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid origin: %q", origin)
+	}
+	return &WebAuthnIdentity{
+		privateKey: privateKey,
+		publicKey:  &privateKey.PublicKey,
+		origin:     origin,
+		rpID:       u.Hostname(),
+	}, nil
+}
+
+// NewWebAuthnIdentityFromPEM creates a new identity from the given PEM file of a
+// P-256 key, see NewWebAuthnIdentity.
+func NewWebAuthnIdentityFromPEM(data []byte, origin string) (*WebAuthnIdentity, error) {
+	block, remainder := pem.Decode(data)
+	if block == nil || block.Type != "EC PRIVATE KEY" || len(remainder) != 0 {
+		return nil, fmt.Errorf("invalid pem file")
+	}
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewWebAuthnIdentity(privateKey, origin)
+}
+
+// PublicKey returns the DER encoded COSE key of the identity.
+func (id WebAuthnIdentity) PublicKey() []byte {
+	der, _ := derEncodeWebAuthnPublicKey(id.publicKey)
+	return der
+}
+
+// Sender returns the principal of the identity.
+func (id WebAuthnIdentity) Sender() principal.Principal {
+	return principal.NewSelfAuthenticating(id.PublicKey())
+}
+
+// Sign signs the given message like a WebAuthn authenticator. The message is the
+// challenge of the client data, and the signature is the CBOR encoded authenticator
+// data, client data and DER encoded ECDSA signature.
+func (id WebAuthnIdentity) Sign(msg []byte) ([]byte, error) {
+	if id.privateKey == nil {
+		return nil, fmt.Errorf("the private key is not available")
+	}
+	clientData, err := json.Marshal(webAuthnClientData{
+		Type:      "webauthn.get",
+		Challenge: base64.RawURLEncoding.EncodeToString(msg),
+		Origin:    id.origin,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rpIDHash := sha256.Sum256([]byte(id.rpID))
+	// The signature counter is always zero, like for most passkeys.
+	authenticatorData := append(rpIDHash[:], webAuthnFlags, 0, 0, 0, 0)
+	hash := webAuthnHash(authenticatorData, string(clientData))
+	sig, err := ecdsa.SignASN1(rand.Reader, id.privateKey, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(cbor.Tag{
+		Number: cborSelfDescribeTag,
+		Content: webAuthnSignature{
+			AuthenticatorData: authenticatorData,
+			ClientDataJSON:    string(clientData),
+			Signature:         sig,
+		},
+	})
+}
+
+// ToPEM returns the PEM encoding of the private key.
+func (id WebAuthnIdentity) ToPEM() ([]byte, error) {
+	if id.privateKey == nil {
+		return nil, fmt.Errorf("the private key is not available")
+	}
+	data, err := x509.MarshalECPrivateKey(id.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "EC PRIVATE KEY",
+		Bytes: data,
+	}), nil
+}
+
+// Verify verifies the WebAuthn signature of the given message.
+func (id WebAuthnIdentity) Verify(msg, sig []byte) bool {
+	return verifyWebAuthn(id.publicKey, msg, sig) == nil
+}
+
+// coseKey is a COSE encoded EC2 public key, see RFC 9052.
+type coseKey struct {
+	KeyType   int    `cbor:"1,keyasint"`
+	Algorithm int    `cbor:"3,keyasint"`
+	Curve     int    `cbor:"-1,keyasint"`
+	X         []byte `cbor:"-2,keyasint"`
+	Y         []byte `cbor:"-3,keyasint"`
+}
+
+// webAuthnClientData is the client data of a WebAuthn assertion.
+type webAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// webAuthnSignature is the signature of a WebAuthn identity.
+type webAuthnSignature struct {
+	AuthenticatorData []byte `cbor:"authenticator_data"`
+	ClientDataJSON    string `cbor:"client_data_json"`
+	Signature         []byte `cbor:"signature"`
+}
@@ -0,0 +1,80 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestWebAuthnIdentity(t *testing.T) {
+	id, err := NewRandomWebAuthnIdentity("https://identity.ic0.app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// SEQUENCE { SEQUENCE { OID 1.3.6.1.4.1.56387.1.1 } BIT STRING { COSE key } }
+	prefix, _ := hex.DecodeString("305e300c060a2b0601040183b8430101034e00a5010203262001215820")
+	publicKey := id.PublicKey()
+	if len(publicKey) != 96 || !bytes.HasPrefix(publicKey, prefix) {
+		t.Fatalf("unexpected public key: %x", publicKey)
+	}
+
+	msg := []byte("hello")
+	sig, err := id.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(sig, []byte{0xd9, 0xd9, 0xf7}) {
+		t.Error("missing self-describe tag")
+	}
+	if !id.Verify(msg, sig) {
+		t.Error("invalid signature")
+	}
+	if id.Verify([]byte("world"), sig) {
+		t.Error("signature of another message")
+	}
+	if err := VerifyWebAuthnSignature(publicKey, msg, sig); err != nil {
+		t.Error(err)
+	}
+
+	var raw webAuthnSignature
+	if err := cbor.Unmarshal(sig, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(raw.ClientDataJSON); got != `{"type":"webauthn.get","challenge":"aGVsbG8","origin":"https://identity.ic0.app"}` {
+		t.Errorf("unexpected client data: %s", got)
+	}
+	if len(raw.AuthenticatorData) != 37 {
+		t.Errorf("unexpected authenticator data: %x", raw.AuthenticatorData)
+	}
+	raw.AuthenticatorData[32] ^= 0x40
+	tampered, _ := cbor.Marshal(raw)
+	if id.Verify(msg, tampered) {
+		t.Error("tampered authenticator data")
+	}
+
+	public, err := publicIdentity(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !public.Sender().Equal(id.Sender()) || !public.Verify(msg, sig) {
+		t.Error("public identity mismatch")
+	}
+	if _, err := public.Sign(msg); err == nil {
+		t.Error("signed without a private key")
+	}
+
+	data, err := id.ToPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id_, err := NewWebAuthnIdentityFromPEM(data, "https://identity.ic0.app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(id.PublicKey(), id_.PublicKey()) {
+		t.Error("pem round trip")
+	}
+}
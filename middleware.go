@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// maxEnvelopeSize is the maximum size of the body of a request that is verified by
+// EnvelopeMiddleware, the IC accepts messages of up to 2 MiB.
+const maxEnvelopeSize = 4 << 20
+
+// requestContextKey is the context key of the request of a verified envelope.
+type requestContextKey struct{}
+
+// EnvelopeConfig is the configuration of EnvelopeMiddleware.
+type EnvelopeConfig struct {
+	// RootKey is used to verify canister signatures, e.g. of Internet Identity
+	// delegations, see VerifyEnvelope.
+	RootKey []byte
+	// CanisterID and MethodName are the canister and the method every envelope has to
+	// be addressed to, so that requests that are signed for another canister or
+	// method, e.g. a call sniffed from a frontend, are not accepted.
+	CanisterID principal.Principal
+	MethodName string
+	// Nonce, if set, returns the challenge the nonce of the request has to be equal
+	// to, e.g. issued by the backend before the client signs the envelope.
+	Nonce func(r *http.Request) []byte
+}
+
+// EnvelopeMiddleware returns a handler that verifies the CBOR encoded envelope in the
+// body of every request with VerifyEnvelope, before passing the request on to the
+// next handler. Requests with an invalid envelope, an anonymous sender, another
+// canister, method or nonce than configured, or a request id that was already used
+// are rejected with 401 Unauthorized. Request ids are remembered until their ingress
+// expiry, after which the envelope is rejected anyway. The verified request is
+// available with RequestFromContext and SenderFromContext, and the body can be read
+// again by the next handler. It returns an error if the configuration has no method
+// name.
+func EnvelopeMiddleware(config EnvelopeConfig, next http.Handler) (http.Handler, error) {
+	if config.MethodName == "" {
+		return nil, fmt.Errorf("invalid envelope config: no method name")
+	}
+	var used requestIDs
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEnvelopeSize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request, err := VerifyEnvelope(body, config.RootKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case request.Sender.IsAnonymous():
+			http.Error(w, "anonymous requests are not allowed", http.StatusUnauthorized)
+			return
+		case !request.CanisterID.Equal(config.CanisterID) || request.MethodName != config.MethodName:
+			http.Error(w, "the request is addressed to another canister or method", http.StatusUnauthorized)
+			return
+		case config.Nonce != nil && !bytes.Equal(request.Nonce, config.Nonce(r)):
+			http.Error(w, "invalid nonce", http.StatusUnauthorized)
+			return
+		}
+		if !used.add(NewRequestID(*request), time.Unix(0, int64(request.IngressExpiry))) {
+			http.Error(w, "the request id was already used", http.StatusUnauthorized)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestContextKey{}, request)))
+	}), nil
+}
+
+// RequestFromContext returns the request of the envelope verified by
+// EnvelopeMiddleware.
+func RequestFromContext(ctx context.Context) (*Request, bool) {
+	request, ok := ctx.Value(requestContextKey{}).(*Request)
+	return request, ok
+}
+
+// SenderFromContext returns the sender of the envelope verified by
+// EnvelopeMiddleware.
+func SenderFromContext(ctx context.Context) (principal.Principal, bool) {
+	request, ok := RequestFromContext(ctx)
+	if !ok {
+		return principal.Principal{}, false
+	}
+	return request.Sender, true
+}
+
+// requestIDs are the ids of the requests that were accepted, until their ingress
+// expiry. The ids are also kept in a min-heap by expiry, so that expired ids are
+// removed in logarithmic time.
+type requestIDs struct {
+	mu       sync.Mutex
+	expiries map[RequestID]time.Time
+	heap     requestIDHeap
+}
+
+// add adds the request id, unless it was already added. Expired ids are removed.
+func (ids *requestIDs) add(id RequestID, expiry time.Time) bool {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	if ids.expiries == nil {
+		ids.expiries = make(map[RequestID]time.Time)
+	}
+	now := time.Now()
+	for len(ids.heap) != 0 && ids.heap[0].expiry.Before(now) {
+		delete(ids.expiries, heap.Pop(&ids.heap).(requestIDExpiry).id)
+	}
+	if _, ok := ids.expiries[id]; ok {
+		return false
+	}
+	ids.expiries[id] = expiry
+	heap.Push(&ids.heap, requestIDExpiry{id: id, expiry: expiry})
+	return true
+}
+
+// requestIDExpiry is a request id and its ingress expiry.
+type requestIDExpiry struct {
+	id     RequestID
+	expiry time.Time
+}
+
+// requestIDHeap is a min-heap of request ids by expiry, see container/heap.
+type requestIDHeap []requestIDExpiry
+
+func (h requestIDHeap) Len() int { return len(h) }
+
+func (h requestIDHeap) Less(i, j int) bool { return h[i].expiry.Before(h[j].expiry) }
+
+func (h *requestIDHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+func (h *requestIDHeap) Push(x any) { *h = append(*h, x.(requestIDExpiry)) }
+
+func (h requestIDHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestIDs(t *testing.T) {
+	var ids requestIDs
+	now := time.Now()
+	for i := range 10 {
+		// The ids expire in reverse order.
+		if !ids.add(RequestID{byte(i)}, now.Add(time.Duration(10-i)*time.Hour)) {
+			t.Fatalf("id %d was not added", i)
+		}
+	}
+	if ids.add(RequestID{0}, now.Add(time.Hour)) {
+		t.Error("id was added twice")
+	}
+	if !ids.add(RequestID{10}, now.Add(-time.Second)) {
+		t.Error("expired id was not added")
+	}
+	// The next id removes the expired one, only the earliest ids are inspected.
+	if !ids.add(RequestID{11}, now.Add(time.Hour)) || len(ids.expiries) != 11 || len(ids.heap) != 11 {
+		t.Errorf("expired id was not removed: %d ids", len(ids.expiries))
+	}
+	if ids.heap[0].expiry != now.Add(time.Hour) {
+		t.Errorf("unexpected earliest expiry: %s", ids.heap[0].expiry)
+	}
+}
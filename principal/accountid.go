@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/crc32"
+
+	"github.com/fxamacker/cbor/v2"
 )
 
 var (
@@ -64,16 +66,60 @@ func (id AccountIdentifier) Encode() string {
 	return hex.EncodeToString(id.Bytes())
 }
 
+// MarshalBinary returns the bytes of the account identifier, including the checksum.
+func (id AccountIdentifier) MarshalBinary() ([]byte, error) {
+	return id.Bytes(), nil
+}
+
+// MarshalCBOR encodes the 28 bytes of the account identifier, without the checksum,
+// as a CBOR byte string.
+func (id AccountIdentifier) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(id[:])
+}
+
 // MarshalJSON encodes the account identifier into JSON bytes as a string.
 func (id AccountIdentifier) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id.String())
 }
 
+// MarshalText returns the hexadecimal representation of the account identifier.
+func (id AccountIdentifier) MarshalText() ([]byte, error) {
+	return []byte(id.Encode()), nil
+}
+
 // String returns the hexadecimal representation of the account identifier.
 func (id AccountIdentifier) String() string {
 	return id.Encode()
 }
 
+// UnmarshalBinary decodes the bytes of an account identifier, either including the
+// checksum (32 bytes) or not (28 bytes).
+func (id *AccountIdentifier) UnmarshalBinary(data []byte) error {
+	switch len(data) {
+	case 28:
+		copy(id[:], data)
+		return nil
+	case 32:
+		if crc := binary.BigEndian.Uint32(data[:4]); crc != crc32.ChecksumIEEE(data[4:]) {
+			return fmt.Errorf("invalid checksum: %d", crc)
+		}
+		copy(id[:], data[4:])
+		return nil
+	default:
+		return fmt.Errorf("invalid length: %d", len(data))
+	}
+}
+
+// UnmarshalCBOR decodes a CBOR byte string into an account identifier, see
+// UnmarshalBinary.
+func (id *AccountIdentifier) UnmarshalCBOR(data []byte) error {
+	var raw []byte
+	if err := cbor.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return id.UnmarshalBinary(raw)
+}
+
 // UnmarshalJSON decodes the given JSON bytes into an account identifier from a string.
 func (id *AccountIdentifier) UnmarshalJSON(bytes []byte) error {
 	var accountID string
@@ -87,3 +133,13 @@ func (id *AccountIdentifier) UnmarshalJSON(bytes []byte) error {
 	*id = decoded
 	return nil
 }
+
+// UnmarshalText decodes the hexadecimal representation of an account identifier.
+func (id *AccountIdentifier) UnmarshalText(text []byte) error {
+	decoded, err := DecodeAccountID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = decoded
+	return nil
+}
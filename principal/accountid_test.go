@@ -1,9 +1,11 @@
 package principal_test
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/aviate-labs/agent-go/principal"
+	"github.com/fxamacker/cbor/v2"
 	"testing"
 )
 
@@ -40,3 +42,58 @@ func TestAccountIdentifier_MarshalJSON(t *testing.T) {
 		t.Errorf("expected %v, got %v", original, decoded)
 	}
 }
+
+// The CBOR representation of an account identifier are its 28 bytes, without the
+// checksum that is included in the binary representation.
+func TestAccountIdentifier_cbor(t *testing.T) {
+	owner := principal.MustDecode("k2t6j-2nvnp-4zjm3-25dtz-6xhaa-c7boj-5gayf-oj3xs-i43lp-teztq-6ae")
+	id := principal.NewAccountID(owner, principal.DefaultSubAccount)
+	raw, err := cbor.Marshal(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(raw) != "581c9339f89053454a4b9865ea0452a4bffe2b1cd41f4982bad10c1e637c" {
+		t.Errorf("unexpected encoding: %x", raw)
+	}
+	var decoded principal.AccountIdentifier
+	if err := cbor.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != id {
+		t.Errorf("expected %s, got %s", id, decoded)
+	}
+}
+
+func TestAccountIdentifier_sql(t *testing.T) {
+	id := principal.NewAccountID(principal.Principal{}, principal.DefaultSubAccount)
+	text, err := id.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromText principal.AccountIdentifier
+	if err := fromText.Scan(text); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := principal.Binary(&id).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.([]byte)) != 32 {
+		t.Errorf("unexpected binary value: %x", raw)
+	}
+	var fromBinary, fromHash principal.AccountIdentifier
+	if err := principal.Binary(&fromBinary).Scan(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := fromHash.UnmarshalBinary(id[:]); err != nil {
+		t.Fatal(err)
+	}
+	if fromText != id || fromBinary != id || fromHash != id {
+		t.Error(fromText, fromBinary, fromHash)
+	}
+	invalid := raw.([]byte)
+	invalid[0] ^= 0x01
+	if err := principal.Binary(&fromBinary).Scan(invalid); err == nil {
+		t.Error("invalid checksum")
+	}
+}
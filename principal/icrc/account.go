@@ -1,17 +1,24 @@
 package icrc
 
 import (
+	"database/sql/driver"
 	"encoding/base32"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/aviate-labs/agent-go/principal"
+	"github.com/fxamacker/cbor/v2"
 	"hash/crc32"
 	"strings"
 )
 
 var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
 
+// account is an Account without the text and binary marshalers, it is used to keep
+// the JSON and CBOR representation of an account a struct.
+type account Account
+
 func trimLeadingZeros(str string) string {
 	for str[0] == '0' {
 		str = str[1:]
@@ -90,6 +97,95 @@ func (a Account) Encode() string {
 	return a.Owner.String() + "-" + b32cs + "." + trimLeadingZeros(hex.EncodeToString(a.SubAccount[:]))
 }
 
+// MarshalBinary returns the binary representation of the account: the length of
+// the owner, the owner and the sub-account, which is omitted if it is the default
+// sub-account.
+func (a Account) MarshalBinary() ([]byte, error) {
+	raw := append([]byte{byte(len(a.Owner.Raw))}, a.Owner.Raw...)
+	if a.SubAccount != nil && *a.SubAccount != [32]byte{} {
+		raw = append(raw, a.SubAccount[:]...)
+	}
+	return raw, nil
+}
+
+// MarshalCBOR encodes the account as a map of its fields, like the struct. The binary
+// representation is only used by MarshalBinary.
+func (a Account) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(account(a))
+}
+
+// MarshalJSON encodes the account as an object of its fields, like the struct. The
+// textual representation is only used by MarshalText.
+func (a Account) MarshalJSON() ([]byte, error) {
+	return json.Marshal(account(a))
+}
+
+// MarshalText returns the textual representation of the account.
+func (a Account) MarshalText() ([]byte, error) {
+	return []byte(a.Encode()), nil
+}
+
+// Scan implements the sql.Scanner interface, it expects the textual representation.
+// Use principal.Binary to scan the binary representation.
+func (a *Account) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return a.UnmarshalText([]byte(src))
+	case []byte:
+		return a.UnmarshalText(src)
+	default:
+		return fmt.Errorf("can not scan %T into an account, expected text", src)
+	}
+}
+
 func (a Account) String() string {
 	return a.Encode()
 }
+
+// UnmarshalBinary decodes the binary representation of an account, see MarshalBinary.
+func (a *Account) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || 29 < data[0] || len(data) < 1+int(data[0]) {
+		return fmt.Errorf("invalid account: %x", data)
+	}
+	n := 1 + int(data[0])
+	account := Account{
+		Owner: principal.Principal{Raw: append([]byte{}, data[1:n]...)},
+	}
+	switch len(data) - n {
+	case 0:
+	case 32:
+		var subAccount [32]byte
+		copy(subAccount[:], data[n:])
+		account.SubAccount = &subAccount
+	default:
+		return fmt.Errorf("invalid sub account length: %d", len(data)-n)
+	}
+	*a = account
+	return nil
+}
+
+// UnmarshalCBOR decodes an account from a map of its fields, see MarshalCBOR.
+func (a *Account) UnmarshalCBOR(data []byte) error {
+	return cbor.Unmarshal(data, (*account)(a))
+}
+
+// UnmarshalJSON decodes an account from an object of its fields, see MarshalJSON.
+func (a *Account) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*account)(a))
+}
+
+// UnmarshalText decodes the textual representation of an account.
+func (a *Account) UnmarshalText(text []byte) error {
+	account, err := Decode(string(text))
+	if err != nil {
+		return err
+	}
+	*a = account
+	return nil
+}
+
+// Value implements the driver.Valuer interface, it returns the textual
+// representation. Use principal.Binary to store the binary representation.
+func (a Account) Value() (driver.Value, error) {
+	return a.Encode(), nil
+}
@@ -2,11 +2,13 @@ package icrc_test
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"testing"
 
 	"github.com/aviate-labs/agent-go/principal"
 	"github.com/aviate-labs/agent-go/principal/icrc"
+	"github.com/fxamacker/cbor/v2"
 )
 
 func ExampleAccount() {
@@ -63,3 +65,85 @@ func TestVectors(t *testing.T) {
 		}
 	}
 }
+
+// The JSON and CBOR representation of an account is a struct, the text and binary
+// representation are only used by MarshalText and MarshalBinary.
+func TestAccount_encoding(t *testing.T) {
+	owner := principal.MustDecode("k2t6j-2nvnp-4zjm3-25dtz-6xhaa-c7boj-5gayf-oj3xs-i43lp-teztq-6ae")
+	for _, test := range []struct {
+		account icrc.Account
+		json    string
+		cbor    string
+	}{
+		{
+			account: icrc.Account{Owner: owner},
+			json:    `{"Owner":"k2t6j-2nvnp-4zjm3-25dtz-6xhaa-c7boj-5gayf-oj3xs-i43lp-teztq-6ae","SubAccount":null}`,
+			cbor:    "a2654f776e6572581db56bf994b37ae8e79f5ce000be1727a6060ae4eef24736b7cc999c3c026a5375624163636f756e74f6",
+		},
+		{
+			account: icrc.Account{Owner: owner, SubAccount: &[32]byte{31: 1}},
+			json:    `{"Owner":"k2t6j-2nvnp-4zjm3-25dtz-6xhaa-c7boj-5gayf-oj3xs-i43lp-teztq-6ae","SubAccount":[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1]}`,
+			cbor:    "a2654f776e6572581db56bf994b37ae8e79f5ce000be1727a6060ae4eef24736b7cc999c3c026a5375624163636f756e7458200000000000000000000000000000000000000000000000000000000000000001",
+		},
+	} {
+		j, err := json.Marshal(test.account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := cbor.Marshal(test.account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(j) != test.json || hex.EncodeToString(c) != test.cbor {
+			t.Errorf("%s: unexpected encoding: %s, %x", test.account, j, c)
+		}
+		var fromJSON, fromCBOR icrc.Account
+		if err := json.Unmarshal(j, &fromJSON); err != nil {
+			t.Fatal(err)
+		}
+		if err := cbor.Unmarshal(c, &fromCBOR); err != nil {
+			t.Fatal(err)
+		}
+		if fromJSON.String() != test.account.String() || fromCBOR.String() != test.account.String() {
+			t.Errorf("expected %s, got %s and %s", test.account, fromJSON, fromCBOR)
+		}
+	}
+}
+
+func TestAccount_sql(t *testing.T) {
+	owner := principal.MustDecode("k2t6j-2nvnp-4zjm3-25dtz-6xhaa-c7boj-5gayf-oj3xs-i43lp-teztq-6ae")
+	for _, a := range []icrc.Account{
+		{Owner: owner},
+		{Owner: owner, SubAccount: &[32]byte{31: 1}},
+	} {
+		text, err := a.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromText icrc.Account
+		if err := fromText.Scan(text); err != nil {
+			t.Fatal(err)
+		}
+		raw, err := principal.Binary(&a).Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromBinary icrc.Account
+		if err := principal.Binary(&fromBinary).Scan(raw); err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range []icrc.Account{fromText, fromBinary} {
+			if b.String() != a.String() {
+				t.Errorf("expected %s, got %s", a, b)
+			}
+		}
+	}
+	// The default sub-account is the same account as no sub-account.
+	raw, _ := icrc.Account{Owner: owner, SubAccount: &[32]byte{}}.MarshalBinary()
+	if len(raw) != 1+len(owner.Raw) {
+		t.Errorf("unexpected binary value: %x", raw)
+	}
+	if err := new(icrc.Account).UnmarshalBinary(append(raw, 0x01)); err == nil {
+		t.Error("invalid sub account")
+	}
+}
@@ -126,6 +126,11 @@ func (p Principal) IsSelfAuthenticating() bool {
 	return p.Raw[len(p.Raw)-1] == 0x02
 }
 
+// MarshalBinary converts the principal to its binary representation, the raw bytes.
+func (p Principal) MarshalBinary() ([]byte, error) {
+	return p.Raw, nil
+}
+
 // MarshalCBOR converts the principal to its CBOR representation.
 func (p Principal) MarshalCBOR() ([]byte, error) {
 	return cbor.Marshal(p.Raw)
@@ -136,11 +141,26 @@ func (p Principal) MarshalJSON() ([]byte, error) {
 	return json.Marshal(p.String())
 }
 
+// MarshalText converts the principal to its textual representation.
+func (p Principal) MarshalText() ([]byte, error) {
+	return []byte(p.Encode()), nil
+}
+
 // String implements the Stringer interface.
 func (p Principal) String() string {
 	return p.Encode()
 }
 
+// UnmarshalBinary converts the raw bytes into a principal.
+func (p *Principal) UnmarshalBinary(data []byte) error {
+	if 29 < len(data) {
+		return fmt.Errorf("invalid length: %d", len(data))
+	}
+	// The empty principal (aaaaa-aa) is not nil.
+	p.Raw = append([]byte{}, data...)
+	return nil
+}
+
 // UnmarshalCBOR converts a CBOR representation into a principal.
 func (p *Principal) UnmarshalCBOR(bytes []byte) error {
 	return cbor.Unmarshal(bytes, &p.Raw)
@@ -159,3 +179,13 @@ func (p *Principal) UnmarshalJSON(bytes []byte) error {
 	*p = decoded
 	return nil
 }
+
+// UnmarshalText converts a textual representation into a principal.
+func (p *Principal) UnmarshalText(text []byte) error {
+	decoded, err := Decode(string(text))
+	if err != nil {
+		return err
+	}
+	*p = decoded
+	return nil
+}
@@ -56,3 +56,64 @@ func TestPrincipal_MarshalJSON(t *testing.T) {
 		t.Errorf("expected %v, got %v", original, decoded)
 	}
 }
+
+func TestPrincipal_sql(t *testing.T) {
+	for _, p := range []principal.Principal{LEDGER_PRINCIPAL, principal.AnonymousID, {Raw: []byte{}}} {
+		text, err := p.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromText principal.Principal
+		if err := fromText.Scan(text); err != nil {
+			t.Fatal(err)
+		}
+		var fromBytes principal.Principal
+		if err := fromBytes.Scan([]byte(text.(string))); err != nil {
+			t.Fatal(err)
+		}
+		raw, err := principal.Binary(&p).Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(raw.([]byte), p.Raw) {
+			t.Errorf("unexpected binary value: %x", raw)
+		}
+		var fromBinary principal.Principal
+		if err := principal.Binary(&fromBinary).Scan(raw); err != nil {
+			t.Fatal(err)
+		}
+		for _, q := range []principal.Principal{fromText, fromBytes, fromBinary} {
+			if !q.Equal(p) || q.Raw == nil {
+				t.Errorf("expected %s, got %s", p, q)
+			}
+		}
+	}
+	var p principal.Principal
+	if err := p.Scan(int64(1)); err == nil {
+		t.Error("scanned an integer")
+	}
+	if err := principal.Binary(&p).Scan(make([]byte, 30)); err == nil {
+		t.Error("scanned an invalid principal")
+	}
+}
+
+func TestPrincipal_text(t *testing.T) {
+	m := map[string]principal.Principal{"ledger": LEDGER_PRINCIPAL}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `{"ledger":"ryjl3-tyaaa-aaaaa-aaaba-cai"}` {
+		t.Error(string(raw))
+	}
+	var p principal.Principal
+	if err := p.UnmarshalText([]byte("ryjl3-tyaaa-aaaaa-aaaba-cai")); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(LEDGER_PRINCIPAL) {
+		t.Error(p)
+	}
+	if err := p.UnmarshalText([]byte("ryjl3-tyaaa-aaaaa-aaaba-caa")); err == nil {
+		t.Error("invalid checksum")
+	}
+}
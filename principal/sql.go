@@ -0,0 +1,87 @@
+package principal
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// BinaryValue stores a value in its binary representation in a database, see Binary.
+type BinaryValue struct {
+	v binaryValue
+}
+
+// Binary returns a database value that stores the given value in its binary
+// representation, e.g. in a BYTEA or BLOB column, instead of its textual
+// representation. The value has to be a pointer to be scanned into.
+//
+//	_, err := db.Exec("INSERT INTO owners (account, owner) VALUES ($1, $2)", principal.Binary(&account), principal.Binary(&owner))
+//	err = row.Scan(principal.Binary(&account), principal.Binary(&owner))
+//
+// It can be used for any type that implements encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler, e.g. icrc.Account and idl.Nat.
+func Binary(v binaryValue) BinaryValue {
+	return BinaryValue{v: v}
+}
+
+// Scan implements the sql.Scanner interface.
+func (b BinaryValue) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		// The driver may reuse the slice.
+		return b.v.UnmarshalBinary(append([]byte{}, src...))
+	default:
+		return fmt.Errorf("can not scan %T into %T, expected bytes", src, b.v)
+	}
+}
+
+// Value implements the driver.Valuer interface.
+func (b BinaryValue) Value() (driver.Value, error) {
+	return b.v.MarshalBinary()
+}
+
+// Scan implements the sql.Scanner interface, it expects the hexadecimal
+// representation. Use Binary to scan the binary representation.
+func (id *AccountIdentifier) Scan(src any) error {
+	return scanText(id, src)
+}
+
+// Value implements the driver.Valuer interface, it returns the hexadecimal
+// representation. Use Binary to store the binary representation.
+func (id AccountIdentifier) Value() (driver.Value, error) {
+	return id.Encode(), nil
+}
+
+// Scan implements the sql.Scanner interface, it expects the textual representation.
+// Use Binary to scan the binary representation.
+func (p *Principal) Scan(src any) error {
+	return scanText(p, src)
+}
+
+// Value implements the driver.Valuer interface, it returns the textual
+// representation. Use Binary to store the binary representation.
+func (p Principal) Value() (driver.Value, error) {
+	return p.Encode(), nil
+}
+
+func scanText(v textUnmarshaler, src any) error {
+	switch src := src.(type) {
+	case string:
+		return v.UnmarshalText([]byte(src))
+	case []byte:
+		return v.UnmarshalText(src)
+	default:
+		return fmt.Errorf("can not scan %T into %T, expected text", src, v)
+	}
+}
+
+// binaryValue is implemented by the types that implement encoding.BinaryMarshaler
+// and encoding.BinaryUnmarshaler.
+type binaryValue interface {
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary(data []byte) error
+}
+
+// textUnmarshaler is encoding.TextUnmarshaler.
+type textUnmarshaler interface {
+	UnmarshalText(text []byte) error
+}
@@ -25,7 +25,11 @@ func (q APIRequest[In, Out]) QueryWithContext(ctx context.Context, out Out, skip
 	q.a.logger.Printf("[AGENT] QUERY %s %s", q.effectiveCanisterID, q.methodName)
 	ctx, cancel := context.WithTimeout(ctx, q.a.ingressExpiry)
 	defer cancel()
-	rawResp, err := q.a.client.Query(ctx, q.effectiveCanisterID, q.data)
+	data, err := q.envelope(ctx)
+	if err != nil {
+		return err
+	}
+	rawResp, err := q.a.client.Query(ctx, q.effectiveCanisterID, data)
 	if err != nil {
 		return err
 	}
@@ -136,6 +140,19 @@ func (q APIRequest[In, Out]) QueryWithContext(ctx context.Context, out Out, skip
 	}
 }
 
+// CompositeQuery calls a composite query method on a canister and unmarshals the
+// result into the given values. Composite queries are sent as query requests, they
+// can not be called as update.
+func (a Agent) CompositeQuery(canisterID principal.Principal, methodName string, in, out []any) error {
+	return a.CompositeQueryWithContext(a.ctx, canisterID, methodName, in, out)
+}
+
+// CompositeQueryWithContext is like CompositeQuery but uses the given context as the
+// parent of the per-request timeout.
+func (a Agent) CompositeQueryWithContext(ctx context.Context, canisterID principal.Principal, methodName string, in, out []any) error {
+	return a.QueryWithContext(ctx, canisterID, methodName, in, out)
+}
+
 // Query calls a method on a canister and unmarshals the result into the given values.
 func (a Agent) Query(canisterID principal.Principal, methodName string, in, out []any) error {
 	query, err := a.CreateCandidAPIRequest(RequestTypeQuery, canisterID, methodName, in...)
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"sort"
 
@@ -97,6 +98,34 @@ func (r *Request) MarshalCBOR() ([]byte, error) {
 	return cbor.Marshal(m)
 }
 
+// UnmarshalCBOR implements the CBOR unmarshaler interface.
+func (r *Request) UnmarshalCBOR(data []byte) error {
+	var raw struct {
+		Type          string             `cbor:"request_type"`
+		CanisterID    []byte             `cbor:"canister_id"`
+		MethodName    string             `cbor:"method_name"`
+		Arguments     []byte             `cbor:"arg"`
+		Sender        []byte             `cbor:"sender"`
+		IngressExpiry uint64             `cbor:"ingress_expiry"`
+		Nonce         []byte             `cbor:"nonce"`
+		Paths         [][]hashtree.Label `cbor:"paths"`
+	}
+	if err := cbor.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = Request{
+		Type:          raw.Type,
+		Sender:        principal.Principal{Raw: raw.Sender},
+		Nonce:         raw.Nonce,
+		IngressExpiry: raw.IngressExpiry,
+		CanisterID:    principal.Principal{Raw: raw.CanisterID},
+		MethodName:    raw.MethodName,
+		Arguments:     raw.Arguments,
+		Paths:         raw.Paths,
+	}
+	return nil
+}
+
 // RequestID is the request ID.
 type RequestID [32]byte
 
@@ -154,12 +183,18 @@ func NewRequestID(req Request) RequestID {
 
 // Sign signs the request ID with the given identity.
 func (r RequestID) Sign(id identity.Identity) ([]byte, error) {
+	return r.SignWithContext(context.Background(), id)
+}
+
+// SignWithContext signs the request ID with the given identity, see
+// identity.SignWithContext.
+func (r RequestID) SignWithContext(ctx context.Context, id identity.Identity) ([]byte, error) {
 	message := append(
 		// \x0Aic-request
 		[]byte{0x0a, 0x69, 0x63, 0x2d, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74},
 		r[:]...,
 	)
-	return id.Sign(message)
+	return identity.SignWithContext(ctx, id, message)
 }
 
 // RequestType is the type of request.
@@ -11,7 +11,7 @@ import (
 
 func (a Agent) GetSubnetMetrics(subnetID principal.Principal) (*SubnetMetrics, error) {
 	path := []hashtree.Label{hashtree.Label("subnet"), subnetID.Raw, hashtree.Label("metrics")}
-	cert, err := a.readSubnetStateCertificate(subnetID, [][]hashtree.Label{path})
+	cert, err := a.readSubnetStateCertificate(a.ctx, subnetID, [][]hashtree.Label{path})
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +29,7 @@ func (a Agent) GetSubnetMetrics(subnetID principal.Principal) (*SubnetMetrics, e
 
 func (a Agent) GetSubnets() ([]principal.Principal, error) {
 	path := []hashtree.Label{hashtree.Label("subnet")}
-	cert, err := a.readSubnetStateCertificate(principal.MustDecode(certification.RootSubnetID), [][]hashtree.Label{path})
+	cert, err := a.readSubnetStateCertificate(a.ctx, principal.MustDecode(certification.RootSubnetID), [][]hashtree.Label{path})
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +51,7 @@ func (a Agent) GetSubnets() ([]principal.Principal, error) {
 func (a Agent) GetSubnetsInfo() ([]SubnetInfo, error) {
 	rootSubnetID := principal.MustDecode(certification.RootSubnetID)
 	path := []hashtree.Label{hashtree.Label("subnet")}
-	cert, err := a.readSubnetStateCertificate(rootSubnetID, [][]hashtree.Label{path})
+	cert, err := a.readSubnetStateCertificate(a.ctx, rootSubnetID, [][]hashtree.Label{path})
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +78,7 @@ func (a Agent) GetSubnetsInfo() ([]SubnetInfo, error) {
 		nodes, err := cert.Tree.LookupSubTree(hashtree.Label("subnet"), subnetID.Raw, hashtree.Label("node"))
 		if err != nil {
 			path = []hashtree.Label{hashtree.Label("subnet"), subnetID.Raw, hashtree.Label("node")}
-			nodesCert, err := a.readSubnetStateCertificate(subnetID, [][]hashtree.Label{path})
+			nodesCert, err := a.readSubnetStateCertificate(a.ctx, subnetID, [][]hashtree.Label{path})
 			if err != nil {
 				return nil, err
 			}
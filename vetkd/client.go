@@ -0,0 +1,116 @@
+// Package vetkd provides a client for the vetKD (verifiably encrypted threshold key
+// derivation) methods of the management canister, and the client side of the
+// protocol: transport keys, the decryption and verification of encrypted vetKeys,
+// and identity based encryption (IBE) to derived public keys.
+//
+// A vetKey is a BLS signature of the input by the key that is derived for a
+// canister and a context. The key is encrypted to a transport public key, so only
+// the holder of the transport secret key can decrypt it.
+//
+//	tsk, _ := vetkd.NewTransportSecretKey()
+//	resp, _ := client.DeriveKey(vetkd.DeriveKeyArgs{Input: input, Context: context, KeyID: keyID, TransportPublicKey: tsk.PublicKey()})
+//	key, _ := tsk.DecryptAndVerify(resp.EncryptedKey, derivedPublicKey, input)
+package vetkd
+
+import (
+	"github.com/aviate-labs/agent-go"
+	"github.com/aviate-labs/agent-go/candid/idl"
+	"github.com/aviate-labs/agent-go/principal"
+)
+
+// Client calls the vetKD methods of the management canister.
+//
+// The management canister only accepts the vetKD methods from canisters, ingress
+// messages are rejected by the IC. The client is meant for canisters that forward
+// the calls and for test environments that accept them.
+type Client struct {
+	a                   *agent.Agent
+	effectiveCanisterID principal.Principal
+}
+
+// New creates a new client that routes the calls to the subnet of the given
+// canister.
+func New(a *agent.Agent, effectiveCanisterID principal.Principal) *Client {
+	return &Client{
+		a:                   a,
+		effectiveCanisterID: effectiveCanisterID,
+	}
+}
+
+// DeriveKey calls vetkd_derive_key, it returns the vetKey of the input encrypted to
+// the transport public key.
+func (c Client) DeriveKey(args DeriveKeyArgs) (*DeriveKeyResult, error) {
+	var r0 DeriveKeyResult
+	if err := c.a.CallWithEffectiveCanisterID(
+		principal.Principal{},
+		c.effectiveCanisterID,
+		"vetkd_derive_key",
+		[]any{args},
+		[]any{&r0},
+	); err != nil {
+		return nil, err
+	}
+	return &r0, nil
+}
+
+// PublicKey calls vetkd_public_key, it returns the public key that is derived for
+// the canister and the context.
+func (c Client) PublicKey(args PublicKeyArgs) (*PublicKeyResult, error) {
+	var r0 PublicKeyResult
+	if err := c.a.CallWithEffectiveCanisterID(
+		principal.Principal{},
+		c.effectiveCanisterID,
+		"vetkd_public_key",
+		[]any{args},
+		[]any{&r0},
+	); err != nil {
+		return nil, err
+	}
+	return &r0, nil
+}
+
+// Curve is the curve of a vetKD key.
+type Curve struct {
+	Bls12_381_G2 *idl.Null `ic:"bls12_381_g2,variant"`
+}
+
+// DeriveKeyArgs are the arguments of vetkd_derive_key.
+type DeriveKeyArgs struct {
+	Input              []byte `ic:"input"`
+	Context            []byte `ic:"context"`
+	KeyID              KeyID  `ic:"key_id"`
+	TransportPublicKey []byte `ic:"transport_public_key"`
+}
+
+// DeriveKeyResult is the result of vetkd_derive_key.
+type DeriveKeyResult struct {
+	EncryptedKey []byte `ic:"encrypted_key"`
+}
+
+// KeyID identifies a vetKD master key, e.g. "key_1" or "test_key_1" on the IC and
+// "dfx_test_key" on a local replica.
+type KeyID struct {
+	Curve Curve  `ic:"curve"`
+	Name  string `ic:"name"`
+}
+
+// NewKeyID returns the id of the BLS12-381 G2 master key with the given name.
+func NewKeyID(name string) KeyID {
+	return KeyID{
+		Curve: Curve{Bls12_381_G2: new(idl.Null)},
+		Name:  name,
+	}
+}
+
+// PublicKeyArgs are the arguments of vetkd_public_key. The canister id defaults to
+// the caller.
+type PublicKeyArgs struct {
+	CanisterID *principal.Principal `ic:"canister_id,omitempty"`
+	Context    []byte               `ic:"context"`
+	KeyID      KeyID                `ic:"key_id"`
+}
+
+// PublicKeyResult is the result of vetkd_public_key.
+type PublicKeyResult struct {
+	PublicKey []byte `ic:"public_key"`
+}
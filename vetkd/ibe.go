@@ -0,0 +1,163 @@
+package vetkd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+
+	"github.com/aviate-labs/agent-go/certification/bls"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// ibeHeader is the header of the encoding of IBE ciphertexts of ic-vetkeys, including
+// its version.
+const ibeHeader = "IC IBE\x00\x01"
+
+// Domain separators of the hashes of the IBE scheme.
+const (
+	dstIBEHashToMask = "ic-vetkd-bls12-381-ibe-hash-to-mask"
+	dstIBEMaskSeed   = "ic-vetkd-bls12-381-ibe-mask-seed"
+	dstIBEMaskMsg    = "ic-vetkd-bls12-381-ibe-mask-msg"
+)
+
+const ibeSeedLen = 32
+
+// ibeScalar returns the scalar of the header, the seed and the message.
+func ibeScalar(seed, message []byte) (*big.Int, error) {
+	input := make([]byte, 0, len(ibeHeader)+len(seed)+len(message))
+	input = append(input, ibeHeader...)
+	input = append(input, seed...)
+	input = append(input, message...)
+	t, err := fr.Hash(input, []byte(dstIBEHashToMask), 1)
+	if err != nil {
+		return nil, err
+	}
+	return t[0].BigInt(new(big.Int)), nil
+}
+
+// ibeSeedMask returns the mask of the seed, derived from the encoding of the element
+// of GT with the coefficients in ascending order, i.e. c0.c0.c0 first. gnark-crypto
+// encodes them in descending order.
+func ibeSeedMask(tsig *bls12381.GT) ([]byte, error) {
+	raw := tsig.Bytes()
+	b := make([]byte, 0, len(raw))
+	for i := len(raw) - fp.Bytes; 0 <= i; i -= fp.Bytes {
+		b = append(b, raw[i:i+fp.Bytes]...)
+	}
+	return deriveSymmetricKey(b, dstIBEMaskSeed, ibeSeedLen)
+}
+
+// IBECiphertext is a message encrypted to an identity, e.g. a principal, under a
+// derived public key. The message can be decrypted with the vetKey of the identity,
+// see IBEEncrypt.
+type IBECiphertext struct {
+	c1 bls12381.G2Affine
+	c2 [ibeSeedLen]byte
+	c3 []byte
+}
+
+// IBECiphertextFromBytes decodes a ciphertext, see Bytes.
+func IBECiphertextFromBytes(b []byte) (*IBECiphertext, error) {
+	if len(b) < len(ibeHeader)+g2Len+ibeSeedLen || string(b[:len(ibeHeader)]) != ibeHeader {
+		return nil, fmt.Errorf("invalid IBE ciphertext")
+	}
+	b = b[len(ibeHeader):]
+	var c IBECiphertext
+	if _, err := c.c1.SetBytes(b[:g2Len]); err != nil {
+		return nil, fmt.Errorf("invalid IBE ciphertext: %w", err)
+	}
+	copy(c.c2[:], b[g2Len:])
+	c.c3 = append([]byte{}, b[g2Len+ibeSeedLen:]...)
+	return &c, nil
+}
+
+// IBEEncrypt encrypts the message to the identity under the derived public key, as
+// returned by vetkd_public_key. Only the holder of the vetKey of the identity, as
+// returned by vetkd_derive_key with the identity as input, can decrypt it.
+//
+// The scheme is the Boneh-Franklin IBE with the Fujisaki-Okamoto transform: a random
+// seed is encrypted with the pairing of the augmented hash of the identity and the
+// public key, the message is masked with a key derived from the seed. The encoding is
+// the one of ic-vetkeys.
+func IBEEncrypt(derivedPublicKey *bls.PublicKey, identity, message []byte) (*IBECiphertext, error) {
+	seed := make([]byte, ibeSeedLen)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	t, err := ibeScalar(seed, message)
+	if err != nil {
+		return nil, err
+	}
+	h, err := augmentedHashToG1(derivedPublicKey, identity)
+	if err != nil {
+		return nil, err
+	}
+	pt, err := bls12381.Pair([]bls12381.G1Affine{h}, []bls12381.G2Affine{bls12381.G2Affine(*derivedPublicKey)})
+	if err != nil {
+		return nil, err
+	}
+	var tsig bls12381.GT
+	tsig.Exp(pt, t)
+	seedMask, err := ibeSeedMask(&tsig)
+	if err != nil {
+		return nil, err
+	}
+	msgMask, err := deriveSymmetricKey(seed, dstIBEMaskMsg, len(message))
+	if err != nil {
+		return nil, err
+	}
+
+	var c IBECiphertext
+	c.c1.ScalarMultiplication(&g2Gen, t)
+	subtle.XORBytes(c.c2[:], seed, seedMask)
+	c.c3 = make([]byte, len(message))
+	subtle.XORBytes(c.c3, message, msgMask)
+	return &c, nil
+}
+
+// Bytes returns the encoding of the ciphertext: the header, the compressed G2
+// encoding of c1, the masked seed c2 and the masked message c3.
+func (c IBECiphertext) Bytes() []byte {
+	c1 := c.c1.Bytes()
+	b := make([]byte, 0, len(ibeHeader)+g2Len+ibeSeedLen+len(c.c3))
+	b = append(b, ibeHeader...)
+	b = append(b, c1[:]...)
+	b = append(b, c.c2[:]...)
+	return append(b, c.c3...)
+}
+
+// Decrypt decrypts the ciphertext with the vetKey of the identity it was encrypted
+// to. It fails if the key does not belong to the identity or if the ciphertext was
+// modified.
+func (c IBECiphertext) Decrypt(key *VetKey) ([]byte, error) {
+	tsig, err := bls12381.Pair([]bls12381.G1Affine{key.k}, []bls12381.G2Affine{c.c1})
+	if err != nil {
+		return nil, err
+	}
+	seedMask, err := ibeSeedMask(&tsig)
+	if err != nil {
+		return nil, err
+	}
+	seed := make([]byte, ibeSeedLen)
+	subtle.XORBytes(seed, c.c2[:], seedMask)
+	msgMask, err := deriveSymmetricKey(seed, dstIBEMaskMsg, len(c.c3))
+	if err != nil {
+		return nil, err
+	}
+	message := make([]byte, len(c.c3))
+	subtle.XORBytes(message, c.c3, msgMask)
+
+	t, err := ibeScalar(seed, message)
+	if err != nil {
+		return nil, err
+	}
+	var c1 bls12381.G2Affine
+	c1.ScalarMultiplication(&g2Gen, t)
+	if !c1.Equal(&c.c1) {
+		return nil, fmt.Errorf("invalid IBE ciphertext or key")
+	}
+	return message, nil
+}
@@ -0,0 +1,171 @@
+package vetkd_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/aviate-labs/agent-go/candid"
+	"github.com/aviate-labs/agent-go/certification/bls"
+	"github.com/aviate-labs/agent-go/principal"
+	"github.com/aviate-labs/agent-go/vetkd"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+func TestPublicKeyArgs(t *testing.T) {
+	canisterID := principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai")
+	raw, err := candid.Marshal([]any{vetkd.PublicKeyArgs{
+		CanisterID: &canisterID,
+		Context:    []byte("cafe"),
+		KeyID:      vetkd.NewKeyID("test_key_1"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := candid.EncodeValueString(`(record { canister_id = opt principal "ryjl3-tyaaa-aaaaa-aaaba-cai"; context = blob "cafe"; key_id = record { curve = variant { bls12_381_g2 }; name = "test_key_1" } })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("expected %x, got %x", expected, raw)
+	}
+}
+
+func TestIBECiphertext(t *testing.T) {
+	sk := bls.NewSecretKeyByCSPRNG()
+	dpk := sk.PublicKey()
+	identity := principal.MustDecode("ryjl3-tyaaa-aaaaa-aaaba-cai").Raw
+	key := vetKey(t, sk, identity)
+
+	message := []byte("hello, world")
+	c, err := vetkd.IBEEncrypt(dpk, identity, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := vetkd.IBECiphertextFromBytes(c.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := decoded.Decrypt(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, message) {
+		t.Errorf("expected %q, got %q", message, plaintext)
+	}
+
+	raw := c.Bytes()
+	if !bytes.HasPrefix(raw, []byte("IC IBE\x00\x01")) || len(raw) != 8+96+32+len(message) {
+		t.Errorf("unexpected encoding %x", raw)
+	}
+	if _, err := vetkd.IBECiphertextFromBytes(append([]byte("IC IBE\x00\x02"), raw[8:]...)); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+
+	if _, err := c.Decrypt(vetKey(t, sk, []byte("other"))); err == nil {
+		t.Error("expected an error for the key of another identity")
+	}
+	raw[len(raw)-1] ^= 1
+	modified, err := vetkd.IBECiphertextFromBytes(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := modified.Decrypt(key); err == nil {
+		t.Error("expected an error for a modified ciphertext")
+	}
+}
+
+func TestTransportSecretKey(t *testing.T) {
+	sk := bls.NewSecretKeyByCSPRNG()
+	dpk := sk.PublicKey()
+	input := []byte("input")
+
+	tsk, err := vetkd.NewTransportSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := vetkd.TransportSecretKeyFromBytes(tsk.Bytes()); err != nil || !bytes.Equal(restored.PublicKey(), tsk.PublicKey()) {
+		t.Fatal("transport secret key does not round trip", err)
+	}
+
+	encryptedKey := encryptKey(t, sk, tsk.PublicKey(), input, false)
+	key, err := tsk.DecryptAndVerify(encryptedKey, dpk, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Bytes(), vetKey(t, sk, input).Bytes()) {
+		t.Error("unexpected vetKey")
+	}
+	if !key.Verify(dpk, input) {
+		t.Error("valid vetKey rejected")
+	}
+	k1, _ := key.DeriveSymmetricKey("aes-256-gcm", 32)
+	k2, _ := key.DeriveSymmetricKey("aes-256-gcm", 32)
+	if len(k1) != 32 || !bytes.Equal(k1, k2) {
+		t.Error("symmetric key is not deterministic")
+	}
+
+	if _, err := tsk.DecryptAndVerify(encryptedKey, dpk, []byte("other")); err == nil {
+		t.Error("expected an error for another input")
+	}
+	if _, err := tsk.DecryptAndVerify(encryptedKey, bls.NewSecretKeyByCSPRNG().PublicKey(), input); err == nil {
+		t.Error("expected an error for another public key")
+	}
+	if _, err := tsk.DecryptAndVerify(encryptKey(t, sk, tsk.PublicKey(), input, true), dpk, input); err == nil {
+		t.Error("expected an error for inconsistent randomness")
+	}
+	other, _ := vetkd.NewTransportSecretKey()
+	if _, err := other.DecryptAndVerify(encryptedKey, dpk, input); err == nil {
+		t.Error("expected an error for another transport key")
+	}
+}
+
+// encryptKey encrypts the vetKey of the input to the transport public key, like the
+// IC does: (r * G1, r * G2, r * tpk + k).
+func encryptKey(t *testing.T, sk *bls.SecretKey, tpk, input []byte, inconsistent bool) []byte {
+	var r fr.Element
+	if _, err := r.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	rInt := r.BigInt(new(big.Int))
+	var (
+		c1, c3, pk bls12381.G1Affine
+		c2         bls12381.G2Affine
+	)
+	c1.ScalarMultiplicationBase(rInt)
+	if inconsistent {
+		rInt.Add(rInt, big.NewInt(1))
+	}
+	c2.ScalarMultiplicationBase(rInt)
+	if _, err := pk.SetBytes(tpk); err != nil {
+		t.Fatal(err)
+	}
+	k, err := vetkd.VetKeyFromBytes(vetKey(t, sk, input).Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c3.ScalarMultiplication(&pk, r.BigInt(new(big.Int)))
+	c3.Add(&c3, (*bls12381.G1Affine)(k.Signature()))
+	b1, b2, b3 := c1.Bytes(), c2.Bytes(), c3.Bytes()
+	return append(append(b1[:], b2[:]...), b3[:]...)
+}
+
+// vetKey returns the vetKey of the input, the signature of the input augmented with
+// the public key.
+func vetKey(t *testing.T, sk *bls.SecretKey, input []byte) *vetkd.VetKey {
+	pk := bls12381.G2Affine(*sk.PublicKey())
+	raw := pk.Bytes()
+	h, err := bls12381.HashToG1(append(raw[:], input...), []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_AUG_"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	element := fr.Element(*sk)
+	h.ScalarMultiplication(&h, element.BigInt(new(big.Int)))
+	b := h.Bytes()
+	key, err := vetkd.VetKeyFromBytes(b[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,179 @@
+package vetkd
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/aviate-labs/agent-go/certification/bls"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// dstAugmented is the domain separator of the hash of the input to G1, the input is
+// prefixed with the derived public key.
+const dstAugmented = "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_AUG_"
+
+// Sizes of the compressed encodings of the points.
+const (
+	g1Len           = bls12381.SizeOfG1AffineCompressed
+	g2Len           = bls12381.SizeOfG2AffineCompressed
+	encryptedKeyLen = 2*g1Len + g2Len
+)
+
+var (
+	g1Gen        bls12381.G1Affine
+	g2Gen, g2Neg bls12381.G2Affine
+)
+
+func init() {
+	_, _, g1Gen, g2Gen = bls12381.Generators()
+	g2Neg.Neg(&g2Gen)
+}
+
+// augmentedHashToG1 hashes the input to G1, augmented with the derived public key.
+func augmentedHashToG1(derivedPublicKey *bls.PublicKey, input []byte) (bls12381.G1Affine, error) {
+	pk := bls12381.G2Affine(*derivedPublicKey)
+	raw := pk.Bytes()
+	return bls12381.HashToG1(append(raw[:], input...), []byte(dstAugmented))
+}
+
+// deriveSymmetricKey derives a key of the given length from the input with HKDF-SHA256,
+// without salt and with the domain as info.
+func deriveSymmetricKey(input []byte, domain string, length int) ([]byte, error) {
+	return hkdf.Key(sha256.New, input, nil, domain, length)
+}
+
+// TransportSecretKey is the secret key to which vetKeys are encrypted.
+type TransportSecretKey struct {
+	sk fr.Element
+}
+
+// NewTransportSecretKey generates a random transport secret key.
+func NewTransportSecretKey() (*TransportSecretKey, error) {
+	var tsk TransportSecretKey
+	if _, err := tsk.sk.SetRandom(); err != nil {
+		return nil, err
+	}
+	return &tsk, nil
+}
+
+// TransportSecretKeyFromBytes returns the transport secret key of the big-endian
+// encoded scalar, see Bytes.
+func TransportSecretKeyFromBytes(b []byte) (*TransportSecretKey, error) {
+	if len(b) != fr.Bytes {
+		return nil, fmt.Errorf("invalid transport secret key length: %d", len(b))
+	}
+	var tsk TransportSecretKey
+	if err := tsk.sk.SetBytesCanonical(b); err != nil {
+		return nil, fmt.Errorf("invalid transport secret key: %w", err)
+	}
+	return &tsk, nil
+}
+
+// Bytes returns the big-endian encoding of the secret key.
+func (tsk TransportSecretKey) Bytes() []byte {
+	b := tsk.sk.Bytes()
+	return b[:]
+}
+
+// DecryptAndVerify decrypts the encrypted vetKey returned by vetkd_derive_key, and
+// verifies that it is the vetKey of the input for the derived public key returned
+// by vetkd_public_key.
+func (tsk TransportSecretKey) DecryptAndVerify(encryptedKey []byte, derivedPublicKey *bls.PublicKey, input []byte) (*VetKey, error) {
+	if len(encryptedKey) != encryptedKeyLen {
+		return nil, fmt.Errorf("invalid encrypted key length: %d", len(encryptedKey))
+	}
+	var (
+		c1, c3 bls12381.G1Affine
+		c2     bls12381.G2Affine
+	)
+	if _, err := c1.SetBytes(encryptedKey[:g1Len]); err != nil {
+		return nil, fmt.Errorf("invalid encrypted key: %w", err)
+	}
+	if _, err := c2.SetBytes(encryptedKey[g1Len : g1Len+g2Len]); err != nil {
+		return nil, fmt.Errorf("invalid encrypted key: %w", err)
+	}
+	if _, err := c3.SetBytes(encryptedKey[g1Len+g2Len:]); err != nil {
+		return nil, fmt.Errorf("invalid encrypted key: %w", err)
+	}
+
+	// The randomness of c1 = r * G1 and c2 = r * G2 has to be the same.
+	if ok, err := bls12381.PairingCheck(
+		[]bls12381.G1Affine{c1, g1Gen},
+		[]bls12381.G2Affine{g2Gen, *new(bls12381.G2Affine).Neg(&c2)},
+	); err != nil || !ok {
+		return nil, fmt.Errorf("invalid encrypted key")
+	}
+
+	// k = c3 - tsk * c1
+	var mask, k bls12381.G1Affine
+	mask.ScalarMultiplication(&c1, tsk.sk.BigInt(new(big.Int)))
+	k.Sub(&c3, &mask)
+
+	key := VetKey{k: k}
+	if !key.Verify(derivedPublicKey, input) {
+		return nil, fmt.Errorf("invalid vetKey")
+	}
+	return &key, nil
+}
+
+// PublicKey returns the compressed G1 encoding of the transport public key, as
+// expected by vetkd_derive_key.
+func (tsk TransportSecretKey) PublicKey() []byte {
+	var pk bls12381.G1Affine
+	pk.ScalarMultiplicationBase(tsk.sk.BigInt(new(big.Int)))
+	b := pk.Bytes()
+	return b[:]
+}
+
+// VetKey is a decrypted vetKey, the BLS signature of the input by the derived key.
+type VetKey struct {
+	k bls12381.G1Affine
+}
+
+// VetKeyFromBytes returns the vetKey of the compressed G1 encoding, see Bytes. The
+// key is not verified.
+func VetKeyFromBytes(b []byte) (*VetKey, error) {
+	var key VetKey
+	if len(b) != g1Len {
+		return nil, fmt.Errorf("invalid vetKey length: %d", len(b))
+	}
+	if _, err := key.k.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("invalid vetKey: %w", err)
+	}
+	return &key, nil
+}
+
+// Bytes returns the compressed G1 encoding of the vetKey.
+func (key VetKey) Bytes() []byte {
+	b := key.k.Bytes()
+	return b[:]
+}
+
+// DeriveSymmetricKey derives a symmetric key of the given length from the vetKey,
+// with HKDF-SHA256 and the domain as info, e.g. for AES-GCM.
+func (key VetKey) DeriveSymmetricKey(domain string, length int) ([]byte, error) {
+	return deriveSymmetricKey(key.Bytes(), domain, length)
+}
+
+// Signature returns the vetKey as BLS signature.
+func (key VetKey) Signature() *bls.Signature {
+	k := key.k
+	return (*bls.Signature)(&k)
+}
+
+// Verify verifies that the vetKey is the vetKey of the input for the derived public
+// key.
+func (key VetKey) Verify(derivedPublicKey *bls.PublicKey, input []byte) bool {
+	h, err := augmentedHashToG1(derivedPublicKey, input)
+	if err != nil {
+		return false
+	}
+	ok, err := bls12381.PairingCheck(
+		[]bls12381.G1Affine{key.k, h},
+		[]bls12381.G2Affine{g2Neg, bls12381.G2Affine(*derivedPublicKey)},
+	)
+	return err == nil && ok
+}